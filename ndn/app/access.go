@@ -0,0 +1,296 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+// How long repeated requests are ignored after a request is denied
+const accessDenyTimeout = 24 * time.Hour
+
+// Local store prefix for the access request queues
+var accessStorePrefix, _ = enc.NameFromStr("/localhost/ownly/32=access")
+
+// AccessRequest is a request from a user to join a workspace we own.
+type AccessRequest struct {
+	// Requester is the identity name of the user.
+	Requester enc.Name
+	// Time is when the request was first received.
+	Time time.Time
+}
+
+// accessQueue holds the access requests to a workspace.
+// The queue is persisted in the app store, so requests survive restarts.
+type accessQueue struct {
+	mutex sync.Mutex
+	store ndn.Store
+	key   enc.Name
+
+	// Requests by requester name
+	requests map[string]*tlv.AccessRequest
+	// Latest pending Interest of each requester
+	interests map[string]ndn.InterestHandlerArgs
+}
+
+func newAccessQueue(store ndn.Store, group enc.Name) *accessQueue {
+	q := &accessQueue{
+		store:     store,
+		key:       accessStorePrefix.Append(group...),
+		requests:  make(map[string]*tlv.AccessRequest),
+		interests: make(map[string]ndn.InterestHandlerArgs),
+	}
+
+	// Load persisted requests
+	wire, _ := store.Get(q.key, false)
+	if wire != nil {
+		list, err := tlv.ParseAccessRequestList(enc.NewBufferView(wire), true)
+		if err != nil {
+			log.Warn(q, "Failed to parse access requests", "err", err)
+			return q
+		}
+		for _, req := range list.Requests {
+			q.requests[req.Requester] = req
+		}
+	}
+	return q
+}
+
+func (q *accessQueue) String() string {
+	return "access-queue"
+}
+
+// add adds a request, or refreshes the pending Interest of an existing one.
+// Returns true if this is a new request.
+func (q *accessQueue) add(requester enc.Name, args ndn.InterestHandlerArgs) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	key := requester.String()
+	now := time.Now()
+	if req := q.requests[key]; req != nil {
+		if !req.Denied {
+			q.interests[key] = args
+			return false
+		}
+		if now.Before(time.UnixMilli(int64(req.Time)).Add(accessDenyTimeout)) {
+			return false // recently denied
+		}
+	}
+
+	q.requests[key] = &tlv.AccessRequest{
+		Requester: key,
+		Time:      uint64(now.UnixMilli()),
+	}
+	q.interests[key] = args
+	q.persist()
+	return true
+}
+
+// has returns true if there is a pending request from the requester.
+func (q *accessQueue) has(requester enc.Name) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	req := q.requests[requester.String()]
+	return req != nil && !req.Denied
+}
+
+// take removes a pending request and returns its pending Interest, if any.
+// If deny is true, the request is kept to ignore repeated requests.
+func (q *accessQueue) take(requester enc.Name, deny bool) (args ndn.InterestHandlerArgs, err error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	key := requester.String()
+	req := q.requests[key]
+	if req == nil || req.Denied {
+		return args, fmt.Errorf("no access request from %s", requester)
+	}
+
+	args = q.interests[key]
+	delete(q.interests, key)
+	if deny {
+		req.Denied = true
+		req.Time = uint64(time.Now().UnixMilli())
+	} else {
+		delete(q.requests, key)
+	}
+	q.persist()
+	return args, nil
+}
+
+// list returns the pending requests, oldest first.
+func (q *accessQueue) list() []AccessRequest {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	reqs := make([]AccessRequest, 0, len(q.requests))
+	for _, req := range q.requests {
+		if req.Denied {
+			continue
+		}
+		requester, err := enc.NameFromStr(req.Requester)
+		if err != nil {
+			continue
+		}
+		reqs = append(reqs, AccessRequest{
+			Requester: requester,
+			Time:      time.UnixMilli(int64(req.Time)),
+		})
+	}
+	sort.Slice(reqs, func(i, j int) bool {
+		return reqs[i].Time.Before(reqs[j].Time)
+	})
+	return reqs
+}
+
+// persist writes the queue to the store. Must hold the mutex.
+func (q *accessQueue) persist() {
+	list := &tlv.AccessRequestList{
+		Requests: make([]*tlv.AccessRequest, 0, len(q.requests)),
+	}
+	for _, req := range q.requests {
+		// Forget denied requests after the timeout
+		if req.Denied && time.Since(time.UnixMilli(int64(req.Time))) > accessDenyTimeout {
+			continue
+		}
+		list.Requests = append(list.Requests, req)
+	}
+
+	if err := q.store.Put(q.key, list.Bytes()); err != nil {
+		log.Error(q, "Failed to persist access requests", "err", err)
+	}
+}
+
+// accessPrefix is the prefix of invitations and access requests.
+// /<wksp>/root/32=INVITE/<requester>
+func (w *Workspace) accessPrefix() enc.Name {
+	return w.group.
+		Append(enc.NewGenericComponent("root")).
+		Append(enc.NewKeywordComponent("INVITE"))
+}
+
+// onAccessRequest handles an access request Interest from a user.
+func (w *Workspace) onAccessRequest(args ndn.InterestHandlerArgs) {
+	name := args.Interest.Name()
+	prefix := w.accessPrefix()
+	if len(name) <= len(prefix) || !prefix.IsPrefix(name) {
+		return
+	}
+	requester := name[len(prefix):]
+
+	// Answer directly if we already signed an invitation
+	if invitation := w.renewInvitation(requester); invitation != nil {
+		args.Reply(invitation)
+		return
+	}
+
+	if w.access.add(requester, args) {
+		log.Info(w, "Received access request", "requester", requester)
+		w.emitAccessRequests()
+	}
+}
+
+// renewInvitation returns the invitation of a member that was already invited.
+// If the invitation is about to expire, a new one with the same lifetime is signed,
+// so that members can renew their certificates without approval.
+// Returns nil if the requester has no valid invitation or was removed.
+func (w *Workspace) renewInvitation(requester enc.Name) enc.Wire {
+	wire, _ := w.client.Store().Get(invitePrefix(w.group, requester), true)
+	if wire == nil {
+		return nil
+	}
+	data, _, err := spec.Spec{}.ReadData(enc.NewBufferView(wire))
+	if err != nil || len(data.Name()) != len(w.accessPrefix())+len(requester)+1 {
+		return nil
+	}
+	notBefore, notAfter, err := dataValidity(data)
+	if err != nil || time.Now().After(notAfter) || w.crypto.IsRemoved(requester) || w.isRevoked(requester) {
+		return nil
+	}
+	if !needsRenewal(notBefore, notAfter) {
+		return enc.Wire{wire}
+	}
+
+	// Keep the role of the member
+	role, err := inviteRole(w.group, requester, enc.Wire{wire})
+	if err != nil {
+		return nil
+	}
+	invitation, err := w.SignInvitation(requester, InviteOpts{
+		NotAfter: time.Now().Add(notAfter.Sub(notBefore)),
+		Role:     role,
+	})
+	if err != nil {
+		log.Error(w, "Failed to renew invitation", "requester", requester, "err", err)
+		return nil
+	}
+	log.Info(w, "Renewed invitation", "requester", requester)
+	return invitation
+}
+
+// emitAccessRequests sends the list of pending requests to the UI.
+func (w *Workspace) emitAccessRequests() {
+	w.app.ui.OnAccessRequests(w.group.String(), w.access.list())
+}
+
+// ListAccessRequests returns the pending access requests to the workspace.
+func (w *Workspace) ListAccessRequests() []AccessRequest {
+	return w.access.list()
+}
+
+// ApproveAccessRequest signs an invitation for the requester.
+// If the request Interest is still pending, it is answered with the invitation.
+// The invitation is also returned, so it can be published to the repo.
+func (w *Workspace) ApproveAccessRequest(requester enc.Name, opts InviteOpts) (enc.Wire, error) {
+	if !w.owner {
+		return nil, fmt.Errorf("only owners can approve access requests")
+	}
+
+	if !w.access.has(requester) {
+		return nil, fmt.Errorf("no access request from %s", requester)
+	}
+
+	// Keep the request if signing fails, so the owner can retry
+	invitation, err := w.SignInvitation(requester, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := w.access.take(requester, false)
+	if err != nil {
+		return nil, err
+	}
+	defer w.emitAccessRequests()
+
+	if args.Reply != nil && time.Now().Before(args.Deadline) {
+		if err := args.Reply(invitation); err != nil {
+			log.Warn(w, "Failed to answer access request", "requester", requester, "err", err)
+		}
+	}
+
+	log.Info(w, "Approved access request", "requester", requester)
+	return invitation, nil
+}
+
+// DenyAccessRequest removes the access request of the requester.
+// Further requests from the same user are ignored for a while.
+func (w *Workspace) DenyAccessRequest(requester enc.Name) error {
+	if !w.owner {
+		return fmt.Errorf("only owners can deny access requests")
+	}
+	if _, err := w.access.take(requester, true); err != nil {
+		return err
+	}
+	w.emitAccessRequests()
+
+	log.Info(w, "Denied access request", "requester", requester)
+	return nil
+}
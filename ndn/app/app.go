@@ -1,20 +1,16 @@
-//go:build js && wasm
-
 package app
 
 import (
-	"crypto/cipher"
 	"fmt"
-	"syscall/js"
-	"time"
+	"sync"
 
 	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
 	"github.com/named-data/ndnd/std/ndn"
-	"github.com/named-data/ndnd/std/object/storage"
+	"github.com/named-data/ndnd/std/object"
 	"github.com/named-data/ndnd/std/security"
-	"github.com/named-data/ndnd/std/security/keychain"
 	"github.com/named-data/ndnd/std/security/trust_schema"
-	jsutil "github.com/named-data/ndnd/std/utils/js"
+	"github.com/named-data/ndnd/std/types/optional"
 )
 
 type App struct {
@@ -23,167 +19,109 @@ type App struct {
 	store    ndn.Store
 	keychain ndn.KeyChain
 
-	// Trust config for testbed certs only
-	// In practice all trust configs are currently the same, but
-	// each workspace could theoretically have a different trust config.
+	// Platform-specific services
+	dialer FaceDialer
+	yjs    YjsMerger
+	ui     UI
+
+	// Networks known to the app, the first one is connected to
+	profiles []*NetworkProfile
+	// Routers of the network, and the face to the current one
+	routers     routerSet
+	rface       *routerFace
+	switchMutex sync.Mutex
+	// State of the connection to the current router
+	conn connMachine
+	// LAN-only mode, without the testbed (native only)
+	lan bool
+	// Closed when the app is stopped
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// Trust config for identity certs of all networks
+	// Each workspace has a trust config with the anchor of its network.
 	trust *security.TrustConfig
-
-	// Encryption keys
-	psk []byte
-	dsk []byte
-	aes cipher.Block
-	ivb uint64
-
-	// Pending DSK requests -> cancel function
-	dskReqs map[string]*time.Timer
 }
 
-var _ndnd_store_js = js.Global().Get("_ndnd_store_js")
-var _ndnd_keychain_js = js.Global().Get("_ndnd_keychain_js")
-
-// function(connected: boolean, router: string): void
-var _ndnd_conn_change_js = js.Global().Get("_ndnd_conn_change_js")
-
-func NewApp() *App {
-	// Setup JS shim store
-	store := storage.NewJsStore(_ndnd_store_js)
-
-	// Setup JS shim keychain
-	kc, err := keychain.NewKeyChainJS(_ndnd_keychain_js, store)
-	if err != nil {
-		panic(err)
+// New creates a new App on the given platform.
+func New(p Platform) (*App, error) {
+	if p.Store == nil || p.KeyChain == nil {
+		return nil, fmt.Errorf("platform must provide a store and keychain")
 	}
 
 	a := &App{
-		store:    store,
-		keychain: kc,
-		dskReqs:  make(map[string]*time.Timer),
+		store:    p.Store,
+		keychain: p.KeyChain,
+		dialer:   p.Dialer,
+		yjs:      p.Yjs,
+		ui:       p.UI,
+		profiles: p.Profiles,
+		stop:     make(chan struct{}),
 	}
-	a.initialize()
-	return a
-}
-
-func NewNodeApp() *App {
-	// NodeApp currently only supports consumer mode.
-	// If we want producer mode, we need a real store implementation.
-	// FS already works but badger may be too slow.
-	store := storage.NewMemoryStore()
-
-	// Setup directory keychain
-	// TODO: make this path configurable, maybe env variable
-	kc, err := keychain.NewKeyChainDir("./keychain", store)
-	if err != nil {
-		panic(err)
+	if a.ui == nil {
+		a.ui = nullUI{}
 	}
-
-	a := &App{
-		store:    store,
-		keychain: kc,
-		dskReqs:  make(map[string]*time.Timer),
+	if len(a.profiles) == 0 {
+		a.profiles = []*NetworkProfile{TestbedProfile()}
 	}
 
-	a.initialize()
-	return a
+	if err := a.initialize(); err != nil {
+		return nil, err
+	}
+	return a, nil
 }
 
-// Common initialization for both Node and WASM apps
-func (a *App) initialize() {
-	var err error
+// Stop stops the background tasks of the app and its engine.
+func (a *App) Stop() error {
+	a.stopOnce.Do(func() { close(a.stop) })
+	if a.engine != nil {
+		return a.engine.Stop()
+	}
+	return nil
+}
 
-	// Insert trust anchor
-	if err = a.keychain.InsertCert(testbedRootCert); err != nil {
-		panic(err)
+// Common initialization for all platforms
+func (a *App) initialize() (err error) {
+	// Insert trust anchors
+	anchors := make([]enc.Name, 0, len(a.profiles))
+	for _, p := range a.profiles {
+		if err = p.init(); err != nil {
+			return err
+		}
+		if err = a.keychain.InsertCert(p.AnchorCert); err != nil {
+			return err
+		}
+		anchors = append(anchors, p.anchorName)
 	}
 
-	// Testbed trust config
-	a.trust, err = getTrustConfig(a.keychain)
+	// Trust config for identities of any network
+	a.trust, err = getTrustConfig(a.keychain, anchors)
 	if err != nil {
-		panic(err)
+		return err
 	}
+
+	return nil
 }
 
 func (a *App) String() string {
 	return "app"
 }
 
-func (a *App) JsApi() js.Value {
-	api := map[string]any{
-		// has_testbed_key(): Promise<boolean>;
-		"has_testbed_key": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			key, _ := a.GetTestbedKey()
-			return key != nil, nil
-		}),
-
-		// is_testbed_cert_expiring_soon(): Promise<boolean>;
-		"is_testbed_cert_expiring_soon": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			// Check if certificate expires within one week
-			_, notAfter := a.GetTestbedKey()
-			return notAfter.Before(time.Now().Add(7 * 24 * time.Hour)), nil
-		}),
-
-		// get_identity_name(): Promise<string>;
-		"get_identity_name": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			key, _ := a.GetTestbedKey()
-			if key == nil {
-				return nil, fmt.Errorf("no testbed key")
-			}
-			return js.ValueOf(key.KeyName().Prefix(-2).String()), nil
-		}),
-
-		// connect_testbed(): Promise<void>;
-		"connect_testbed": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			return nil, a.ConnectTestbed()
-		}),
-
-		// ndncert_email(email: string, code: (status: string) => Promise<string>): Promise<void>;
-		"ndncert_email": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			return nil, a.NdncertEmail(p[0].String(), func(status string) string {
-				code, err := jsutil.Await(p[1].Invoke(status))
-				if err != nil {
-					return ""
-				}
-				return code.String()
-			})
-		}),
-
-		// ndncert_dns(domain: string, confirm: (recordName: string, recordValue: string, status: string) => Promise<string>): Promise<void>;
-		"ndncert_dns": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			return nil, a.NdncertDns(p[0].String(), func(recordName, expectedValue, status string) string {
-				confirmation, err := jsutil.Await(p[1].Invoke(recordName, expectedValue, status))
-				if err != nil {
-					return ""
-				}
-				return confirmation.String()
-			})
-		}),
-
-		// join_workspace(wksp: string, create: boolean): Promise<string>;
-		"join_workspace": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			return a.JoinWorkspace(p[0].String(), p[1].Bool())
-		}),
-
-		// is_workspace_owner(wksp: string): Promise<boolean>;
-		"is_workspace_owner": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			return a.IsWorkspaceOwner(p[0].String())
-		}),
-
-		// get_workspace(name: string, ignore: boolean): Promise<WorkspaceAPI>;
-		"get_workspace": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			return a.GetWorkspace(p[0].String(), p[1].Bool())
-		}),
-	}
-
-	return js.ValueOf(api)
+// IsLan returns true if the app runs in LAN-only mode.
+// There is no repo or NDNCERT in this mode, and peers serve
+// each other the certificates and blobs they have cached.
+func (a *App) IsLan() bool {
+	return a.lan
 }
 
-// GetTestbedKey returns an instance of the trust configuration
-func getTrustConfig(keychain ndn.KeyChain) (trust *security.TrustConfig, err error) {
+// getTrustConfig returns an instance of the trust configuration with the given anchors
+func getTrustConfig(keychain ndn.KeyChain, anchors []enc.Name) (trust *security.TrustConfig, err error) {
 	schema, err := trust_schema.NewLvsSchema(SchemaBytes)
 	if err != nil {
 		return
 	}
 
-	trust, err = security.NewTrustConfig(keychain, schema, []enc.Name{testbedRootName})
+	trust, err = security.NewTrustConfig(keychain, schema, anchors)
 	if err != nil {
 		return
 	}
@@ -191,3 +129,48 @@ func getTrustConfig(keychain ndn.KeyChain) (trust *security.TrustConfig, err err
 
 	return
 }
+
+// validateData checks a Data packet against the trust schema.
+// Missing certificates are fetched from the network.
+func (a *App) validateData(data ndn.Data, sigCov enc.Wire) error {
+	ch := make(chan error, 1)
+	a.trust.Validate(security.TrustConfigValidateArgs{
+		Data:              data,
+		DataSigCov:        sigCov,
+		UseDataNameFwHint: optional.Some(false),
+		Fetch: func(name enc.Name, cfg *ndn.InterestConfig, callback ndn.ExpressCallbackFunc) {
+			object.ExpressR(a.engine, ndn.ExpressRArgs{
+				Name:     name,
+				Config:   cfg,
+				Retries:  3,
+				TryStore: a.store,
+				Callback: callback,
+			})
+		},
+		Callback: func(valid bool, err error) {
+			if err == nil && !valid {
+				err = fmt.Errorf("signature is not trusted")
+			}
+			ch <- err
+		},
+	})
+	return <-ch
+}
+
+// serveCached answers Interests under the prefix with Data from the store.
+// This is used in LAN-only mode, where there is no repo. Interests for
+// fresh Data are left to the producers, since the store may be stale.
+func (a *App) serveCached(prefix enc.Name) error {
+	return a.engine.AttachHandler(prefix, func(args ndn.InterestHandlerArgs) {
+		if args.Interest.MustBeFresh() {
+			return
+		}
+		wire, _ := a.store.Get(args.Interest.Name(), args.Interest.CanBePrefix())
+		if wire == nil {
+			return
+		}
+		if err := args.Reply(enc.Wire{wire}); err != nil {
+			log.Debug(a, "Failed to serve cached data", "name", args.Interest.Name(), "err", err)
+		}
+	})
+}
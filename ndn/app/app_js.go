@@ -0,0 +1,265 @@
+//go:build js && wasm
+
+package app
+
+import (
+	"fmt"
+	"syscall/js"
+	"time"
+
+	"github.com/named-data/ndnd/std/engine/face"
+	"github.com/named-data/ndnd/std/ndn"
+	"github.com/named-data/ndnd/std/object/storage"
+	"github.com/named-data/ndnd/std/security/keychain"
+	jsutil "github.com/named-data/ndnd/std/utils/js"
+)
+
+var _ndnd_store_js = js.Global().Get("_ndnd_store_js")
+var _ndnd_keychain_js = js.Global().Get("_ndnd_keychain_js")
+
+// function(updates: Uint8Array[]): Uint8Array
+var _yjs_merge_updates = js.Global().Get("_yjs_merge_updates")
+
+// function(connected: boolean, router: { uri: string, rtt: number }, state: string): void
+var _ndnd_conn_change_js = js.Global().Get("_ndnd_conn_change_js")
+
+// function(wksp: string, requests: { requester: string, time: number }[]): void
+var _ndnd_access_requests_js = js.Global().Get("_ndnd_access_requests_js")
+
+// JSON string of the network profiles, or undefined for the testbed
+var _ndnd_network_profiles_js = js.Global().Get("_ndnd_network_profiles_js")
+
+func NewApp() *App {
+	// Setup JS shim store
+	store := storage.NewJsStore(_ndnd_store_js)
+
+	// Setup JS shim keychain
+	kc, err := keychain.NewKeyChainJS(_ndnd_keychain_js, store)
+	if err != nil {
+		panic(err)
+	}
+
+	a, err := New(Platform{
+		Store:    store,
+		KeyChain: kc,
+		Dialer:   wasmWsDialer{},
+		Yjs:      jsYjsMerger{},
+		UI:       jsUI{},
+		Profiles: jsNetworkProfiles(),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// jsNetworkProfiles parses the network profiles given by JS, if any.
+func jsNetworkProfiles() []*NetworkProfile {
+	if _ndnd_network_profiles_js.Type() != js.TypeString {
+		return nil
+	}
+	profiles, err := ParseNetworkProfiles([]byte(_ndnd_network_profiles_js.String()))
+	if err != nil {
+		panic(err)
+	}
+	return profiles
+}
+
+func (a *App) JsApi() js.Value {
+	api := map[string]any{
+		// has_testbed_key(): Promise<boolean>;
+		"has_testbed_key": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			key, _ := a.GetTestbedKey()
+			return key != nil, nil
+		}),
+
+		// is_testbed_cert_expiring_soon(): Promise<boolean>;
+		"is_testbed_cert_expiring_soon": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			// Check if certificate expires within one week
+			_, notAfter := a.GetTestbedKey()
+			return notAfter.Before(time.Now().Add(7 * 24 * time.Hour)), nil
+		}),
+
+		// get_identity_name(): Promise<string>;
+		"get_identity_name": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			key, _ := a.GetTestbedKey()
+			if key == nil {
+				return nil, fmt.Errorf("no testbed key")
+			}
+			return js.ValueOf(key.KeyName().Prefix(-2).String()), nil
+		}),
+
+		// connect_testbed(): Promise<void>;
+		"connect_testbed": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, a.ConnectTestbed()
+		}),
+
+		// list_routers(): Promise<{ uri: string, rtt: number, reachable: boolean, current: boolean }[]>;
+		"list_routers": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			arr := js.Global().Get("Array").New()
+			for _, router := range a.ListRouters() {
+				arr.Call("push", jsRouterInfo(router))
+			}
+			return arr, nil
+		}),
+
+		// set_router(uri: string): Promise<void>;
+		"set_router": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, a.SetRouter(p[0].String())
+		}),
+
+		// ndncert_email(email: string, code: (status: string) => Promise<string>): Promise<void>;
+		"ndncert_email": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, a.NdncertEmail(p[0].String(), func(status string) string {
+				code, err := jsutil.Await(p[1].Invoke(status))
+				if err != nil {
+					return ""
+				}
+				return code.String()
+			})
+		}),
+
+		// ndncert_dns(domain: string, confirm: (recordName: string, recordValue: string, status: string) => Promise<string>): Promise<void>;
+		"ndncert_dns": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, a.NdncertDns(p[0].String(), func(recordName, expectedValue, status string) string {
+				confirmation, err := jsutil.Await(p[1].Invoke(recordName, expectedValue, status))
+				if err != nil {
+					return ""
+				}
+				return confirmation.String()
+			})
+		}),
+
+		// network_profiles(): Promise<string[]>;
+		"network_profiles": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			arr := js.Global().Get("Array").New()
+			for _, profile := range a.NetworkProfiles() {
+				arr.Call("push", js.ValueOf(profile.Name))
+			}
+			return arr, nil
+		}),
+
+		// set_workspace_profile(wksp: string, profile: string): Promise<void>;
+		"set_workspace_profile": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, a.SetWorkspaceProfile(p[0].String(), p[1].String())
+		}),
+
+		// get_workspace_profile(wksp: string): Promise<string>;
+		"get_workspace_profile": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return a.WorkspaceProfile(p[0].String())
+		}),
+
+		// join_workspace(wksp: string, create: boolean, label?: string): Promise<string>;
+		"join_workspace": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			label := ""
+			if len(p) > 2 && p[2].Type() == js.TypeString {
+				label = p[2].String()
+			}
+			return a.JoinWorkspace(p[0].String(), p[1].Bool(), label)
+		}),
+
+		// get_workspace_info(wksp: string): Promise<WorkspaceInfo>;
+		"get_workspace_info": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			info, err := a.GetWorkspaceInfo(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+			return js.ValueOf(map[string]any{
+				"name":          info.Name.String(),
+				"label":         info.Label,
+				"owner":         info.Owner.String(),
+				"created":       info.Created.UnixMilli(),
+				"schemaVersion": info.SchemaVersion,
+				"repo":          info.Repo.String(),
+				"repos":         jsNames(info.Repos),
+				"algorithm":     info.Algorithm,
+			}), nil
+		}),
+
+		// renew_workspace_cert(wksp: string): Promise<boolean>;
+		"renew_workspace_cert": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return a.RenewWorkspaceCert(p[0].String())
+		}),
+
+		// accept_coowner_grant(wksp: string): Promise<void>;
+		"accept_coowner_grant": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, a.AcceptCoOwnerGrant(p[0].String())
+		}),
+
+		// is_workspace_owner(wksp: string): Promise<boolean>;
+		"is_workspace_owner": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return a.IsWorkspaceOwner(p[0].String())
+		}),
+
+		// get_workspace(name: string, ignore: boolean): Promise<WorkspaceAPI>;
+		"get_workspace": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			wksp, err := a.GetWorkspace(p[0].String(), p[1].Bool())
+			if err != nil {
+				return nil, err
+			}
+			return wksp.JsApi(), nil
+		}),
+	}
+
+	return js.ValueOf(api)
+}
+
+// wasmWsDialer connects to routers over WebSocket.
+// The routers are given by the network profile or FCH.
+type wasmWsDialer struct{}
+
+func (wasmWsDialer) Transport() string {
+	return "wss"
+}
+
+func (wasmWsDialer) DefaultRouter() string {
+	return ""
+}
+
+func (wasmWsDialer) Dial(endpoint string) (ndn.Face, error) {
+	return face.NewWasmWsFace(endpoint, false), nil
+}
+
+// jsYjsMerger merges Yjs updates using the JS Yjs library.
+type jsYjsMerger struct{}
+
+func (jsYjsMerger) MergeUpdates(updates [][]byte) ([]byte, error) {
+	updatesJs := js.Global().Get("Array").New()
+	for _, update := range updates {
+		updatesJs.Call("push", jsutil.SliceToJsArray(update))
+	}
+	return jsutil.JsArrayToSlice(_yjs_merge_updates.Invoke(updatesJs)), nil
+}
+
+// jsUI forwards notifications to the JS globals.
+type jsUI struct{}
+
+func (jsUI) OnConnChange(state ConnState, router RouterInfo) {
+	_ndnd_conn_change_js.Invoke(state == ConnUp, jsRouterInfo(router), state.String())
+}
+
+// jsRouterInfo converts a router to a JS object.
+func jsRouterInfo(router RouterInfo) js.Value {
+	return js.ValueOf(map[string]any{
+		"uri":       router.URI,
+		"rtt":       router.RTT.Milliseconds(),
+		"reachable": router.Reachable,
+		"current":   router.Current,
+	})
+}
+
+func (jsUI) OnAccessRequests(wksp string, requests []AccessRequest) {
+	_ndnd_access_requests_js.Invoke(wksp, jsAccessRequests(requests))
+}
+
+// jsAccessRequests converts access requests to a JS array.
+func jsAccessRequests(requests []AccessRequest) js.Value {
+	list := js.Global().Get("Array").New()
+	for _, req := range requests {
+		list.Call("push", js.ValueOf(map[string]any{
+			"requester": req.Requester.String(),
+			"time":      req.Time.UnixMilli(),
+		}))
+	}
+	return list
+}
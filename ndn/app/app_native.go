@@ -0,0 +1,143 @@
+//go:build !js
+
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/named-data/ndnd/std/engine/face"
+	"github.com/named-data/ndnd/std/ndn"
+	"github.com/named-data/ndnd/std/object/storage"
+	"github.com/named-data/ndnd/std/security/keychain"
+)
+
+// DefaultRouter is the default local forwarder for native apps.
+const DefaultRouter = "unix:///run/nfd/nfd.sock"
+
+// NativeOpts are the options for creating a native App.
+type NativeOpts struct {
+	// KeyChainDir is the directory of the keychain.
+	KeyChainDir string
+	// StateDir is the directory for persistent state.
+	// If empty, an in-memory store is used.
+	StateDir string
+	// Router is the forwarder to connect to, e.g. unix:///run/nfd/nfd.sock
+	// or tcp://localhost:6363, or a multicast group such as DefaultLanGroup.
+	// Defaults to DefaultRouter, or DefaultLanGroup in LAN-only mode.
+	Router string
+	// Lan enables the LAN-only mode, where the app does not need the testbed.
+	// Peers exchange packets directly over UDP multicast, or through the
+	// local forwarder if Router is one, which must then forward the
+	// workspace prefixes on its multicast faces.
+	Lan bool
+	// LanInterface is the network interface for multicast (optional).
+	LanInterface string
+	// UI receives notifications (optional).
+	UI UI
+	// Profiles are the NDN networks known to the app (optional).
+	// Identities are taken from the first one. Defaults to the testbed.
+	Profiles []*NetworkProfile
+}
+
+// NewNativeApp creates an App for native (non-WASM) environments.
+func NewNativeApp(opts NativeOpts) (*App, error) {
+	if opts.KeyChainDir == "" {
+		return nil, fmt.Errorf("keychain directory is required")
+	}
+	if opts.Router == "" {
+		opts.Router = DefaultRouter
+		if opts.Lan {
+			opts.Router = DefaultLanGroup
+		}
+	}
+
+	var dialer FaceDialer
+	var err error
+	if strings.HasPrefix(opts.Router, "udp4://") {
+		dialer, err = NewMulticastDialer(opts.Router, opts.LanInterface)
+	} else {
+		dialer, err = NewStreamDialer(opts.Router)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Badger store if we have a state directory
+	var store ndn.Store = storage.NewMemoryStore()
+	if opts.StateDir != "" {
+		storeDir := filepath.Join(opts.StateDir, "store")
+		if err := os.MkdirAll(storeDir, 0700); err != nil {
+			return nil, err
+		}
+		if store, err = storage.NewBadgerStore(storeDir); err != nil {
+			return nil, fmt.Errorf("failed to open store: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(opts.KeyChainDir, 0700); err != nil {
+		return nil, err
+	}
+	kc, err := keychain.NewKeyChainDir(opts.KeyChainDir, store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open keychain: %w", err)
+	}
+
+	a, err := New(Platform{
+		Store:    store,
+		KeyChain: kc,
+		Dialer:   dialer,
+		UI:       opts.UI,
+		Profiles: opts.Profiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.lan = opts.Lan
+	return a, nil
+}
+
+// StreamDialer connects directly to a forwarder over a TCP or Unix socket.
+type StreamDialer struct {
+	network string
+	addr    string
+	router  string
+}
+
+// NewStreamDialer creates a dialer for a forwarder URI, e.g. tcp://localhost:6363
+func NewStreamDialer(router string) (*StreamDialer, error) {
+	uri, err := url.Parse(router)
+	if err != nil {
+		return nil, fmt.Errorf("invalid router %s: %w", router, err)
+	}
+
+	d := &StreamDialer{network: uri.Scheme, router: router}
+	switch uri.Scheme {
+	case "unix":
+		d.addr = uri.Path
+	case "tcp", "tcp4", "tcp6":
+		d.addr = uri.Host
+	default:
+		return nil, fmt.Errorf("unsupported router scheme: %s", uri.Scheme)
+	}
+	return d, nil
+}
+
+// Transport is empty since the forwarder is known (no FCH).
+func (d *StreamDialer) Transport() string {
+	return ""
+}
+
+func (d *StreamDialer) DefaultRouter() string {
+	return d.router
+}
+
+func (d *StreamDialer) Dial(endpoint string) (ndn.Face, error) {
+	if endpoint != d.router {
+		return nil, fmt.Errorf("unknown router: %s", endpoint)
+	}
+	return face.NewStreamFace(d.network, d.addr, d.network == "unix"), nil
+}
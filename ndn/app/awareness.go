@@ -1,5 +1,3 @@
-//go:build js && wasm
-
 package app
 
 import (
@@ -19,6 +17,9 @@ type Awareness struct {
 	Name   enc.Name
 	Client ndn.Client
 	OnData func(enc.Wire)
+	// Validate checks an update, e.g. against the trust schema and the
+	// revocation list. Updates are validated with the client if not set.
+	Validate func(data ndn.Data, sigCov enc.Wire) error
 }
 
 func (a *Awareness) String() string {
@@ -42,6 +43,18 @@ func (a *Awareness) Start() error {
 			return
 		}
 
+		if a.Validate != nil {
+			// Validation may fetch certificates, so do not block the handler
+			go func() {
+				if err := a.Validate(data, sigCov); err != nil {
+					log.Warn(a, "failed to validate update", "name", data.Name(), "err", err)
+					return
+				}
+				a.OnData(data.Content())
+			}()
+			return
+		}
+
 		a.Client.Validate(data, sigCov, func(valid bool, err error) {
 			if !valid || err != nil {
 				log.Warn(a, "failed to validate signature", "name", data.Name(), "valid", valid, "err", err)
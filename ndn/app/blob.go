@@ -0,0 +1,543 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	"github.com/named-data/ndnd/std/types/optional"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+// Plaintext bytes in a segment of a blob. This leaves room in a packet
+// for the name, the signature and the AEAD overhead.
+const blobSegmentSize = 7 * 1024
+
+// Default number of segments fetched in parallel
+const blobFetchWindow = 8
+
+// Number of failed attempts to fetch a segment while online
+const blobFetchRetries = 5
+
+// Local store prefix for the fetch state of blobs
+//
+// /localhost/ownly/32=blob/<blob>/32=verified is the number of segments,
+// from the first, that were validated and stored
+// /localhost/ownly/32=blob/<blob>/32=refs is the number of documents
+// that reference a content-addressed blob
+var blobStorePrefix, _ = enc.NameFromStr("/localhost/ownly/32=blob")
+
+// errBlobCanceled is returned when a blob fetch is canceled.
+var errBlobCanceled = errors.New("blob fetch canceled")
+
+// BlobProgress is called after each segment of a blob is produced or fetched,
+// with the number of bytes done and the size of the blob.
+type BlobProgress func(done int64, total int64)
+
+// blobSegments returns the number of segments of a blob of the given size.
+// An empty blob has a single empty segment.
+func blobSegments(size int64) uint64 {
+	return max(1, uint64((size+blobSegmentSize-1)/blobSegmentSize))
+}
+
+// BlobFetchOpts are the options of FetchBlob.
+type BlobFetchOpts struct {
+	// Window is the number of segments fetched in parallel.
+	// The default is 8.
+	Window int
+	// Progress is called after each segment is written.
+	Progress BlobProgress
+	// Cancel stops the fetch when closed. The segments fetched so far
+	// are kept in the store, so the fetch can be resumed later.
+	Cancel <-chan struct{}
+}
+
+// BlobPublishOpts are the options of PublishBlob.
+type BlobPublishOpts struct {
+	// Dedup names the blob by the digest of its content, so the same
+	// content is only produced and sent to the repo once.
+	Dedup bool
+	// Progress is called after each segment is produced.
+	Progress BlobProgress
+}
+
+// blobRef returns the name of a blob as published in the project,
+// which carries the keyed digest of its content.
+func blobRef(blobName enc.Name, digest []byte) enc.Name {
+	return blobName.Append(
+		enc.NewKeywordComponent("digest"),
+		enc.NewGenericBytesComponent(digest),
+	)
+}
+
+// splitBlobRef returns the name and digest of a blob from its published name.
+// Content-addressed blobs are their own reference.
+// The digest is nil for names without a digest.
+func splitBlobRef(ref enc.Name) (enc.Name, []byte) {
+	if len(ref) >= 2 {
+		if ref.At(-2).Equal(enc.NewKeywordComponent("digest")) {
+			return ref.Prefix(-2), ref.At(-1).Val
+		}
+		if ref.At(-2).Equal(enc.NewKeywordComponent("cas")) {
+			return ref, ref.At(-1).Val
+		}
+	}
+	return ref, nil
+}
+
+// blobPrefix is the prefix of the blobs of a document in the project.
+func (s *SvsAlo) blobPrefix(uuid string) enc.Name {
+	return s.alo.DataPrefix().Append(
+		enc.NewKeywordComponent("blob"),
+		enc.NewGenericComponent(uuid),
+	)
+}
+
+// casBlobName is the name of a content-addressed blob in the project.
+func (s *SvsAlo) casBlobName(digest []byte) enc.Name {
+	return s.alo.DataPrefix().Append(
+		enc.NewKeywordComponent("blob"),
+		enc.NewKeywordComponent("cas"),
+		enc.NewGenericBytesComponent(digest),
+	)
+}
+
+// PublishBlob encrypts a blob of the given size read from r and produces it
+// under <data-prefix>/32=blob/<uuid>/v=<time>. Each segment is encrypted and
+// signed as it is read, so the blob is never held in memory. The repo is sent
+// BlobFetch only once the last segment is produced.
+// Only editors can publish blobs. Returns the name of the blob with the
+// digest of its content, which is what should be published in the project.
+//
+// With the Dedup option, the blob is named <data-prefix>/32=blob/32=cas/<digest>
+// instead, and is counted as referenced once more. Nothing is produced or sent
+// to the repo if the blob is already in the store or the repo.
+func (s *SvsAlo) PublishBlob(uuid string, r io.ReaderAt, size int64, opts BlobPublishOpts) (enc.Name, error) {
+	if err := s.checkPublish(""); err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid blob size: %d", size)
+	}
+	if opts.Dedup {
+		return s.publishBlobDedup(r, size, opts.Progress)
+	}
+
+	digest, err := s.wksp.crypto.blobHash()
+	if err != nil {
+		return nil, err
+	}
+	blobName := s.blobPrefix(uuid).WithVersion(enc.VersionUnixMicro)
+	content := io.TeeReader(io.NewSectionReader(r, 0, size), digest)
+	if err := s.produceBlob(blobName, content, size, opts.Progress); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.PubBlobFetch(blobName, nil); err != nil {
+		return nil, err
+	}
+	return blobRef(blobName, digest.Sum(nil)), nil
+}
+
+// publishBlobDedup publishes a content-addressed blob.
+func (s *SvsAlo) publishBlobDedup(r io.ReaderAt, size int64, progress BlobProgress) (enc.Name, error) {
+	// The name is only known once all of the content is read
+	digest, err := s.wksp.crypto.blobHash()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(digest, io.NewSectionReader(r, 0, size)); err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	blobName := s.casBlobName(digest.Sum(nil))
+
+	switch {
+	case s.blobVerified(blobName) >= blobSegments(size):
+		log.Info(s, "Blob is already in the store", "name", blobName)
+		s.indexBlob(blobName)
+		if progress != nil {
+			progress(size, size)
+		}
+	case !s.wksp.app.lan && s.wksp.repoc.hasBlob(blobName):
+		log.Info(s, "Blob is already in the repo", "name", blobName)
+		if progress != nil {
+			progress(size, size)
+		}
+	default:
+		if err := s.produceBlob(blobName, io.NewSectionReader(r, 0, size), size, progress); err != nil {
+			return nil, err
+		}
+		if _, err := s.PubBlobFetch(blobName, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	s.addBlobRef(blobName, 1)
+	return blobName, nil
+}
+
+// produceBlob encrypts, signs and stores the segments of a blob.
+// A partial blob is removed from the store if this fails.
+func (s *SvsAlo) produceBlob(blobName enc.Name, r io.Reader, size int64, progress BlobProgress) error {
+	signer := s.client.SuggestSigner(blobName)
+	if signer == nil {
+		return fmt.Errorf("no valid signing key for %s", blobName)
+	}
+
+	if err := s.produceSegments(blobName, r, size, signer, progress); err != nil {
+		// Do not leave a partial blob in the store
+		if err := s.client.Store().RemovePrefix(blobName); err != nil {
+			log.Warn(s, "Failed to remove partial blob", "name", blobName, "err", err)
+		}
+		return err
+	}
+	log.Info(s, "Produced blob", "name", blobName, "size", size)
+
+	// Our own segments need no validation when fetched
+	s.setBlobVerified(blobName, blobSegments(size))
+	return nil
+}
+
+// produceSegments encrypts, signs and stores each segment of a blob.
+func (s *SvsAlo) produceSegments(blobName enc.Name, r io.Reader, size int64, signer ndn.Signer, progress BlobProgress) error {
+	store := s.client.Store()
+	count := blobSegments(size)
+	final := enc.NewSegmentComponent(count - 1)
+
+	buf := make([]byte, blobSegmentSize)
+	var done int64
+	for seg := uint64(0); seg < count; seg++ {
+		chunk := buf[:min(size-done, blobSegmentSize)]
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("failed to read blob at %d of %d bytes: %w", done, size, err)
+		}
+
+		segName := blobName.Append(enc.NewSegmentComponent(seg))
+		msg, err := s.wksp.crypto.encryptBlob(chunk, segName, s.proj)
+		if err != nil {
+			return err
+		}
+
+		data, err := spec.Spec{}.MakeData(segName, &ndn.DataConfig{
+			ContentType:  optional.Some(ndn.ContentTypeBlob),
+			FinalBlockID: optional.Some(final),
+		}, msg.Encode(), signer)
+		if err != nil {
+			return err
+		}
+		if err := store.Put(segName, data.Wire.Join()); err != nil {
+			return err
+		}
+
+		done += int64(len(chunk))
+		if progress != nil {
+			progress(done, size)
+		}
+	}
+	return nil
+}
+
+// FetchBlob fetches and decrypts a blob published with PublishBlob, and
+// writes it to w in order. Segments are fetched in parallel within a window,
+// validated and kept in the store, so a fetch that failed or was canceled
+// resumes after the last verified segment. Fetches wait for the face to
+// come back up instead of failing. If the name carries a digest,
+// the content is checked against it.
+func (s *SvsAlo) FetchBlob(ref enc.Name, w io.Writer, opts BlobFetchOpts) error {
+	blobName, digest := splitBlobRef(ref)
+	window := opts.Window
+	if window <= 0 {
+		window = blobFetchWindow
+	}
+
+	// Stops the fetches in flight when we return
+	stop := make(chan struct{})
+	defer close(stop)
+	f := &blobFetch{
+		s:        s,
+		name:     blobName,
+		verified: s.blobVerified(blobName),
+		cancel:   opts.Cancel,
+		stop:     stop,
+	}
+
+	var h hash.Hash
+	if digest != nil {
+		var err error
+		if h, err = s.wksp.crypto.blobHash(); err != nil {
+			return err
+		}
+		w = io.MultiWriter(w, h)
+	}
+
+	// The first segment tells the number of segments
+	first := f.segment(0)
+	if first.err != nil {
+		return first.err
+	}
+	s.indexBlob(blobName)
+	count := first.count
+	size := int64(count) * blobSegmentSize // estimate until the last segment
+
+	// Results of the segments in flight, by segment modulo window.
+	// A segment is only fetched once the one a window before is written,
+	// so at most a window of segments is held in memory.
+	inflight := make([]chan blobSegment, window)
+	fetch := func(seg uint64) {
+		ch := make(chan blobSegment, 1)
+		inflight[seg%uint64(window)] = ch
+		go func() { ch <- f.segment(seg) }()
+	}
+	for seg := uint64(1); seg < count && seg <= uint64(window); seg++ {
+		fetch(seg)
+	}
+
+	var done int64
+	for seg := uint64(0); seg < count; seg++ {
+		res := first
+		if seg > 0 {
+			select {
+			case res = <-inflight[seg%uint64(window)]:
+			case <-opts.Cancel:
+				return errBlobCanceled
+			}
+			if res.err != nil {
+				return res.err
+			}
+			if next := seg + uint64(window); next < count {
+				fetch(next)
+			}
+		}
+
+		if _, err := w.Write(res.chunk); err != nil {
+			return err
+		}
+		// Remember the verified segments every window, for resuming
+		if seg >= f.verified && (seg%uint64(window) == 0 || seg == count-1) {
+			s.setBlobVerified(blobName, seg+1)
+		}
+
+		done += int64(len(res.chunk))
+		if seg == count-1 {
+			size = done
+		}
+		if opts.Progress != nil {
+			opts.Progress(done, size)
+		}
+	}
+
+	if h != nil && !bytes.Equal(h.Sum(nil), digest) {
+		// Fetch again from scratch next time
+		if err := s.client.Store().RemovePrefix(blobName); err != nil {
+			log.Warn(s, "Failed to remove corrupt blob", "name", blobName, "err", err)
+		}
+		s.removeBlobVerified(blobName)
+		return fmt.Errorf("blob does not match its digest: %s", blobName)
+	}
+	return nil
+}
+
+// blobFetch is the state of a blob fetch.
+type blobFetch struct {
+	s    *SvsAlo
+	name enc.Name
+	// Number of segments that were verified before the fetch started
+	verified uint64
+	cancel   <-chan struct{}
+	stop     <-chan struct{}
+}
+
+// blobSegment is the decrypted content of a segment.
+type blobSegment struct {
+	chunk []byte
+	// Number of segments of the blob
+	count uint64
+	err   error
+}
+
+// segment fetches, validates and decrypts a segment. Segments that were
+// verified before are read from the store without validation.
+func (f *blobFetch) segment(seg uint64) blobSegment {
+	s := f.s
+	store := s.client.Store()
+	segName := f.name.Append(enc.NewSegmentComponent(seg))
+
+	var data ndn.Data
+	if seg < f.verified {
+		if wire, _ := store.Get(segName, false); wire != nil {
+			data, _, _ = spec.Spec{}.ReadData(enc.NewBufferView(wire))
+		}
+	}
+
+	if data == nil {
+		var raw, sigCov enc.Wire
+		var err error
+		if data, raw, sigCov, err = f.fetch(segName); err != nil {
+			return blobSegment{err: fmt.Errorf("failed to fetch segment %d of %s: %w", seg, f.name, err)}
+		}
+		if err := s.validateSegment(data, sigCov); err != nil {
+			return blobSegment{err: err}
+		}
+		if err := store.Put(segName, raw.Join()); err != nil {
+			log.Warn(s, "Failed to store blob segment", "name", segName, "err", err)
+		}
+	}
+
+	final, ok := data.FinalBlockID().Get()
+	if !ok || !final.IsSegment() {
+		return blobSegment{err: fmt.Errorf("blob has no final segment: %s", f.name)}
+	}
+
+	msg, err := tlv.ParseMessage(enc.NewWireView(data.Content()), true)
+	if err != nil {
+		return blobSegment{err: fmt.Errorf("invalid blob segment %s: %w", segName, err)}
+	}
+	chunk, err := s.wksp.crypto.decryptBlob(msg, segName, s.proj)
+	if err != nil {
+		return blobSegment{err: fmt.Errorf("failed to decrypt blob segment %s: %w", segName, err)}
+	}
+	return blobSegment{chunk: chunk, count: final.NumberVal() + 1}
+}
+
+// fetch fetches a segment from the store or the network. While the app is
+// offline, this waits for the face to come back up instead of failing.
+func (f *blobFetch) fetch(segName enc.Name) (ndn.Data, enc.Wire, enc.Wire, error) {
+	delay := time.Second
+	for attempt := 1; ; attempt++ {
+		ch := make(chan ndn.ExpressCallbackArgs, 1)
+		f.s.client.ExpressR(ndn.ExpressRArgs{
+			Name: segName,
+			Config: &ndn.InterestConfig{
+				Lifetime: optional.Some(4 * time.Second),
+			},
+			Retries:  2,
+			TryStore: f.s.client.Store(),
+			Callback: func(args ndn.ExpressCallbackArgs) { ch <- args },
+		})
+		args := <-ch
+		if args.Result == ndn.InterestResultData {
+			return args.Data, args.RawData, args.SigCovered, nil
+		}
+
+		err := args.Error
+		if err == nil {
+			err = fmt.Errorf("%s", args.Result)
+		}
+		online := f.s.wksp.app.isOnline()
+		if online && attempt >= blobFetchRetries {
+			return nil, nil, nil, err
+		}
+		if !online {
+			attempt-- // does not count while offline
+		}
+
+		select {
+		case <-f.cancel:
+			return nil, nil, nil, errBlobCanceled
+		case <-f.stop:
+			return nil, nil, nil, errBlobCanceled
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, 30*time.Second)
+	}
+}
+
+// validateSegment checks the signature of a segment with the trust schema.
+func (s *SvsAlo) validateSegment(data ndn.Data, sigCov enc.Wire) error {
+	if err := s.wksp.validate(data, sigCov); err != nil {
+		return fmt.Errorf("invalid blob segment: %w", err)
+	}
+	return nil
+}
+
+func blobVerifiedName(blobName enc.Name) enc.Name {
+	return blobStorePrefix.Append(blobName...).Append(enc.NewKeywordComponent("verified"))
+}
+
+// blobVerified returns the number of segments of the blob, from the first,
+// that were validated and stored.
+func (s *SvsAlo) blobVerified(blobName enc.Name) uint64 {
+	if buf, _ := s.wksp.app.store.Get(blobVerifiedName(blobName), false); len(buf) == 8 {
+		return binary.BigEndian.Uint64(buf)
+	}
+	return 0
+}
+
+func (s *SvsAlo) setBlobVerified(blobName enc.Name, count uint64) {
+	name := blobVerifiedName(blobName)
+	if err := s.wksp.app.store.Put(name, binary.BigEndian.AppendUint64(nil, count)); err != nil {
+		log.Warn(s, "Failed to persist blob fetch state", "name", blobName, "err", err)
+	}
+}
+
+func (s *SvsAlo) removeBlobVerified(blobName enc.Name) {
+	if err := s.wksp.app.store.Remove(blobVerifiedName(blobName)); err != nil {
+		log.Warn(s, "Failed to remove blob fetch state", "name", blobName, "err", err)
+	}
+}
+
+func blobRefsName(blobName enc.Name) enc.Name {
+	return blobStorePrefix.Append(blobName...).Append(enc.NewKeywordComponent("refs"))
+}
+
+// BlobRefs returns the number of times a content-addressed blob was published
+// with PublishBlob and not released. GcBlobs keeps blobs that are referenced.
+func (s *SvsAlo) BlobRefs(ref enc.Name) uint64 {
+	blobName, _ := splitBlobRef(ref)
+	if buf, _ := s.wksp.app.store.Get(blobRefsName(blobName), false); len(buf) == 8 {
+		return binary.BigEndian.Uint64(buf)
+	}
+	return 0
+}
+
+// blobRefs returns the reference count of a blob in the blob index.
+func (s *SvsAlo) blobRefs(key string) uint64 {
+	blobName, err := enc.NameFromStr(key)
+	if err != nil {
+		return 0
+	}
+	return s.BlobRefs(blobName)
+}
+
+// ReleaseBlob drops a reference to a content-addressed blob, when a document
+// no longer uses it. Returns the number of references left.
+func (s *SvsAlo) ReleaseBlob(ref enc.Name) uint64 {
+	blobName, _ := splitBlobRef(ref)
+	return s.addBlobRef(blobName, -1)
+}
+
+// addBlobRef changes the reference count of a blob, which never goes below zero.
+func (s *SvsAlo) addBlobRef(blobName enc.Name, delta int) uint64 {
+	s.wksp.blobMutex.Lock()
+	defer s.wksp.blobMutex.Unlock()
+
+	refs := s.BlobRefs(blobName)
+	if delta < 0 && refs < uint64(-delta) {
+		refs = 0
+	} else {
+		refs = uint64(int64(refs) + int64(delta))
+	}
+
+	name := blobRefsName(blobName)
+	var err error
+	if refs == 0 {
+		err = s.wksp.app.store.Remove(name)
+	} else {
+		err = s.wksp.app.store.Put(name, binary.BigEndian.AppendUint64(nil, refs))
+	}
+	if err != nil {
+		log.Warn(s, "Failed to persist blob references", "name", blobName, "err", err)
+	}
+	return refs
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/types/optional"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+// Default time an unreferenced blob is kept in the store
+const blobGcGrace = 7 * 24 * time.Hour
+
+// BlobGcOpts are the options of GcBlobs.
+type BlobGcOpts struct {
+	// Grace is how long a blob is kept in the store after it is first seen
+	// unreferenced. The default is 7 days.
+	Grace time.Duration
+}
+
+// BlobGcResult is the outcome of a blob collection.
+type BlobGcResult struct {
+	// Kept is the number of blobs that are referenced.
+	Kept int
+	// Expiring is the number of unreferenced blobs within the grace period.
+	Expiring int
+	// Removed are the blobs removed from the store.
+	Removed []enc.Name
+}
+
+// blobIndexName is the name of the index of the blobs of the project in the
+// local store, since the store cannot list them.
+//
+// /localhost/ownly/32=blob/32=index/<group> is the BlobIndex of a project
+func (s *SvsAlo) blobIndexName() enc.Name {
+	return blobStorePrefix.Append(enc.NewKeywordComponent("index")).Append(s.alo.GroupPrefix()...)
+}
+
+// loadBlobIndex reads the blob index of the project.
+// The caller must hold the blob mutex of the workspace.
+func (s *SvsAlo) loadBlobIndex() *tlv.BlobIndex {
+	wire, _ := s.wksp.app.store.Get(s.blobIndexName(), false)
+	if wire == nil {
+		return &tlv.BlobIndex{}
+	}
+	index, err := tlv.ParseBlobIndex(enc.NewBufferView(wire), true)
+	if err != nil {
+		log.Warn(s, "Invalid blob index, starting over", "err", err)
+		return &tlv.BlobIndex{}
+	}
+	return index
+}
+
+// saveBlobIndex writes the blob index of the project.
+// The caller must hold the blob mutex of the workspace.
+func (s *SvsAlo) saveBlobIndex(index *tlv.BlobIndex) {
+	if err := s.wksp.app.store.Put(s.blobIndexName(), index.Bytes()); err != nil {
+		log.Warn(s, "Failed to persist blob index", "err", err)
+	}
+}
+
+// indexBlob adds a blob to the index of the project, so that it is
+// considered by GcBlobs. Blobs are indexed when they are pinned with
+// BlobFetch and when they are fetched.
+func (s *SvsAlo) indexBlob(blobName enc.Name) {
+	s.wksp.blobMutex.Lock()
+	defer s.wksp.blobMutex.Unlock()
+
+	key := blobName.String()
+	index := s.loadBlobIndex()
+	for _, entry := range index.Blobs {
+		if entry.Name == key {
+			// Published or fetched again, so in use
+			if entry.Unreferenced.IsSet() {
+				entry.Unreferenced = optional.None[uint64]()
+				s.saveBlobIndex(index)
+			}
+			return
+		}
+	}
+	index.Blobs = append(index.Blobs, &tlv.BlobIndexEntry{Name: key})
+	s.saveBlobIndex(index)
+}
+
+// GcBlobs removes the blobs of the project from the local store that are no
+// longer referenced. The reachable names are the blobs referenced by the
+// latest state of the project documents, as published with PublishBlob or
+// PubBlobFetch. Unreferenced blobs are kept for a grace period first, since
+// the documents of the project may not be fully synced yet.
+func (s *SvsAlo) GcBlobs(reachable []enc.Name, opts BlobGcOpts) BlobGcResult {
+	grace := opts.Grace
+	if grace <= 0 {
+		grace = blobGcGrace
+	}
+
+	refs := make(map[string]bool, len(reachable))
+	for _, ref := range reachable {
+		blobName, _ := splitBlobRef(ref)
+		refs[blobName.String()] = true
+	}
+
+	s.wksp.blobMutex.Lock()
+	defer s.wksp.blobMutex.Unlock()
+
+	// Content-addressed blobs are also kept while counted as referenced,
+	// since the documents that publish them may not be synced yet
+	index := s.loadBlobIndex()
+	res, expired := sweepBlobIndex(index, func(name string) bool {
+		return refs[name] || s.blobRefs(name) > 0
+	}, time.Now(), grace)
+	for _, name := range expired {
+		blobName, err := enc.NameFromStr(name)
+		if err != nil {
+			continue // drop invalid entries
+		}
+		s.dropBlob(blobName)
+		res.Removed = append(res.Removed, blobName)
+	}
+	s.saveBlobIndex(index)
+
+	log.Info(s, "Collected blobs", "kept", res.Kept, "expiring", res.Expiring, "removed", len(res.Removed))
+	return res
+}
+
+// sweepBlobIndex updates the grace period of the entries of a blob index.
+// Referenced entries are kept, and unreferenced entries are kept until the
+// grace period has passed since they were first seen unreferenced.
+// The expired entries are removed from the index and returned.
+func sweepBlobIndex(
+	index *tlv.BlobIndex,
+	referenced func(name string) bool,
+	now time.Time,
+	grace time.Duration,
+) (res BlobGcResult, expired []string) {
+	nowMs := uint64(now.UnixMilli())
+	blobs := index.Blobs[:0]
+	for _, entry := range index.Blobs {
+		if referenced(entry.Name) {
+			entry.Unreferenced = optional.None[uint64]()
+			blobs = append(blobs, entry)
+			res.Kept++
+			continue
+		}
+
+		since, ok := entry.Unreferenced.Get()
+		if !ok {
+			entry.Unreferenced = optional.Some(nowMs)
+			since = nowMs
+		}
+		if nowMs-since < uint64(grace.Milliseconds()) {
+			blobs = append(blobs, entry)
+			res.Expiring++
+			continue
+		}
+		expired = append(expired, entry.Name)
+	}
+	index.Blobs = blobs
+	return res, expired
+}
+
+// RemoveBlob removes a blob from the local store, e.g. content that was
+// shared by mistake, and stops tracking whether the repos store it.
+// Only owners can remove blobs. The documents that reference the blob are
+// not changed. The repo protocol has no delete or unpin command (only
+// SyncJoin, SyncLeave and BlobFetch), so the repos keep the blob until it
+// is deleted on the repo itself.
+func (s *SvsAlo) RemoveBlob(ref enc.Name) error {
+	if !s.wksp.owner {
+		return fmt.Errorf("only owners can remove blobs")
+	}
+	blobName, _ := splitBlobRef(ref)
+
+	s.wksp.blobMutex.Lock()
+	index := s.loadBlobIndex()
+	for i, entry := range index.Blobs {
+		if entry.Name == blobName.String() {
+			index.Blobs = append(index.Blobs[:i], index.Blobs[i+1:]...)
+			s.saveBlobIndex(index)
+			break
+		}
+	}
+	s.dropBlob(blobName)
+	s.wksp.blobMutex.Unlock()
+
+	s.wksp.repoc.forgetBlob(s.alo.GroupPrefix(), blobName)
+	log.Warn(s, "Removed blob locally, the repos cannot delete it", "name", blobName)
+	return nil
+}
+
+// dropBlob removes the segments and local state of a blob from the store.
+// The caller must hold the blob mutex of the workspace.
+func (s *SvsAlo) dropBlob(blobName enc.Name) {
+	if err := s.client.Store().RemovePrefix(blobName); err != nil {
+		log.Warn(s, "Failed to remove blob", "name", blobName, "err", err)
+	}
+	if err := s.wksp.app.store.RemovePrefix(blobStorePrefix.Append(blobName...)); err != nil {
+		log.Warn(s, "Failed to remove blob state", "name", blobName, "err", err)
+	}
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/named-data/ndnd/std/types/optional"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+func TestSweepBlobIndex(t *testing.T) {
+	now := time.Now()
+	grace := time.Hour
+	since := func(d time.Duration) optional.Optional[uint64] {
+		return optional.Some(uint64(now.Add(-d).UnixMilli()))
+	}
+
+	tests := []struct {
+		name         string
+		entry        *tlv.BlobIndexEntry
+		referenced   bool
+		wantKept     bool
+		wantExpiring bool
+	}{
+		{"referenced", &tlv.BlobIndexEntry{Name: "/a"}, true, true, false},
+		{"referenced again", &tlv.BlobIndexEntry{Name: "/a", Unreferenced: since(2 * grace)}, true, true, false},
+		{"newly unreferenced", &tlv.BlobIndexEntry{Name: "/a"}, false, false, true},
+		{"within grace", &tlv.BlobIndexEntry{Name: "/a", Unreferenced: since(grace / 2)}, false, false, true},
+		{"after grace", &tlv.BlobIndexEntry{Name: "/a", Unreferenced: since(2 * grace)}, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := &tlv.BlobIndex{Blobs: []*tlv.BlobIndexEntry{tt.entry}}
+			res, expired := sweepBlobIndex(index, func(string) bool { return tt.referenced }, now, grace)
+
+			if kept := res.Kept == 1; kept != tt.wantKept {
+				t.Errorf("kept = %v, want %v", kept, tt.wantKept)
+			}
+			if expiring := res.Expiring == 1; expiring != tt.wantExpiring {
+				t.Errorf("expiring = %v, want %v", expiring, tt.wantExpiring)
+			}
+			if gone := len(expired) == 1; gone != (!tt.wantKept && !tt.wantExpiring) {
+				t.Errorf("expired = %v", expired)
+			}
+			if inIndex := len(index.Blobs) == 1; inIndex != (tt.wantKept || tt.wantExpiring) {
+				t.Errorf("index = %v", index.Blobs)
+			}
+
+			// Referenced blobs restart the grace period when unreferenced again
+			if tt.wantKept && tt.entry.Unreferenced.IsSet() {
+				t.Error("referenced entry is still marked unreferenced")
+			}
+			if tt.wantExpiring && !tt.entry.Unreferenced.IsSet() {
+				t.Error("unreferenced entry is not marked")
+			}
+		})
+	}
+}
+
+func TestSweepBlobIndexOrder(t *testing.T) {
+	now := time.Now()
+	old := optional.Some(uint64(now.Add(-48 * time.Hour).UnixMilli()))
+	index := &tlv.BlobIndex{Blobs: []*tlv.BlobIndexEntry{
+		{Name: "/a", Unreferenced: old},
+		{Name: "/b"},
+		{Name: "/c", Unreferenced: old},
+		{Name: "/d", Unreferenced: old},
+	}}
+	refs := map[string]bool{"/d": true}
+
+	res, expired := sweepBlobIndex(index, func(name string) bool { return refs[name] }, now, time.Hour)
+	if res.Kept != 1 || res.Expiring != 1 {
+		t.Errorf("kept %d, expiring %d, want 1 and 1", res.Kept, res.Expiring)
+	}
+	if want := []string{"/a", "/c"}; !slices.Equal(expired, want) {
+		t.Errorf("expired = %v, want %v", expired, want)
+	}
+
+	names := make([]string, 0, len(index.Blobs))
+	for _, entry := range index.Blobs {
+		names = append(names, entry.Name)
+	}
+	if want := []string{"/b", "/d"}; !slices.Equal(names, want) {
+		t.Errorf("index = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+)
+
+func TestBlobSegments(t *testing.T) {
+	tests := []struct {
+		size int64
+		want uint64
+	}{
+		{0, 1},
+		{1, 1},
+		{blobSegmentSize - 1, 1},
+		{blobSegmentSize, 1},
+		{blobSegmentSize + 1, 2},
+		{10 * blobSegmentSize, 10},
+		{10*blobSegmentSize + 1, 11},
+	}
+	for _, tt := range tests {
+		if got := blobSegments(tt.size); got != tt.want {
+			t.Errorf("blobSegments(%d) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestSplitBlobRef(t *testing.T) {
+	blobName, _ := enc.NameFromStr("/ndn/owner/ws/proj/ndn/alice/32=blob/doc/v=1")
+	casName, _ := enc.NameFromStr("/ndn/owner/ws/proj/ndn/alice/32=blob/32=cas")
+	digest := []byte{1, 2, 3, 4}
+	casName = casName.Append(enc.NewGenericBytesComponent(digest))
+
+	tests := []struct {
+		name       string
+		ref        enc.Name
+		wantName   enc.Name
+		wantDigest []byte
+	}{
+		{"with digest", blobRef(blobName, digest), blobName, digest},
+		{"without digest", blobName, blobName, nil},
+		{"content-addressed", casName, casName, digest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, digest := splitBlobRef(tt.ref)
+			if !name.Equal(tt.wantName) {
+				t.Errorf("splitBlobRef() name = %s, want %s", name, tt.wantName)
+			}
+			if !bytes.Equal(digest, tt.wantDigest) {
+				t.Errorf("splitBlobRef() digest = %x, want %x", digest, tt.wantDigest)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn/svs_ps"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+// Number of the latest chat messages of each channel kept in a snapshot
+const chatSnapshotKeep = 500
+
+// Age after which chat messages are dropped from snapshots
+const chatSnapshotMaxAge = 180 * 24 * time.Hour
+
+// PubChat publishes an encrypted chat message. The author is set to our name,
+// and the timestamp to now if not set. A message with the ID of an earlier
+// one replaces it, e.g. to edit it. Commenters can only chat in the root project.
+func (s *SvsAlo) PubChat(msg *tlv.ChatMessage) (enc.Name, error) {
+	if err := s.checkPublish(commentDoc); err != nil {
+		return nil, err
+	}
+	if msg.Channel == "" || msg.ID == "" {
+		return nil, fmt.Errorf("chat message needs a channel and an ID")
+	}
+
+	msg.Author = s.wksp.idName.String()
+	if msg.Timestamp == 0 {
+		msg.Timestamp = uint64(time.Now().UnixMilli())
+	}
+
+	// Encrypt the publication
+	epub, err := s.wksp.crypto.encryptPub(&tlv.Message{ChatMessage: msg}, s.proj)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publish(epub.Encode())
+}
+
+// compressSnapshotChat applies the retention rules of chat messages to the
+// history snapshot of publisher. Edits replace earlier versions of a message,
+// and only the latest chatSnapshotKeep messages of each channel that are not
+// older than chatSnapshotMaxAge are kept. Messages with another author are
+// ignored by subscribers, and like other entries they are not changed.
+func (w *Workspace) compressSnapshotChat(proj string, publisher enc.Name, hs *svs_ps.HistorySnap) {
+	type chatEntry struct {
+		entry *svs_ps.HistorySnapEntry
+		msg   *tlv.ChatMessage
+	}
+	type chatKey struct {
+		author string
+		id     string
+	}
+
+	// Latest entry of each message, by channel, author and message ID
+	author := publisher.String()
+	channels := make(map[string]map[chatKey]chatEntry)
+	drop := make(map[*svs_ps.HistorySnapEntry]bool)
+	for _, entry := range hs.Entries {
+		msg, err := tlv.ParseMessage(enc.NewWireView(entry.Content), true)
+		if err != nil {
+			continue
+		}
+		if msg, err = w.crypto.decryptPub(msg, proj); err != nil || msg.ChatMessage == nil {
+			continue
+		}
+
+		chat := msg.ChatMessage
+		if chat.Author != author {
+			continue
+		}
+		if channels[chat.Channel] == nil {
+			channels[chat.Channel] = make(map[chatKey]chatEntry)
+		}
+		key := chatKey{author: chat.Author, id: chat.ID}
+		if prev, ok := channels[chat.Channel][key]; ok {
+			drop[prev.entry] = true // edited later
+		}
+		channels[chat.Channel][key] = chatEntry{entry: entry, msg: chat}
+	}
+
+	minTime := uint64(time.Now().Add(-chatSnapshotMaxAge).UnixMilli())
+	for _, messages := range channels {
+		list := make([]chatEntry, 0, len(messages))
+		for _, ce := range messages {
+			list = append(list, ce)
+		}
+		slices.SortFunc(list, func(a, b chatEntry) int {
+			return cmp.Compare(b.msg.Timestamp, a.msg.Timestamp) // newest first
+		})
+
+		for i, ce := range list {
+			if i >= chatSnapshotKeep || ce.msg.Timestamp < minTime {
+				drop[ce.entry] = true
+			}
+		}
+	}
+	if len(drop) == 0 {
+		return
+	}
+
+	log.Info(nil, "Dropping chat messages from snapshot", "project", proj, "count", len(drop))
+	hs.Entries = slices.DeleteFunc(hs.Entries, func(entry *svs_ps.HistorySnapEntry) bool {
+		return drop[entry]
+	})
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/ndn/svs_ps"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+func TestCompressSnapshotChat(t *testing.T) {
+	alice, _ := enc.NameFromStr("/ndn/alice")
+	now := uint64(time.Now().UnixMilli())
+	day := uint64(24 * time.Hour / time.Millisecond)
+	chat := func(channel string, id string, ts uint64) *tlv.Message {
+		return &tlv.Message{ChatMessage: &tlv.ChatMessage{
+			Channel: channel, Author: alice.String(), ID: id, Timestamp: ts, Body: id,
+		}}
+	}
+	forged := func(channel string, id string, ts uint64) *tlv.Message {
+		msg := chat(channel, id, ts)
+		msg.ChatMessage.Author = "/ndn/bob"
+		return msg
+	}
+	delta := &tlv.Message{YjsDelta: &tlv.YjsDelta{UUID: "doc", Binary: []byte{1}}}
+
+	// Messages of a full channel, the first two are the oldest
+	full := make([]*tlv.Message, 0, chatSnapshotKeep+2)
+	for i := range chatSnapshotKeep + 2 {
+		full = append(full, chat("full", fmt.Sprint(i), now-day+uint64(i)))
+	}
+
+	tests := []struct {
+		name string
+		msgs []*tlv.Message
+		keep []int // indexes of the kept entries, or nil for all
+	}{
+		{
+			name: "recent messages",
+			msgs: []*tlv.Message{chat("a", "1", now), chat("b", "1", now), delta},
+		},
+		{
+			name: "edited message",
+			msgs: []*tlv.Message{chat("a", "1", now-2), chat("a", "2", now-1), chat("a", "1", now)},
+			keep: []int{1, 2},
+		},
+		{
+			name: "same ID in other channel",
+			msgs: []*tlv.Message{chat("a", "1", now), chat("b", "1", now)},
+		},
+		{
+			name: "same ID with another author",
+			msgs: []*tlv.Message{chat("a", "1", now-1), forged("a", "1", now)},
+		},
+		{
+			name: "old message with another author",
+			msgs: []*tlv.Message{forged("a", "1", now-200*day), chat("a", "1", now)},
+		},
+		{
+			name: "too old",
+			msgs: []*tlv.Message{chat("a", "1", now-200*day), delta, chat("a", "2", now-day)},
+			keep: []int{1, 2},
+		},
+		{
+			name: "too many in channel",
+			msgs: append(slices.Clone(full), chat("other", "1", now-2*day)),
+			keep: func() []int {
+				keep := []int{}
+				for i := 2; i <= len(full); i++ {
+					keep = append(keep, i)
+				}
+				return keep
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Workspace{crypto: testCrypto(t, 0)}
+
+			hs := &svs_ps.HistorySnap{}
+			for i, msg := range tt.msgs {
+				epub, err := w.crypto.encryptPub(msg, "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				hs.Entries = append(hs.Entries, &svs_ps.HistorySnapEntry{
+					SeqNo:   uint64(i),
+					Content: epub.Encode(),
+				})
+			}
+
+			w.compressSnapshotChat("", alice, hs)
+
+			want := tt.keep
+			if want == nil {
+				for i := range tt.msgs {
+					want = append(want, i)
+				}
+			}
+			got := make([]int, 0, len(hs.Entries))
+			for _, entry := range hs.Entries {
+				got = append(got, int(entry.SeqNo))
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("kept entries %v, want %v", got, want)
+			}
+		})
+	}
+}
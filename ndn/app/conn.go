@@ -0,0 +1,176 @@
+package app
+
+import (
+	"sync"
+	"time"
+
+	"github.com/named-data/ndnd/std/log"
+)
+
+// How long the face may be down before the app is offline
+// and starts to reconnect, moving through the routers
+const connOfflineDelay = 15 * time.Second
+
+// Bounds of the exponential backoff between reconnection attempts
+const connBackoffMin = time.Second
+const connBackoffMax = time.Minute
+
+// ConnState is the state of the connection to the network.
+type ConnState int
+
+const (
+	// ConnConnecting is the state until the face first comes up.
+	ConnConnecting ConnState = iota
+	// ConnUp is the state while the face is up.
+	ConnUp
+	// ConnDegraded is the state after the face went down,
+	// while it may still come back up by itself.
+	ConnDegraded
+	// ConnOffline is the state after the face was down for connOfflineDelay.
+	// The app reconnects with exponential backoff.
+	ConnOffline
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case ConnConnecting:
+		return "connecting"
+	case ConnUp:
+		return "up"
+	case ConnDegraded:
+		return "degraded"
+	case ConnOffline:
+		return "offline"
+	}
+	return "unknown"
+}
+
+// connMachine tracks the connection state and the reconnection backoff.
+type connMachine struct {
+	mutex    sync.Mutex
+	state    ConnState
+	backoff  time.Duration
+	timer    *time.Timer
+	watchers map[int]func(state ConnState, prev ConnState)
+	nextId   int
+}
+
+// ConnState returns the current connection state.
+func (a *App) ConnState() ConnState {
+	a.conn.mutex.Lock()
+	defer a.conn.mutex.Unlock()
+	return a.conn.state
+}
+
+// isOnline returns true if the face is up. This is the same as ConnUp,
+// except that it is already true before the up handlers have run.
+func (a *App) isOnline() bool {
+	return a.face != nil && a.face.IsRunning()
+}
+
+// WatchConnState calls the callback on every change of the connection state,
+// with the previous state. The callback is called in a new goroutine.
+func (a *App) WatchConnState(callback func(state ConnState, prev ConnState)) (cancel func()) {
+	c := &a.conn
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.watchers == nil {
+		c.watchers = make(map[int]func(ConnState, ConnState))
+	}
+	id := c.nextId
+	c.nextId++
+	c.watchers[id] = callback
+
+	return func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		delete(c.watchers, id)
+	}
+}
+
+// setConnState changes the state and notifies the UI and watchers.
+// The timer runs after the delay, unless the state changes first.
+func (a *App) setConnState(state ConnState, delay time.Duration, timer func()) {
+	c := &a.conn
+	c.mutex.Lock()
+	prev := c.state
+	c.state = state
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if timer != nil {
+		c.timer = time.AfterFunc(delay, timer)
+	}
+	watchers := make([]func(ConnState, ConnState), 0, len(c.watchers))
+	for _, watcher := range c.watchers {
+		watchers = append(watchers, watcher)
+	}
+	c.mutex.Unlock()
+
+	if state == prev {
+		return
+	}
+	log.Info(a, "Connection state changed", "state", state, "prev", prev)
+	a.ui.OnConnChange(state, a.currentRouter())
+	for _, watcher := range watchers {
+		go watcher(state, prev)
+	}
+}
+
+// onFaceUp is called when the face to the current router comes up.
+func (a *App) onFaceUp() {
+	a.conn.mutex.Lock()
+	a.conn.backoff = 0
+	a.conn.mutex.Unlock()
+
+	a.setConnState(ConnUp, 0, nil)
+}
+
+// onFaceDown is called when the face to the current router goes down.
+// If it does not come back up in time, the app is offline.
+func (a *App) onFaceDown() {
+	a.setConnState(ConnDegraded, connOfflineDelay, a.goOffline)
+}
+
+// goOffline moves to the offline state and starts to reconnect.
+func (a *App) goOffline() {
+	if a.rface.IsRunning() {
+		return
+	}
+	a.setConnState(ConnOffline, 0, nil)
+	a.reconnect()
+}
+
+// reconnect moves to the next router, or dials the current one again,
+// and tries again after the backoff if the face does not come up.
+func (a *App) reconnect() {
+	a.switchMutex.Lock()
+	defer a.switchMutex.Unlock()
+
+	if a.rface.IsRunning() {
+		return
+	}
+
+	current := a.rface.Router()
+	next := a.routers.failed(current)
+	if next == "" {
+		next = current
+	}
+	log.Warn(a, "Reconnecting", "router", current, "next", next)
+	if err := a.switchRouterLocked(next); err != nil {
+		log.Error(a, "Failed to reconnect", "router", next, "err", err)
+	}
+
+	c := &a.conn
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.backoff = min(max(2*c.backoff, connBackoffMin), connBackoffMax)
+	if c.state == ConnOffline {
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+		c.timer = time.AfterFunc(c.backoff, a.reconnect)
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	"github.com/named-data/ndnd/std/security/trust_schema"
+)
+
+// coOwnerPrefix is the name prefix of the co-owner grant of a user.
+// This is also the identity of the co-owner key signed with the grant.
+// /<wksp>/root/32=OWNER/<coowner>
+func coOwnerPrefix(wkspName enc.Name, coowner enc.Name) enc.Name {
+	return wkspName.
+		Append(enc.NewGenericComponent("root")).
+		Append(enc.NewKeywordComponent("OWNER")).
+		Append(coowner...)
+}
+
+// SignCoOwnerGrant signs a grant that makes a member a co-owner of the workspace.
+// Co-owners can sign invitations and grant ownership to others in turn,
+// so the workspace does not depend on the identity of its creator.
+func (w *Workspace) SignCoOwnerGrant(coowner enc.Name, opts InviteOpts) (enc.Wire, error) {
+	if !w.owner {
+		return nil, fmt.Errorf("only owners can grant ownership")
+	}
+
+	notBefore, notAfter, err := opts.validity()
+	if err != nil {
+		return nil, err
+	}
+
+	// /<wksp>/root/32=OWNER/<coowner>/v=<time>
+	prefix := coOwnerPrefix(w.group, coowner)
+	grantName := prefix.WithVersion(enc.VersionUnixMicro)
+
+	signer := w.client.SuggestSigner(grantName)
+	if signer == nil {
+		return nil, fmt.Errorf("no valid signing key")
+	}
+
+	grant, err := trust_schema.SignCrossSchema(trust_schema.SignCrossSchemaArgs{
+		Name:   grantName,
+		Signer: signer,
+		Content: trust_schema.CrossSchemaContent{
+			SimpleSchemaRules: []*trust_schema.SimpleSchemaRule{{
+				// Authorize the co-owner's identity key to sign the co-owner key
+				NamePrefix: prefix,
+				KeyLocator: &spec.KeyLocator{
+					Name: coowner.Append(enc.NewGenericComponent("KEY")),
+				},
+			}},
+		},
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+		Store:     w.client.Store(), // auto-store
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w.coOwners.Store(coowner.String(), notAfter)
+	log.Info(w, "Granted ownership", "coowner", coowner, "expiry", notAfter)
+	return grant, nil
+}
+
+// AcceptCoOwnerGrant fetches our co-owner grant to a workspace and signs
+// a co-owner key with it. After this, we have owner permissions.
+func (a *App) AcceptCoOwnerGrant(wkspStr string) error {
+	wkspName, err := enc.NameFromStr(wkspStr)
+	if err != nil {
+		return err
+	}
+
+	idSigner, _ := a.workspaceIdKey(wkspName)
+	if idSigner == nil {
+		return fmt.Errorf("no identity key found")
+	}
+	idName := idSigner.KeyName().Prefix(-2) // pop KeyId and KEY
+	if idName.IsPrefix(wkspName) {
+		return fmt.Errorf("already the owner of %s", wkspName)
+	}
+
+	prefix := coOwnerPrefix(wkspName, idName)
+	grant, _, err := a.fetchGrant(wkspName, prefix, prefix, 3, func(wire enc.Wire) (time.Time, error) {
+		return a.verifyCoOwnerGrant(wkspName, idName, wire)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get co-owner grant for %s in %s (%w)", idName, wkspName, err)
+	}
+
+	return a.signDelegatedCert(prefix, idSigner, grant)
+}
+
+// verifyCoOwnerGrant checks that a grant makes idName a co-owner of the workspace.
+// The grant must be signed by an owner, who may be a co-owner in turn.
+// Returns the expiry of the grant.
+func (a *App) verifyCoOwnerGrant(wkspName enc.Name, idName enc.Name, wire enc.Wire) (time.Time, error) {
+	prefix := coOwnerPrefix(wkspName, idName)
+	return a.verifyGrant("co-owner grant", wkspName, prefix, prefix, idName, wire)
+}
+
+// fetchCoOwnerGrant fetches and verifies the co-owner grant of another member.
+// Returns the expiry of the grant.
+func (a *App) fetchCoOwnerGrant(wkspName enc.Name, coowner enc.Name) (time.Time, error) {
+	prefix := coOwnerPrefix(wkspName, coowner)
+	_, notAfter, err := a.fetchGrant(wkspName, prefix, prefix, 1, func(wire enc.Wire) (time.Time, error) {
+		return a.verifyCoOwnerGrant(wkspName, coowner, wire)
+	})
+	return notAfter, err
+}
+
+// hasCoOwnerCert returns true if we have a co-owner key in the workspace
+// that is signed with a valid grant.
+func (a *App) hasCoOwnerCert(wkspName enc.Name, idName enc.Name) bool {
+	id := a.keychain.IdentityByName(coOwnerPrefix(wkspName, idName))
+	if id == nil {
+		return false
+	}
+
+	now := time.Now()
+	for _, key := range id.Keys() {
+		for _, certName := range key.UniqueCerts() {
+			certWire, _ := a.keychain.Store().Get(certName.Prefix(-1), true)
+			if certWire == nil {
+				continue
+			}
+			cert, _, err := spec.Spec{}.ReadData(enc.NewBufferView(certWire))
+			if err != nil {
+				continue
+			}
+			notBefore, notAfter, err := dataValidity(cert)
+			if err != nil || now.Before(notBefore) || now.After(notAfter) {
+				continue
+			}
+			if grant := cert.CrossSchema(); grant != nil {
+				if _, err := a.verifyCoOwnerGrant(wkspName, idName, grant); err == nil {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
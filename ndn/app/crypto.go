@@ -3,8 +3,14 @@ package app
 import (
 	"crypto/cipher"
 	"crypto/ecdh"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
 
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/hkdf"
 )
 
@@ -34,18 +40,68 @@ func hkdfSha256(ss []byte) (sym []byte, err error) {
 	return sym, nil
 }
 
-func aeadSeal(c cipher.Block, nonce []byte, plaintext []byte) ([]byte, error) {
+// aeadOpen decrypts legacy AES-GCM publications, which are no longer made.
+func aeadOpen(c cipher.Block, nonce []byte, ciphertext []byte) ([]byte, error) {
 	aead, err := cipher.NewGCM(c)
 	if err != nil {
 		return nil, err
 	}
-	return aead.Seal(nil, nonce, plaintext, nil), nil
+	return aead.Open(nil, nonce, ciphertext, nil)
 }
 
-func aeadOpen(c cipher.Block, nonce []byte, ciphertext []byte) ([]byte, error) {
-	aead, err := cipher.NewGCM(c)
+// aeadAssocData binds the ciphertext of an AEAD block to its header,
+// i.e. the algorithm, the epoch and whether the project key is used.
+func aeadAssocData(block *tlv.AeadBlock) []byte {
+	ad := binary.BigEndian.AppendUint64(nil, block.Algorithm.GetOr(tlv.AeadAesGcm))
+	ad = binary.BigEndian.AppendUint64(ad, block.Epoch.GetOr(0))
+	if block.Project {
+		return append(ad, 1)
+	}
+	return append(ad, 0)
+}
+
+// dskAssocData binds a wrapped DSK to the requester, the root group
+// of the workspace and the expiry of the request.
+func dskAssocData(requester enc.Name, group enc.Name, expiry uint64) []byte {
+	ad := append(requester.Bytes(), group.Bytes()...)
+	return binary.BigEndian.AppendUint64(ad, expiry)
+}
+
+// wrapDsk encrypts the DSK for the X25519 public key of the requester.
+// Returns the ephemeral public key, nonce and ciphertext.
+func wrapDsk(peerPub []byte, dsk []byte, ad []byte) (pub []byte, nonce []byte, ct []byte, err error) {
+	sk, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sym, err := x25519HkdfSha256(peerPub, sk.Bytes())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	aead, err := chacha20poly1305.NewX(sym)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, nil, err
+	}
+	return sk.PublicKey().Bytes(), nonce, aead.Seal(nil, nonce, dsk, ad), nil
+}
+
+// unwrapDsk decrypts a DSK wrapped with wrapDsk.
+func unwrapDsk(priv []byte, peerPub []byte, nonce []byte, ct []byte, ad []byte) ([]byte, error) {
+	sym, err := x25519HkdfSha256(peerPub, priv)
 	if err != nil {
 		return nil, err
 	}
-	return aead.Open(nil, nonce, ciphertext, nil)
+	aead, err := chacha20poly1305.NewX(sym)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size: %d", len(nonce))
+	}
+	return aead.Open(nil, nonce, ct, ad)
 }
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"testing"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+)
+
+func TestWrapDsk(t *testing.T) {
+	sk, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice, _ := enc.NameFromStr("/ndn/alice")
+	bob, _ := enc.NameFromStr("/ndn/bob")
+	group, _ := enc.NameFromStr("/ndn/owner/ws/root")
+	otherGroup, _ := enc.NameFromStr("/ndn/owner/other/root")
+	const expiry = 1700000000
+
+	dsk := randomKey(t)
+	ad := dskAssocData(alice, group, expiry)
+	pub, nonce, ct, err := wrapDsk(sk.PublicKey().Bytes(), dsk, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		priv    []byte
+		nonce   []byte
+		ad      []byte
+		wantErr bool
+	}{
+		{"same binding", sk.Bytes(), nonce, ad, false},
+		{"other requester", sk.Bytes(), nonce, dskAssocData(bob, group, expiry), true},
+		{"other workspace", sk.Bytes(), nonce, dskAssocData(alice, otherGroup, expiry), true},
+		{"other expiry", sk.Bytes(), nonce, dskAssocData(alice, group, expiry+1), true},
+		{"other private key", other.Bytes(), nonce, ad, true},
+		{"short nonce", sk.Bytes(), nonce[:12], ad, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unwrapDsk(tt.priv, pub, tt.nonce, ct, tt.ad)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("unwrapDsk() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, dsk) {
+				t.Fatal("unwrapDsk() returned a different key")
+			}
+		})
+	}
+}
@@ -1,13 +1,17 @@
-//go:build js && wasm
-
 package app
 
 import (
+	"bytes"
 	"crypto/aes"
+	"crypto/cipher"
 	"crypto/ecdh"
+	"crypto/hmac"
 	"crypto/rand"
-	"encoding/binary"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"hash"
+	"sync"
 	"time"
 
 	spec_repo "github.com/named-data/ndnd/repo/tlv"
@@ -17,40 +21,323 @@ import (
 	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
 	"github.com/named-data/ndnd/std/types/optional"
 	"github.com/pulsejet/ownly/ndn/app/tlv"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
-func (a *App) processDskRequest(client ndn.Client, group enc.Name, pub []byte) enc.Wire {
-	if len(a.dsk) != 32 || len(pub) > 64 {
-		// We are not capable of answering DSK requests
-		return nil
+// WorkspaceCrypto is the encryption context of a single workspace.
+// Each workspace handle owns one, so multiple workspaces can be open at once.
+type WorkspaceCrypto struct {
+	mutex sync.RWMutex
+
+	// Pre-shared key
+	psk []byte
+	// Data-sharing keys by epoch, the newest is used for encryption
+	keys  map[uint64]*epochKey
+	epoch uint64
+	// Keys of private projects, used instead of the data-sharing key
+	projKeys map[string]*epochKey
+	// Members that are not given keys of new epochs
+	removed map[string]bool
+
+	// Callbacks for new epoch keys
+	onKey   map[int]func(epoch uint64)
+	onKeyId int
+	// Epochs and projects with a key request in flight
+	epochReqs map[uint64]bool
+	projReqs  map[string]bool
+
+	// Pending DSK requests -> cancel function
+	dskReqs map[string]*time.Timer
+}
+
+// epochKey is the data-sharing key of one epoch with the derived ciphers.
+type epochKey struct {
+	dsk  []byte
+	aes  cipher.Block // legacy, decryption only
+	aead cipher.AEAD
+}
+
+// errUnknownEpoch is returned when a publication uses a key we do not have (yet).
+var errUnknownEpoch = errors.New("unknown key epoch")
+
+// errEpochConflict is returned when an epoch already has a different key.
+var errEpochConflict = errors.New("conflicting key for epoch")
+
+// errNoProjectKey is returned when a publication uses the key of a private
+// project that we do not have (yet).
+var errNoProjectKey = errors.New("no project key")
+
+func newWorkspaceCrypto() *WorkspaceCrypto {
+	return &WorkspaceCrypto{
+		keys:      make(map[uint64]*epochKey),
+		projKeys:  make(map[string]*epochKey),
+		removed:   make(map[string]bool),
+		onKey:     make(map[int]func(uint64)),
+		epochReqs: make(map[uint64]bool),
+		projReqs:  make(map[string]bool),
+		dskReqs:   make(map[string]*time.Timer),
 	}
+}
+
+func (c *WorkspaceCrypto) String() string {
+	return "wksp-crypto"
+}
+
+// SetKeys sets the pre-shared key and the data-sharing key of an epoch.
+// Workspaces that were never rotated only have epoch 0.
+func (c *WorkspaceCrypto) SetKeys(psk []byte, dsk []byte, epoch uint64) error {
+	if len(psk) == 0 {
+		return fmt.Errorf("invalid keys")
+	}
+
+	c.mutex.Lock()
+	c.psk = psk
+	c.mutex.Unlock()
 
-	sk, err := ecdh.X25519().GenerateKey(rand.Reader)
+	return c.AddEpochKey(epoch, dsk)
+}
+
+// AddEpochKey adds the data-sharing key of an epoch.
+// Keys of older epochs are kept for reading history. A different key for an
+// epoch we already have is rejected with errEpochConflict.
+func (c *WorkspaceCrypto) AddEpochKey(epoch uint64, dsk []byte) error {
+	c.mutex.RLock()
+	psk := c.psk
+	c.mutex.RUnlock()
+
+	key, err := newEpochKey(psk, dsk)
 	if err != nil {
-		log.Error(a, "Failed to generate DSK response key", "err", err)
-		return nil
+		return err
+	}
+
+	c.mutex.Lock()
+	prev, exists := c.keys[epoch]
+	if exists && !bytes.Equal(prev.dsk, dsk) {
+		c.mutex.Unlock()
+		return fmt.Errorf("%w %d", errEpochConflict, epoch)
+	}
+	c.keys[epoch] = key
+	if epoch > c.epoch || len(c.keys) == 1 {
+		c.epoch = epoch
+	}
+	callbacks := c.keyCallbacks()
+	c.mutex.Unlock()
+
+	if !exists {
+		for _, cb := range callbacks {
+			cb(epoch)
+		}
 	}
-	sym, err := x25519HkdfSha256(pub, sk.Bytes())
+	return nil
+}
+
+// SetProjectKey sets the key of a private project.
+// Publications in the project are encrypted with this key instead of the DSK,
+// so members of the workspace outside the project cannot read them.
+func (c *WorkspaceCrypto) SetProjectKey(proj string, key []byte) error {
+	c.mutex.RLock()
+	psk := c.psk
+	c.mutex.RUnlock()
+
+	pkey, err := newEpochKey(psk, key)
 	if err != nil {
-		log.Error(a, "Failed to compute DSK response sym key", "err", err)
-		return nil
+		return err
 	}
 
-	cipher, err := aes.NewCipher(sym)
+	c.mutex.Lock()
+	_, exists := c.projKeys[proj]
+	c.projKeys[proj] = pkey
+	epoch := c.epoch
+	callbacks := c.keyCallbacks()
+	c.mutex.Unlock()
+
+	if !exists {
+		for _, cb := range callbacks {
+			cb(epoch)
+		}
+	}
+	return nil
+}
+
+// HasProjectKey returns true if we have the key of the private project.
+func (c *WorkspaceCrypto) HasProjectKey(proj string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.projKeys[proj] != nil
+}
+
+// newEpochKey derives the ciphers of a data-sharing key.
+func newEpochKey(psk []byte, dsk []byte) (*epochKey, error) {
+	if len(psk) == 0 || len(dsk) == 0 {
+		return nil, fmt.Errorf("invalid keys")
+	}
+
+	symKey, err := hkdfSha256(append(append([]byte{}, psk...), dsk...))
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(symKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(symKey)
 	if err != nil {
-		log.Error(a, "Failed to create AES cipher", "err", err)
+		return nil, err
+	}
+	return &epochKey{dsk: dsk, aes: block, aead: aead}, nil
+}
+
+// keyCallbacks returns the registered key callbacks.
+// The caller must hold the lock.
+func (c *WorkspaceCrypto) keyCallbacks() []func(uint64) {
+	callbacks := make([]func(uint64), 0, len(c.onKey))
+	for _, cb := range c.onKey {
+		callbacks = append(callbacks, cb)
+	}
+	return callbacks
+}
+
+// HasKeys returns true if the encryption keys are set.
+func (c *WorkspaceCrypto) HasKeys() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return len(c.keys) > 0
+}
+
+// Epoch returns the current key epoch.
+func (c *WorkspaceCrypto) Epoch() uint64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.epoch
+}
+
+// HasEpoch returns true if we have the key of the given epoch.
+func (c *WorkspaceCrypto) HasEpoch(epoch uint64) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.keys[epoch] != nil
+}
+
+// RemoveMembers excludes members from receiving the keys of new epochs.
+func (c *WorkspaceCrypto) RemoveMembers(names []enc.Name) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, name := range names {
+		c.removed[name.String()] = true
+	}
+}
+
+// IsRemoved returns true if the member was removed from the workspace.
+func (c *WorkspaceCrypto) IsRemoved(name enc.Name) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.removed[name.String()]
+}
+
+// onEpochKey registers a callback for new epoch keys.
+// Returns a function to cancel the registration.
+func (c *WorkspaceCrypto) onEpochKey(cb func(epoch uint64)) func() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	id := c.onKeyId
+	c.onKeyId++
+	c.onKey[id] = cb
+
+	return func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		delete(c.onKey, id)
+	}
+}
+
+// startEpochReq marks a key request for the epoch as in flight.
+// Returns false if there is already one, or we have the key.
+func (c *WorkspaceCrypto) startEpochReq(epoch uint64) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.keys[epoch] != nil || c.epochReqs[epoch] {
+		return false
+	}
+	c.epochReqs[epoch] = true
+	return true
+}
+
+func (c *WorkspaceCrypto) endEpochReq(epoch uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.epochReqs, epoch)
+}
+
+// startProjectReq marks a key request for the project as in flight.
+// Returns false if there is already one, or we have the key.
+func (c *WorkspaceCrypto) startProjectReq(proj string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.projKeys[proj] != nil || c.projReqs[proj] {
+		return false
+	}
+	c.projReqs[proj] = true
+	return true
+}
+
+func (c *WorkspaceCrypto) endProjectReq(proj string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.projReqs, proj)
+}
+
+// processDskRequest answers a DSK request with the key of the given epoch,
+// or with the key of a private project if the request names one.
+// The caller must check that the requester is allowed to get this key.
+func (c *WorkspaceCrypto) processDskRequest(
+	client ndn.Client,
+	group enc.Name,
+	requester enc.Name,
+	req *tlv.DSKRequest,
+	epoch uint64,
+) enc.Wire {
+	// Project keys are bound to the project group instead of the root group
+	adGroup, resEpoch := group, optional.Some(epoch)
+	proj, isProj := req.Project.Get()
+	if isProj {
+		adGroup = group.Prefix(-1).Append(enc.NewGenericComponent(proj))
+		resEpoch = optional.None[uint64]()
+	}
+
+	c.mutex.RLock()
+	key := c.keys[epoch]
+	if isProj {
+		key = c.projKeys[proj]
+	}
+	var dsk []byte
+	if key != nil {
+		dsk = key.dsk
+	}
+	c.mutex.RUnlock()
+
+	pub := req.X25519Pub
+	if len(dsk) != 32 || len(pub) > 64 {
+		// We are not capable of answering DSK requests
 		return nil
 	}
 
-	// Encrypt the DSK, should be multiple of block size
-	ciphertext := make([]byte, len(a.dsk))
-	for i := 0; i < len(a.dsk); i += cipher.BlockSize() {
-		cipher.Encrypt(ciphertext[i:], a.dsk[i:])
+	// The DSK is only valid for this requester in this workspace
+	ad := dskAssocData(requester, adGroup, req.Expiry)
+	peer, nonce, ciphertext, err := wrapDsk(pub, dsk, ad)
+	if err != nil {
+		log.Error(c, "Failed to wrap DSK", "err", err)
+		return nil
 	}
 
 	dskRes := &tlv.DSKResponse{
-		X25519Peer: sk.PublicKey().Bytes(),
+		X25519Peer: peer,
 		Ciphertext: ciphertext,
+		Epoch:      resEpoch,
+		Nonce:      nonce,
+		Expiry:     req.Expiry,
 	}
 
 	// Create Data packet under the group
@@ -61,7 +348,7 @@ func (a *App) processDskRequest(client ndn.Client, group enc.Name, pub []byte) e
 
 	signer := client.SuggestSigner(name)
 	if signer == nil {
-		log.Error(a, "Failed to suggest signer for DSK response", "name", name)
+		log.Error(c, "Failed to suggest signer for DSK response", "name", name)
 		return nil
 	}
 
@@ -69,10 +356,10 @@ func (a *App) processDskRequest(client ndn.Client, group enc.Name, pub []byte) e
 		Freshness: optional.Some(60 * time.Second),
 	}, dskRes.Encode(), signer)
 	if err != nil {
-		log.Error(a, "Failed to create DSK response", "err", err)
+		log.Error(c, "Failed to create DSK response", "err", err)
 		return nil
 	}
-	log.Info(a, "Created DSK response", "name", name)
+	log.Info(c, "Created DSK response", "name", name, "epoch", resEpoch, "project", proj)
 
 	repoCmd := &spec_repo.RepoCmd{
 		BlobFetch: &spec_repo.BlobFetch{
@@ -82,97 +369,238 @@ func (a *App) processDskRequest(client ndn.Client, group enc.Name, pub []byte) e
 	return repoCmd.Encode()
 }
 
-func (a *App) fetchDsk(client ndn.Client, wksp enc.Name, priv []byte) ([]byte, error) {
+// fetchDsk fetches the response to our DSK request from the repos or the
+// members that answer requests.
+// If proj is not empty, the request was for the key of that private project.
+// Responses must be signed by a member that was not revoked.
+// Returns the epoch and the data-sharing key.
+func (w *Workspace) fetchDsk(proj string, priv []byte) (uint64, []byte, error) {
 	sk, err := ecdh.X25519().NewPrivateKey(priv)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
 
-	name := wksp.
-		Append(enc.NewGenericComponent("root")).
+	group := w.group.Append(enc.NewGenericComponent("root"))
+	name := group.
 		Append(enc.NewKeywordComponent("DSK")).
 		Append(enc.NewGenericBytesComponent(sk.PublicKey().Bytes()))
-	log.Info(a, "Expressing DSK request", "name", name)
-
-	ch := make(chan ndn.ExpressCallbackArgs, 1)
-	client.ExpressR(ndn.ExpressRArgs{
-		Name: name,
-		Config: &ndn.InterestConfig{
-			CanBePrefix: true,
-			MustBeFresh: true,
-			Lifetime:    optional.Some(2 * time.Second),
-		},
-		Retries:  3,
-		Callback: func(args ndn.ExpressCallbackArgs) { ch <- args },
-	})
-	args := <-ch
+	log.Info(w, "Expressing DSK request", "name", name)
 
+	args := w.app.fetchLatest(name, w.Repos(), 3, false)
 	if args.Error != nil {
-		return nil, args.Error
+		return 0, nil, args.Error
 	}
 	if args.Result != ndn.InterestResultData {
-		return nil, fmt.Errorf("%s", args.Result)
+		return 0, nil, fmt.Errorf("%s", args.Result)
+	}
+	// Signature must match #dsk <= #user_cert
+	if err := w.validate(args.Data, args.SigCovered); err != nil {
+		return 0, nil, fmt.Errorf("invalid DSK response: %w", err)
 	}
 
 	dskRes, err := tlv.ParseDSKResponse(enc.NewWireView(args.Data.Content()), false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse DSK response: %w", err)
+		return 0, nil, fmt.Errorf("failed to parse DSK response: %w", err)
 	}
 
-	sym, err := x25519HkdfSha256(dskRes.X25519Peer, priv)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute DSK response sym key: %w", err)
+	// Legacy responses are not authenticated and are not accepted
+	if dskRes.Nonce == nil {
+		return 0, nil, fmt.Errorf("DSK response is not authenticated")
 	}
 
-	cipher, err := aes.NewCipher(sym)
+	adGroup := group
+	if proj != "" {
+		adGroup = w.group.Append(enc.NewGenericComponent(proj))
+	}
+	ad := dskAssocData(w.idName, adGroup, dskRes.Expiry)
+	dsk, err := unwrapDsk(priv, dskRes.X25519Peer, dskRes.Nonce, dskRes.Ciphertext, ad)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		return 0, nil, fmt.Errorf("failed to decrypt DSK response: %w", err)
 	}
 
-	// Decrypt the DSK, should be multiple of block size
-	dsk := make([]byte, len(dskRes.Ciphertext))
-	for i := 0; i < len(dskRes.Ciphertext); i += cipher.BlockSize() {
-		cipher.Decrypt(dsk[i:], dskRes.Ciphertext[i:])
+	return dskRes.Epoch.GetOr(0), dsk, nil
+}
+
+// sealKey returns the key to encrypt new content in the project, with the
+// AEAD block that identifies it. Private projects use the project key,
+// all others the current epoch key.
+func (c *WorkspaceCrypto) sealKey(proj string) (*epochKey, *tlv.AeadBlock, error) {
+	c.mutex.RLock()
+	epoch, key := c.epoch, c.keys[c.epoch]
+	projKey := c.projKeys[proj]
+	c.mutex.RUnlock()
+
+	block := &tlv.AeadBlock{
+		Algorithm: optional.Some(tlv.AeadXChaCha20Poly1305),
+	}
+	if projKey != nil {
+		key = projKey
+		block.Project = true
+	} else {
+		block.Epoch = optional.Some(epoch)
 	}
 
-	return dsk, nil
+	if key == nil {
+		return nil, nil, fmt.Errorf("encryption key not set")
+	}
+	return key, block, nil
 }
 
-func (a *App) encryptPub(pub *tlv.Message, seq uint64) (*tlv.Message, error) {
-	if a.aes == nil {
-		return nil, fmt.Errorf("AES key not set")
+// openKey returns the key that encrypted an AEAD block in the project.
+func (c *WorkspaceCrypto) openKey(block *tlv.AeadBlock, proj string) (*epochKey, error) {
+	epoch := block.Epoch.GetOr(0)
+	c.mutex.RLock()
+	key := c.keys[epoch]
+	if block.Project {
+		key = c.projKeys[proj]
 	}
+	c.mutex.RUnlock()
 
-	iv := make([]byte, 12) // 96-bit IV
-	binary.BigEndian.PutUint32(iv[0:], uint32(a.ivb))
-	binary.BigEndian.PutUint64(iv[4:], a.ivb+seq)
-	ciphertext, err := aeadSeal(a.aes, iv, pub.Encode().Join())
+	if key == nil && block.Project {
+		return nil, fmt.Errorf("%w: %s", errNoProjectKey, proj)
+	} else if key == nil {
+		return nil, fmt.Errorf("%w: %d", errUnknownEpoch, epoch)
+	}
+	return key, nil
+}
+
+// encryptPub encrypts a publication in the project with XChaCha20-Poly1305.
+// The nonce is random, so it is safe to use the same keys on multiple
+// devices and after a loss of the sync state. The header of the AEAD block
+// is authenticated.
+func (c *WorkspaceCrypto) encryptPub(pub *tlv.Message, proj string) (*tlv.Message, error) {
+	key, block, err := c.sealKey(proj)
 	if err != nil {
 		return nil, err
 	}
+	aead := key.aead
 
-	return &tlv.Message{
-		AeadBlock: &tlv.AeadBlock{
-			IV:         iv,
-			Ciphertext: ciphertext,
-		},
-	}, nil
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	block.IV = nonce
+	block.Ciphertext = aead.Seal(nil, nonce, pub.Encode().Join(), aeadAssocData(block))
+
+	return &tlv.Message{AeadBlock: block}, nil
 }
 
-func (a *App) decryptPub(pub *tlv.Message) (*tlv.Message, error) {
+func (c *WorkspaceCrypto) decryptPub(pub *tlv.Message, proj string) (*tlv.Message, error) {
 	if pub.AeadBlock == nil {
 		return pub, nil
 	}
-	if a.aes == nil {
-		return nil, fmt.Errorf("AES key not set")
+
+	key, err := c.openKey(pub.AeadBlock, proj)
+	if err != nil {
+		return nil, err
 	}
+	block, aead := key.aes, key.aead
 
 	iv := pub.AeadBlock.IV
 	ct := pub.AeadBlock.Ciphertext
-	plaintext, err := aeadOpen(a.aes, iv, ct)
+
+	var plaintext []byte
+	switch alg := pub.AeadBlock.Algorithm.GetOr(tlv.AeadAesGcm); alg {
+	case tlv.AeadAesGcm:
+		plaintext, err = aeadOpen(block, iv, ct)
+	case tlv.AeadXChaCha20Poly1305:
+		if len(iv) != aead.NonceSize() {
+			return nil, fmt.Errorf("invalid nonce size: %d", len(iv))
+		}
+		plaintext, err = aead.Open(nil, iv, ct, aeadAssocData(pub.AeadBlock))
+	default:
+		return nil, fmt.Errorf("unknown AEAD algorithm: %d", alg)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	return tlv.ParseMessage(enc.NewBufferView(plaintext), true)
 }
+
+// encryptBlob encrypts a segment of a blob in the project, with the same
+// keys as publications. The header and the segment name are authenticated,
+// so segments cannot be reordered or moved to another blob.
+func (c *WorkspaceCrypto) encryptBlob(segment []byte, segName enc.Name, proj string) (*tlv.Message, error) {
+	key, block, err := c.sealKey(proj)
+	if err != nil {
+		return nil, err
+	}
+	aead := key.aead
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	block.IV = nonce
+	block.Ciphertext = aead.Seal(nil, nonce, segment, append(aeadAssocData(block), segName.Bytes()...))
+
+	return &tlv.Message{AeadBlock: block}, nil
+}
+
+// decryptBlob decrypts a segment of a blob encrypted with encryptBlob.
+func (c *WorkspaceCrypto) decryptBlob(msg *tlv.Message, segName enc.Name, proj string) ([]byte, error) {
+	if msg.AeadBlock == nil {
+		return nil, fmt.Errorf("blob segment is not encrypted")
+	}
+	if alg := msg.AeadBlock.Algorithm.GetOr(tlv.AeadAesGcm); alg != tlv.AeadXChaCha20Poly1305 {
+		return nil, fmt.Errorf("unsupported AEAD algorithm for blobs: %d", alg)
+	}
+
+	key, err := c.openKey(msg.AeadBlock, proj)
+	if err != nil {
+		return nil, err
+	}
+	aead := key.aead
+
+	iv := msg.AeadBlock.IV
+	if len(iv) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size: %d", len(iv))
+	}
+	ad := append(aeadAssocData(msg.AeadBlock), segName.Bytes()...)
+	return aead.Open(nil, iv, msg.AeadBlock.Ciphertext, ad)
+}
+
+// blobHash returns a keyed SHA-256 for the content of blobs. The key is
+// derived from the pre-shared key, so digests are stable across epochs and
+// projects becoming private, but do not reveal the content outside the workspace.
+func (c *WorkspaceCrypto) blobHash() (hash.Hash, error) {
+	c.mutex.RLock()
+	psk := c.psk
+	c.mutex.RUnlock()
+	if len(psk) == 0 {
+		return nil, fmt.Errorf("no pre-shared key")
+	}
+
+	mac := hmac.New(sha256.New, psk)
+	mac.Write([]byte("ownly blob digest"))
+	return hmac.New(sha256.New, mac.Sum(nil)), nil
+}
+
+// addDskRequest schedules a response to a DSK request after the suppression delay.
+func (c *WorkspaceCrypto) addDskRequest(key string, delay time.Duration, respond func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.dskReqs[key]; ok {
+		return // already pending
+	}
+	c.dskReqs[key] = time.AfterFunc(delay, func() {
+		c.mutex.Lock()
+		delete(c.dskReqs, key)
+		c.mutex.Unlock()
+
+		respond()
+	})
+}
+
+// cancelDskRequest cancels a pending DSK response, e.g. if someone else answered.
+func (c *WorkspaceCrypto) cancelDskRequest(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if timer, ok := c.dskReqs[key]; ok {
+		timer.Stop()
+		delete(c.dskReqs, key)
+	}
+}
@@ -0,0 +1,254 @@
+package app
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/types/optional"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+func randomKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func testCrypto(t *testing.T, epoch uint64) *WorkspaceCrypto {
+	t.Helper()
+	c := newWorkspaceCrypto()
+	if err := c.SetKeys(randomKey(t), randomKey(t), epoch); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestEncryptPub(t *testing.T) {
+	delta := &tlv.Message{YjsDelta: &tlv.YjsDelta{UUID: "doc", Binary: []byte("update")}}
+
+	tests := []struct {
+		name    string
+		setup   func(c *WorkspaceCrypto) // before encryption
+		rotate  func(c *WorkspaceCrypto) // after encryption
+		proj    string
+		openIn  string
+		wantErr error
+	}{
+		{name: "epoch key"},
+		{
+			name:   "old epoch after rotation",
+			rotate: func(c *WorkspaceCrypto) { c.AddEpochKey(1, randomKey(t)) },
+		},
+		{
+			name:   "project key",
+			setup:  func(c *WorkspaceCrypto) { c.SetProjectKey("secret", randomKey(t)) },
+			proj:   "secret",
+			openIn: "secret",
+		},
+		{
+			name:    "project key of another project",
+			setup:   func(c *WorkspaceCrypto) { c.SetProjectKey("secret", randomKey(t)) },
+			proj:    "secret",
+			openIn:  "other",
+			wantErr: errNoProjectKey,
+		},
+		{
+			name:    "unknown epoch",
+			rotate:  func(c *WorkspaceCrypto) { c.keys = map[uint64]*epochKey{} },
+			wantErr: errUnknownEpoch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testCrypto(t, 0)
+			if tt.setup != nil {
+				tt.setup(c)
+			}
+
+			epub, err := c.encryptPub(delta, tt.proj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if epub.YjsDelta != nil || epub.AeadBlock == nil {
+				t.Fatal("publication is not encrypted")
+			}
+			if alg := epub.AeadBlock.Algorithm.GetOr(tlv.AeadAesGcm); alg != tlv.AeadXChaCha20Poly1305 {
+				t.Fatalf("algorithm = %d, want XChaCha20-Poly1305", alg)
+			}
+			if tt.rotate != nil {
+				tt.rotate(c)
+			}
+
+			// Through the wire, as received from sync
+			wire, err := tlv.ParseMessage(enc.NewWireView(epub.Encode()), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub, err := c.decryptPub(wire, tt.openIn)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("decryptPub() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pub.YjsDelta == nil || pub.YjsDelta.UUID != "doc" || !bytes.Equal(pub.YjsDelta.Binary, []byte("update")) {
+				t.Fatalf("decryptPub() = %+v, want the delta", pub)
+			}
+		})
+	}
+}
+
+func TestEncryptPubHeader(t *testing.T) {
+	c := testCrypto(t, 0)
+	delta := &tlv.Message{YjsDelta: &tlv.YjsDelta{UUID: "doc", Binary: []byte("update")}}
+	epub, err := c.encryptPub(delta, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another epoch with the same key opens the block only with its own header
+	c.keys[1] = c.keys[0]
+	block := *epub.AeadBlock
+	block.Epoch = optional.Some(uint64(1))
+	if _, err := c.decryptPub(&tlv.Message{AeadBlock: &block}, ""); err == nil {
+		t.Fatal("decryptPub() succeeded with a changed epoch, want error")
+	}
+	if _, err := c.decryptPub(epub, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecryptPubLegacy(t *testing.T) {
+	c := testCrypto(t, 0)
+	key := c.keys[0]
+	plain := (&tlv.Message{ChatMessage: &tlv.ChatMessage{Channel: "general", ID: "1", Body: "hi"}}).Encode().Join()
+
+	iv := make([]byte, 12)
+	if _, err := rand.Read(iv); err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(key.aes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := gcm.Seal(nil, iv, plain, nil)
+
+	tests := []struct {
+		name    string
+		block   *tlv.AeadBlock
+		wantErr bool
+	}{
+		{"no algorithm", &tlv.AeadBlock{IV: iv, Ciphertext: ct}, false},
+		{"AES-GCM", &tlv.AeadBlock{IV: iv, Ciphertext: ct, Algorithm: optional.Some(tlv.AeadAesGcm)}, false},
+		{"tampered", &tlv.AeadBlock{IV: iv, Ciphertext: append([]byte{ct[0] ^ 1}, ct[1:]...)}, true},
+		{"unknown algorithm", &tlv.AeadBlock{IV: iv, Ciphertext: ct, Algorithm: optional.Some(uint64(7))}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub, err := c.decryptPub(&tlv.Message{AeadBlock: tt.block}, "")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("decryptPub() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pub.ChatMessage == nil || pub.ChatMessage.Body != "hi" {
+				t.Fatalf("decryptPub() = %+v, want the chat message", pub)
+			}
+		})
+	}
+}
+
+func TestEncryptBlob(t *testing.T) {
+	c := testCrypto(t, 0)
+	segName, _ := enc.NameFromStr("/ws/proj/alice/doc/32=blob/b/seg=0")
+	otherSeg, _ := enc.NameFromStr("/ws/proj/alice/doc/32=blob/b/seg=1")
+	segment := []byte("segment")
+
+	msg, err := c.encryptBlob(segment, segName, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		segName enc.Name
+		msg     *tlv.Message
+		wantErr bool
+	}{
+		{"same segment", segName, msg, false},
+		{"moved segment", otherSeg, msg, true},
+		{"not encrypted", segName, &tlv.Message{}, true},
+		{"legacy algorithm", segName, &tlv.Message{AeadBlock: &tlv.AeadBlock{
+			IV:         msg.AeadBlock.IV,
+			Ciphertext: msg.AeadBlock.Ciphertext,
+			Epoch:      msg.AeadBlock.Epoch,
+		}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.decryptBlob(tt.msg, tt.segName, "")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("decryptBlob() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, segment) {
+				t.Fatalf("decryptBlob() = %q, want %q", got, segment)
+			}
+		})
+	}
+}
+
+func TestAddEpochKey(t *testing.T) {
+	dsk := randomKey(t)
+
+	tests := []struct {
+		name    string
+		epoch   uint64
+		dsk     []byte
+		want    uint64 // current epoch after adding
+		wantErr error
+	}{
+		{"same key again", 5, dsk, 5, nil},
+		{"newer epoch", 6, randomKey(t), 6, nil},
+		{"older epoch", 4, randomKey(t), 5, nil},
+		{"conflicting key", 5, randomKey(t), 5, errEpochConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newWorkspaceCrypto()
+			if err := c.SetKeys(randomKey(t), dsk, 5); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.AddEpochKey(tt.epoch, tt.dsk)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddEpochKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := c.Epoch(); got != tt.want {
+				t.Errorf("Epoch() = %d, want %d", got, tt.want)
+			}
+			if !bytes.Equal(c.keys[5].dsk, dsk) {
+				t.Error("key of epoch 5 was replaced")
+			}
+		})
+	}
+}
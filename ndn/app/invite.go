@@ -0,0 +1,360 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	"github.com/named-data/ndnd/std/object"
+	"github.com/named-data/ndnd/std/security/trust_schema"
+)
+
+// DefaultInviteValidity is the validity of invitations without an explicit expiry.
+const DefaultInviteValidity = 365 * 24 * time.Hour
+
+// Longest time before expiry that workspace certificates are renewed
+const renewBeforeMax = 7 * 24 * time.Hour
+
+// How often workspaces check if the certificate needs renewal
+const renewCheckInterval = 12 * time.Hour
+
+// Number of times the owners are asked for an invitation
+const inviteRetries = 20
+
+// Number of times the owners are asked for an invitation to a workspace
+// without metadata, which may not exist at all
+const inviteRetriesNoMeta = 2
+
+// errNoGrant is returned when neither the repo nor the owners have a grant
+var errNoGrant = errors.New("no grant found")
+
+// InviteOpts are the options of a workspace invitation.
+type InviteOpts struct {
+	// NotBefore is the start of the validity period (optional).
+	// If zero, the invitation is valid immediately.
+	NotBefore time.Time
+	// NotAfter is the expiry of the invitation, which also limits the
+	// workspace certificate of the invitee (optional).
+	// If zero, the invitation is valid for DefaultInviteValidity.
+	NotAfter time.Time
+	// Role is the permission level of the invitee (default editor).
+	Role Role
+}
+
+// validity returns the validity period for an invitation issued now.
+func (o InviteOpts) validity() (notBefore time.Time, notAfter time.Time, err error) {
+	now := time.Now()
+
+	notBefore = o.NotBefore
+	if notBefore.IsZero() {
+		notBefore = now.Add(-time.Hour) // clock skew
+	}
+	notAfter = o.NotAfter
+	if notAfter.IsZero() {
+		notAfter = now.Add(DefaultInviteValidity)
+	}
+
+	if !notAfter.After(now) {
+		return notBefore, notAfter, fmt.Errorf("invitation expiry %s is in the past", notAfter.Format(time.RFC3339))
+	}
+	if !notAfter.After(notBefore) {
+		return notBefore, notAfter, fmt.Errorf("invitation expires before it becomes valid")
+	}
+	return notBefore, notAfter, nil
+}
+
+// needsRenewal returns true if a certificate or invitation with the given
+// validity period should be renewed. This is the case in the last quarter
+// of the validity period, but at most renewBeforeMax before expiry.
+func needsRenewal(notBefore time.Time, notAfter time.Time) bool {
+	before := min(notAfter.Sub(notBefore)/4, renewBeforeMax)
+	return time.Now().After(notAfter.Add(-before))
+}
+
+// dataValidity returns the validity period of a signed Data packet.
+func dataValidity(data ndn.Data) (notBefore time.Time, notAfter time.Time, err error) {
+	nb, na := data.Signature().Validity()
+	var ok bool
+	if notBefore, ok = nb.Get(); !ok {
+		return notBefore, notAfter, fmt.Errorf("%s has no validity period", data.Name())
+	}
+	if notAfter, ok = na.Get(); !ok {
+		return notBefore, notAfter, fmt.Errorf("%s has no validity period", data.Name())
+	}
+	return notBefore, notAfter, nil
+}
+
+// invitePrefix is the name prefix of the invitation of a user to a workspace.
+// /<wksp>/root/32=INVITE/<invitee>
+func invitePrefix(wkspName enc.Name, invitee enc.Name) enc.Name {
+	return wkspName.
+		Append(enc.NewGenericComponent("root")).
+		Append(enc.NewKeywordComponent("INVITE")).
+		Append(invitee...)
+}
+
+// verifyInvitation checks that an invitation allows idName to join the workspace.
+// The invitation must be signed by an owner as required by the trust schema,
+// currently valid, and only grant our identity keys within the workspace.
+// Returns the expiry of the invitation.
+func (a *App) verifyInvitation(wkspName enc.Name, idName enc.Name, wire enc.Wire) (notAfter time.Time, err error) {
+	// The invitation must at least authorize our workspace key
+	scope := wkspName.Append(idName...).Append(enc.NewGenericComponent("KEY"))
+	return a.verifyGrant("invitation", wkspName, invitePrefix(wkspName, idName), scope, idName, wire)
+}
+
+// verifyGrant checks a CrossSchema signed by an owner of the workspace.
+// The grant must pass checkGrant. Returns the expiry of the grant.
+func (a *App) verifyGrant(
+	kind string,
+	wkspName enc.Name,
+	prefix enc.Name,
+	scope enc.Name,
+	idName enc.Name,
+	wire enc.Wire,
+) (notAfter time.Time, err error) {
+	data, sigCov, err := spec.Spec{}.ReadData(enc.NewWireView(wire))
+	if err != nil {
+		return notAfter, fmt.Errorf("malformed %s: %w", kind, err)
+	}
+	if notAfter, err = checkGrant(kind, wkspName, prefix, scope, idName, data); err != nil {
+		return notAfter, err
+	}
+
+	// Signature must be from an owner and chain to the trust anchor
+	if err := a.validateData(data, sigCov); err != nil {
+		return notAfter, fmt.Errorf("%s is not signed by an owner of %s: %w", kind, wkspName, err)
+	}
+	return notAfter, nil
+}
+
+// checkGrant checks a CrossSchema without its signature.
+// The name must be prefix with a version, and one rule must let the
+// identity key of idName sign under scope. Returns the expiry of the grant.
+func checkGrant(
+	kind string,
+	wkspName enc.Name,
+	prefix enc.Name,
+	scope enc.Name,
+	idName enc.Name,
+	data ndn.Data,
+) (notAfter time.Time, err error) {
+	// Name must be /<prefix>/v=<time>
+	name := data.Name()
+	if len(name) != len(prefix)+1 || !prefix.IsPrefix(name) || !name.At(-1).IsVersion() {
+		return notAfter, fmt.Errorf("%s %s is not for %s in %s", kind, name, idName, wkspName)
+	}
+
+	// Validity period must cover now
+	now := time.Now()
+	notBefore, notAfter, err := dataValidity(data)
+	if err != nil {
+		return notAfter, err
+	}
+	if now.Before(notBefore) {
+		return notAfter, fmt.Errorf("%s to %s is not valid until %s", kind, wkspName, notBefore.Format(time.RFC3339))
+	}
+	if now.After(notAfter) {
+		return notAfter, fmt.Errorf("%s to %s expired at %s", kind, wkspName, notAfter.Format(time.RFC3339))
+	}
+
+	// Every rule must stay in the workspace, and one must authorize our identity key
+	content, err := trust_schema.ParseCrossSchemaContent(enc.NewWireView(data.Content()), true)
+	if err != nil {
+		return notAfter, fmt.Errorf("malformed %s content: %w", kind, err)
+	}
+	if len(content.PrefixSchemaRules) > 0 {
+		return notAfter, fmt.Errorf("%s to %s has unexpected prefix rules", kind, wkspName)
+	}
+	idKeyPrefix := idName.Append(enc.NewGenericComponent("KEY"))
+	authorized := false
+	for _, rule := range content.SimpleSchemaRules {
+		if !wkspName.IsPrefix(rule.NamePrefix) {
+			return notAfter, fmt.Errorf("%s rule %s is outside the workspace %s", kind, rule.NamePrefix, wkspName)
+		}
+		if rule.KeyLocator != nil && idKeyPrefix.IsPrefix(rule.KeyLocator.Name) &&
+			rule.NamePrefix.IsPrefix(scope) {
+			authorized = true
+		}
+	}
+	if !authorized {
+		return notAfter, fmt.Errorf("%s does not authorize %s in %s", kind, idName, wkspName)
+	}
+	return notAfter, nil
+}
+
+// fetchInvitation fetches and verifies our invitation to a workspace.
+// The repo is tried first, then the owners are asked directly.
+// The invitation must expire after the given time to be accepted,
+// which allows renewing certificates with newer invitations.
+func (a *App) fetchInvitation(wkspName enc.Name, idName enc.Name, after time.Time, retries int) (enc.Wire, error) {
+	inviteName := invitePrefix(wkspName, idName)
+
+	// Name to request access from workspace initiator
+	// accessRequestName := multicastPrefix.Append(inviteName...) // Uncomment if you want to use multicast
+	accessRequestName := inviteName
+
+	wire, _, err := a.fetchGrant(wkspName, inviteName, accessRequestName, retries, func(wire enc.Wire) (time.Time, error) {
+		notAfter, err := a.verifyInvitation(wkspName, idName, wire)
+		if err == nil && !notAfter.After(after) {
+			err = fmt.Errorf("invitation to %s expires at %s and cannot be renewed",
+				wkspName, notAfter.Format(time.RFC3339))
+		}
+		return notAfter, err
+	})
+	if errors.Is(err, errNoGrant) {
+		return nil, fmt.Errorf("failed to get invitation, make sure %s is invited to %s (%w)", idName, wkspName, err)
+	}
+	return wire, err
+}
+
+// fetchGrant fetches a CrossSchema signed by the owners of a workspace.
+// The repo is tried first, then the owners are asked with ownerName,
+// with the given number of retries. Returns the grant and its expiry.
+func (a *App) fetchGrant(
+	wkspName enc.Name,
+	name enc.Name,
+	ownerName enc.Name,
+	retries int,
+	verify func(enc.Wire) (time.Time, error),
+) (enc.Wire, time.Time, error) {
+	// Fetch the grant from each repo (there is none in LAN-only mode)
+	ch := make(chan ndn.ExpressCallbackArgs)
+	var repos []enc.Name
+	if !a.lan {
+		repos = a.workspaceRepos(wkspName)
+	}
+	for _, repo := range repos {
+		log.Info(a, "Fetching grant from repo", "name", name, "repo", repo)
+		object.ExpressR(a.engine, ndn.ExpressRArgs{
+			Name: name,
+			Config: &ndn.InterestConfig{
+				MustBeFresh:    true,
+				CanBePrefix:    true,
+				ForwardingHint: []enc.Name{repo},
+			},
+			Retries:  1,
+			Callback: func(args ndn.ExpressCallbackArgs) { ch <- args },
+		})
+		args := <-ch
+		if args.Result != ndn.InterestResultData {
+			continue
+		}
+
+		// A stale grant in the repo may be replaced by asking the owner
+		notAfter, err := verify(args.RawData)
+		if err == nil {
+			log.Info(a, "Got grant", "name", args.Data.Name())
+			return args.RawData, notAfter, nil
+		}
+		log.Warn(a, "Invalid grant in repo", "name", args.Data.Name(), "repo", repo, "err", err)
+	}
+
+	// If the grant is not found, ask the owners directly
+	log.Info(a, "Fetching grant from owners", "name", ownerName)
+	object.ExpressR(a.engine, ndn.ExpressRArgs{
+		Name: ownerName,
+		Config: &ndn.InterestConfig{
+			MustBeFresh: true,
+			CanBePrefix: true,
+		},
+		Retries:  retries,
+		Callback: func(args ndn.ExpressCallbackArgs) { ch <- args },
+	})
+	args := <-ch
+	if args.Result != ndn.InterestResultData {
+		// Failed if all attempts do not return data
+		return nil, time.Time{}, fmt.Errorf("%w: %s", errNoGrant, args.Result)
+	}
+
+	// Make sure the grant is what we asked for before using it
+	notAfter, err := verify(args.RawData)
+	if err != nil {
+		return nil, notAfter, err
+	}
+
+	log.Info(a, "Got grant", "name", args.Data.Name())
+	return args.RawData, notAfter, nil
+}
+
+// RenewWorkspaceCert signs a new workspace certificate if the current one
+// is about to expire. Members need a newer invitation from the owners for this.
+// Returns true if the certificate was renewed.
+func (a *App) RenewWorkspaceCert(wkspStr string) (bool, error) {
+	wkspName, err := enc.NameFromStr(wkspStr)
+	if err != nil {
+		return false, err
+	}
+
+	idSigner, _ := a.workspaceIdKey(wkspName)
+	if idSigner == nil {
+		return false, fmt.Errorf("no identity key found")
+	}
+	idName := idSigner.KeyName().Prefix(-2) // pop KeyId and KEY
+
+	// Find the workspace certificate that expires last
+	var notBefore, notAfter time.Time
+	if id := a.keychain.IdentityByName(wkspName.Append(idName...)); id != nil {
+		for _, key := range id.Keys() {
+			for _, certName := range key.UniqueCerts() {
+				certWire, _ := a.keychain.Store().Get(certName.Prefix(-1), true)
+				if certWire == nil {
+					continue
+				}
+				cert, _, err := spec.Spec{}.ReadData(enc.NewBufferView(certWire))
+				if err != nil {
+					continue
+				}
+				nb, na, err := dataValidity(cert)
+				if err == nil && na.After(notAfter) {
+					notBefore, notAfter = nb, na
+				}
+			}
+		}
+	}
+	if notAfter.IsZero() {
+		return false, fmt.Errorf("no workspace certificate for %s, join the workspace first", wkspName)
+	}
+	if !needsRenewal(notBefore, notAfter) {
+		return false, nil
+	}
+	log.Info(a, "Renewing workspace certificate", "name", wkspName, "expiry", notAfter)
+
+	// Owners do not need an invitation
+	var invitation enc.Wire = nil
+	if !idName.IsPrefix(wkspName) {
+		if invitation, err = a.fetchInvitation(wkspName, idName, notAfter, inviteRetries); err != nil {
+			return false, err
+		}
+	}
+
+	if err := a.SignWorkspaceCert(wkspName, idName, idSigner, invitation); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// renewCert periodically renews the workspace certificate until stop is closed.
+func (w *Workspace) renewCert(stop chan struct{}) {
+	ticker := time.NewTicker(renewCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		renewed, err := w.app.RenewWorkspaceCert(w.group.String())
+		if err != nil {
+			log.Warn(w, "Failed to renew workspace certificate", "err", err)
+		} else if renewed {
+			log.Info(w, "Renewed workspace certificate")
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"crypto/elliptic"
+	"testing"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	sig "github.com/named-data/ndnd/std/security/signer"
+	"github.com/named-data/ndnd/std/security/trust_schema"
+)
+
+func TestNeedsRenewal(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+		want      bool
+	}{
+		{"new long grant", now.Add(-time.Hour), now.Add(365 * day), false},
+		{"long grant in last week", now.Add(-300 * day), now.Add(6 * day), true},
+		{"long grant in last quarter", now.Add(-300 * day), now.Add(60 * day), false},
+		{"short grant", now.Add(-time.Hour), now.Add(7 * time.Hour), false},
+		{"short grant in last quarter", now.Add(-7 * time.Hour), now.Add(time.Hour), true},
+		{"expired", now.Add(-2 * day), now.Add(-day), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsRenewal(tt.notBefore, tt.notAfter); got != tt.want {
+				t.Errorf("needsRenewal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckGrant(t *testing.T) {
+	wksp, _ := enc.NameFromStr("/ndn/owner/ws")
+	alice, _ := enc.NameFromStr("/ndn/alice")
+	bob, _ := enc.NameFromStr("/ndn/bob")
+	other, _ := enc.NameFromStr("/ndn/owner/other")
+	keyName, _ := enc.NameFromStr("/ndn/owner/ws/ndn/owner/KEY/k")
+	signer, err := sig.KeygenEcc(keyName, elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	valid := [2]time.Time{now.Add(-time.Hour), now.Add(time.Hour)}
+	aliceScope := wksp.Append(alice...).Append(enc.NewGenericComponent("KEY"))
+
+	tests := []struct {
+		name     string
+		prefix   enc.Name // of the grant name, before the version
+		validity [2]time.Time
+		rules    []*trust_schema.SimpleSchemaRule
+		prefixes []*trust_schema.PrefixSchemaRule
+		wantRole Role
+		wantErr  bool
+	}{
+		{
+			name:     "editor",
+			prefix:   invitePrefix(wksp, alice),
+			validity: valid,
+			rules:    inviteRules(wksp, alice, RoleEditor),
+			wantRole: RoleEditor,
+		},
+		{
+			name:     "viewer",
+			prefix:   invitePrefix(wksp, alice),
+			validity: valid,
+			rules:    inviteRules(wksp, alice, RoleViewer),
+			wantRole: RoleViewer,
+		},
+		{
+			name:     "commenter",
+			prefix:   invitePrefix(wksp, alice),
+			validity: valid,
+			rules:    inviteRules(wksp, alice, RoleCommenter),
+			wantRole: RoleCommenter,
+		},
+		{
+			name:     "grant of another member",
+			prefix:   invitePrefix(wksp, bob),
+			validity: valid,
+			rules:    inviteRules(wksp, alice, RoleEditor),
+			wantErr:  true,
+		},
+		{
+			name:     "not yet valid",
+			prefix:   invitePrefix(wksp, alice),
+			validity: [2]time.Time{now.Add(time.Hour), now.Add(2 * time.Hour)},
+			rules:    inviteRules(wksp, alice, RoleEditor),
+			wantErr:  true,
+		},
+		{
+			name:     "expired",
+			prefix:   invitePrefix(wksp, alice),
+			validity: [2]time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour)},
+			rules:    inviteRules(wksp, alice, RoleEditor),
+			wantErr:  true,
+		},
+		{
+			name:     "rule outside the workspace",
+			prefix:   invitePrefix(wksp, alice),
+			validity: valid,
+			rules:    append(inviteRules(wksp, alice, RoleEditor), inviteRules(other, alice, RoleEditor)...),
+			wantErr:  true,
+		},
+		{
+			name:     "rule for another member",
+			prefix:   invitePrefix(wksp, alice),
+			validity: valid,
+			rules:    inviteRules(wksp, bob, RoleEditor),
+			wantErr:  true,
+		},
+		{
+			name:     "prefix rule",
+			prefix:   invitePrefix(wksp, alice),
+			validity: valid,
+			rules:    inviteRules(wksp, alice, RoleEditor),
+			prefixes: []*trust_schema.PrefixSchemaRule{{NamePrefix: wksp}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wire, err := trust_schema.SignCrossSchema(trust_schema.SignCrossSchemaArgs{
+				Name:   tt.prefix.WithVersion(enc.VersionUnixMicro),
+				Signer: signer,
+				Content: trust_schema.CrossSchemaContent{
+					SimpleSchemaRules: tt.rules,
+					PrefixSchemaRules: tt.prefixes,
+				},
+				NotBefore: tt.validity[0],
+				NotAfter:  tt.validity[1],
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _, err := spec.Spec{}.ReadData(enc.NewWireView(wire))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			notAfter, err := checkGrant("invitation", wksp, invitePrefix(wksp, alice), aliceScope, alice, data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("checkGrant() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The validity period has a precision of seconds
+			if d := notAfter.Sub(tt.validity[1]); d < -time.Second || d > time.Second {
+				t.Errorf("checkGrant() expiry = %s, want %s", notAfter, tt.validity[1])
+			}
+
+			role, err := inviteRole(wksp, alice, wire)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if role != tt.wantRole {
+				t.Errorf("inviteRole() = %s, want %s", role, tt.wantRole)
+			}
+		})
+	}
+}
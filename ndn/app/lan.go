@@ -0,0 +1,238 @@
+//go:build !js
+
+package app
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+)
+
+// DefaultLanGroup is the NDN multicast group used in LAN-only mode.
+const DefaultLanGroup = "udp4://224.0.23.170:56363"
+
+// Largest NDN packet that is received on a multicast face
+const lanMaxPacket = 8800
+
+// MulticastDialer creates faces that exchange packets directly with
+// peers on the LAN over UDP multicast, without a forwarder.
+type MulticastDialer struct {
+	router string
+	group  *net.UDPAddr
+	iface  *net.Interface
+}
+
+// NewMulticastDialer creates a dialer for a multicast group URI,
+// e.g. udp4://224.0.23.170:56363, on the given network interface.
+// If the interface is empty, the system default is used.
+func NewMulticastDialer(router string, iface string) (*MulticastDialer, error) {
+	uri, err := url.Parse(router)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multicast group %s: %w", router, err)
+	}
+	if uri.Scheme != "udp4" {
+		return nil, fmt.Errorf("unsupported multicast scheme: %s", uri.Scheme)
+	}
+
+	d := &MulticastDialer{router: router}
+	if d.group, err = net.ResolveUDPAddr("udp4", uri.Host); err != nil {
+		return nil, fmt.Errorf("invalid multicast group %s: %w", router, err)
+	}
+	if !d.group.IP.IsMulticast() {
+		return nil, fmt.Errorf("%s is not a multicast group", router)
+	}
+	if iface != "" {
+		if d.iface, err = net.InterfaceByName(iface); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Transport is empty since there are no routers (no FCH).
+func (d *MulticastDialer) Transport() string {
+	return ""
+}
+
+func (d *MulticastDialer) DefaultRouter() string {
+	return d.router
+}
+
+func (d *MulticastDialer) Dial(endpoint string) (ndn.Face, error) {
+	if endpoint != d.router {
+		return nil, fmt.Errorf("unknown multicast group: %s", endpoint)
+	}
+	return &MulticastFace{
+		group:  d.group,
+		iface:  d.iface,
+		onUp:   make(map[int]func()),
+		onDown: make(map[int]func()),
+	}, nil
+}
+
+// MulticastFace sends every packet to all peers in a multicast group.
+// Each peer answers Interests from its own handlers, so SVS, awareness
+// and blob fetches work between peers without any router.
+type MulticastFace struct {
+	group *net.UDPAddr
+	iface *net.Interface
+
+	mutex   sync.Mutex
+	conn    *net.UDPConn
+	onPkt   func(frame []byte)
+	onError func(err error)
+	onUp    map[int]func()
+	onDown  map[int]func()
+	nextId  int
+}
+
+func (f *MulticastFace) String() string {
+	return fmt.Sprintf("multicast-face (%s)", f.group)
+}
+
+func (f *MulticastFace) IsRunning() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.conn != nil
+}
+
+func (f *MulticastFace) IsLocal() bool {
+	return false
+}
+
+func (f *MulticastFace) OnPacket(onPkt func(frame []byte)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.onPkt = onPkt
+}
+
+func (f *MulticastFace) OnError(onError func(err error)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.onError = onError
+}
+
+// Open joins the multicast group.
+// Multicast loopback is disabled, so we do not receive our own packets.
+func (f *MulticastFace) Open() error {
+	conn, err := net.ListenMulticastUDP("udp4", f.iface, f.group)
+	if err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	f.conn = conn
+	f.mutex.Unlock()
+
+	go f.receive(conn)
+	f.fire(f.onUp)
+	return nil
+}
+
+func (f *MulticastFace) Close() error {
+	f.mutex.Lock()
+	conn := f.conn
+	f.mutex.Unlock()
+
+	if f.down(conn) {
+		return conn.Close()
+	}
+	return nil
+}
+
+func (f *MulticastFace) Send(pkt enc.Wire) error {
+	f.mutex.Lock()
+	conn := f.conn
+	f.mutex.Unlock()
+	if conn == nil {
+		return fmt.Errorf("face is not running")
+	}
+
+	_, err := conn.WriteToUDP(pkt.Join(), f.group)
+	return err
+}
+
+func (f *MulticastFace) OnUp(onUp func()) (cancel func()) {
+	return f.addHandler(f.onUp, onUp)
+}
+
+func (f *MulticastFace) OnDown(onDown func()) (cancel func()) {
+	return f.addHandler(f.onDown, onDown)
+}
+
+func (f *MulticastFace) addHandler(handlers map[int]func(), handler func()) (cancel func()) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	id := f.nextId
+	f.nextId++
+	handlers[id] = handler
+	return func() {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		delete(handlers, id)
+	}
+}
+
+func (f *MulticastFace) fire(handlers map[int]func()) {
+	f.mutex.Lock()
+	list := make([]func(), 0, len(handlers))
+	for _, handler := range handlers {
+		list = append(list, handler)
+	}
+	f.mutex.Unlock()
+
+	for _, handler := range list {
+		handler()
+	}
+}
+
+// down marks the face as not running and fires the down handlers.
+// Returns false if the connection is not the running one.
+func (f *MulticastFace) down(conn *net.UDPConn) bool {
+	f.mutex.Lock()
+	if conn == nil || f.conn != conn {
+		f.mutex.Unlock()
+		return false
+	}
+	f.conn = nil
+	f.mutex.Unlock()
+
+	f.fire(f.onDown)
+	return true
+}
+
+// receive passes packets from peers to the engine until the connection fails.
+func (f *MulticastFace) receive(conn *net.UDPConn) {
+	buf := make([]byte, lanMaxPacket)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// Closed by us, or a network error
+			if f.down(conn) {
+				log.Warn(f, "Multicast face failed", "err", err)
+				f.mutex.Lock()
+				onError := f.onError
+				f.mutex.Unlock()
+				if onError != nil {
+					onError(err)
+				}
+				conn.Close()
+			}
+			return
+		}
+
+		f.mutex.Lock()
+		onPkt := f.onPkt
+		f.mutex.Unlock()
+		if onPkt != nil {
+			frame := make([]byte, n)
+			copy(frame, buf[:n])
+			onPkt(frame)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	"github.com/named-data/ndnd/std/object"
+	"github.com/named-data/ndnd/std/types/optional"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+// WorkspaceSchemaVersion is the version of the workspace layout used by this app.
+// Workspaces with a newer version cannot be joined.
+const WorkspaceSchemaVersion = 1
+
+// errNoMeta is returned when neither the store, the repos nor the owners
+// have metadata of a workspace
+var errNoMeta = errors.New("no workspace metadata")
+
+// Local store prefix for the metadata versions sent to the repo
+var metaStorePrefix, _ = enc.NameFromStr("/localhost/ownly/32=meta")
+
+// WorkspaceInfo is the signed metadata of a workspace.
+type WorkspaceInfo struct {
+	// Name is the name of the workspace.
+	Name enc.Name
+	// Label is the display name of the workspace.
+	Label string
+	// Owner is the identity that created the workspace.
+	Owner enc.Name
+	// Created is the creation time of the workspace.
+	Created time.Time
+	// SchemaVersion is the version of the workspace layout.
+	SchemaVersion uint64
+	// Repo is the main repo storing the workspace data.
+	// Older versions of the app only know this repo.
+	Repo enc.Name
+	// Repos are all repos storing the workspace data, starting with Repo.
+	Repos []enc.Name
+	// Algorithm is the AEAD algorithm for publications.
+	Algorithm uint64
+}
+
+// metaPrefix is the name prefix of the workspace metadata.
+// /<wksp>/root/32=META
+func metaPrefix(wkspName enc.Name) enc.Name {
+	return wkspName.
+		Append(enc.NewGenericComponent("root")).
+		Append(enc.NewKeywordComponent("META"))
+}
+
+// GetWorkspaceInfo returns the metadata of the workspace with the given name.
+// The metadata is fetched from the network if not available locally.
+func (a *App) GetWorkspaceInfo(wkspStr string) (*WorkspaceInfo, error) {
+	wkspName, err := enc.NameFromStr(wkspStr)
+	if err != nil {
+		return nil, err
+	}
+	return a.fetchWorkspaceMeta(wkspName)
+}
+
+// publishWorkspaceMeta signs new metadata for a workspace we own.
+func (a *App) publishWorkspaceMeta(wkspName enc.Name, owner enc.Name, label string) error {
+	if label == "" {
+		label = wkspName.String()
+	}
+
+	repo := a.workspaceProfile(wkspName).RepoPrefix.String()
+	return a.signWorkspaceMeta(wkspName, &tlv.WorkspaceMeta{
+		Label:         label,
+		Owner:         owner.String(),
+		Created:       uint64(time.Now().UnixMilli()),
+		SchemaVersion: WorkspaceSchemaVersion,
+		Repo:          repo,
+		Algorithm:     tlv.AeadXChaCha20Poly1305,
+		Repos:         []string{repo},
+	})
+}
+
+// signWorkspaceMeta signs a new version of the workspace metadata.
+// The metadata is inserted into the store, from where it is served.
+func (a *App) signWorkspaceMeta(wkspName enc.Name, meta *tlv.WorkspaceMeta) error {
+	name := metaPrefix(wkspName).WithVersion(enc.VersionUnixMicro)
+	signer := a.trust.Suggest(name)
+	if signer == nil {
+		return fmt.Errorf("no valid key to sign workspace metadata")
+	}
+
+	data, err := spec.Spec{}.MakeData(name, &ndn.DataConfig{}, meta.Encode(), signer)
+	if err != nil {
+		return err
+	}
+	if err := a.store.Put(name, data.Wire.Join()); err != nil {
+		return err
+	}
+
+	log.Info(a, "Published workspace metadata", "name", name)
+	return nil
+}
+
+// fetchWorkspaceMeta fetches and validates the latest metadata of a workspace.
+// The local store is checked first, then the repo and the owners are asked.
+func (a *App) fetchWorkspaceMeta(wkspName enc.Name) (*WorkspaceInfo, error) {
+	prefix := metaPrefix(wkspName)
+
+	args := a.fetchLatest(prefix, a.workspaceRepos(wkspName), 1, true)
+	if args.Result != ndn.InterestResultData {
+		return nil, fmt.Errorf("workspace %s does not exist or its owner is offline (%w: %s)",
+			wkspName, errNoMeta, args.Result)
+	}
+
+	info, err := a.verifyWorkspaceMeta(wkspName, args.Data, args.SigCovered)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the metadata for the workspace
+	if err := a.store.Put(args.Data.Name(), args.RawData.Join()); err != nil {
+		log.Warn(a, "Failed to store workspace metadata", "err", err)
+	}
+	return info, nil
+}
+
+// fetchLatest fetches the latest version of a signed object of a workspace.
+// If tryStore is set, the local store is checked first. Then each repo and
+// the owners are asked (only the owners in LAN-only mode).
+// Without data, the result is a Nack only if all of them sent a Nack,
+// i.e. the object does not exist. The caller must validate the object.
+func (a *App) fetchLatest(prefix enc.Name, repos []enc.Name, retries int, tryStore bool) ndn.ExpressCallbackArgs {
+	var store ndn.Store
+	if tryStore {
+		store = a.store
+	}
+
+	fetch := func(hint []enc.Name) ndn.ExpressCallbackArgs {
+		ch := make(chan ndn.ExpressCallbackArgs, 1)
+		object.ExpressR(a.engine, ndn.ExpressRArgs{
+			Name: prefix,
+			Config: &ndn.InterestConfig{
+				MustBeFresh:    true,
+				CanBePrefix:    true,
+				ForwardingHint: hint,
+				Lifetime:       optional.Some(2 * time.Second),
+			},
+			Retries:  retries,
+			TryStore: store,
+			Callback: func(args ndn.ExpressCallbackArgs) { ch <- args },
+		})
+		return <-ch
+	}
+
+	var failed *ndn.ExpressCallbackArgs
+	if !a.lan {
+		for _, repo := range repos {
+			args := fetch([]enc.Name{repo})
+			switch args.Result {
+			case ndn.InterestResultData:
+				return args
+			case ndn.InterestResultNack:
+			default:
+				failed = &args
+			}
+		}
+	}
+	if args := fetch(nil); args.Result != ndn.InterestResultNack || failed == nil {
+		return args
+	}
+	return *failed
+}
+
+// verifyWorkspaceMeta checks that the metadata is signed by an owner and is
+// supported by this app, and returns the parsed metadata.
+func (a *App) verifyWorkspaceMeta(wkspName enc.Name, data ndn.Data, sigCov enc.Wire) (*WorkspaceInfo, error) {
+	// Name must be /<wksp>/root/32=META/v=<time>
+	name := data.Name()
+	prefix := metaPrefix(wkspName)
+	if len(name) != len(prefix)+1 || !prefix.IsPrefix(name) || !name.At(-1).IsVersion() {
+		return nil, fmt.Errorf("invalid workspace metadata name: %s", name)
+	}
+
+	meta, err := tlv.ParseWorkspaceMeta(enc.NewWireView(data.Content()), true)
+	if err != nil {
+		return nil, fmt.Errorf("malformed workspace metadata: %w", err)
+	}
+	info := &WorkspaceInfo{
+		Name:          wkspName,
+		Label:         meta.Label,
+		Created:       time.UnixMilli(int64(meta.Created)),
+		SchemaVersion: meta.SchemaVersion,
+		Algorithm:     meta.Algorithm,
+	}
+	if info.Owner, err = enc.NameFromStr(meta.Owner); err != nil {
+		return nil, fmt.Errorf("invalid workspace owner: %w", err)
+	}
+	if info.Repos, err = metaRepos(meta); err != nil {
+		return nil, err
+	}
+	info.Repo = info.Repos[0]
+
+	if !info.Owner.IsPrefix(wkspName) {
+		return nil, fmt.Errorf("workspace %s is not owned by %s", wkspName, info.Owner)
+	}
+	if info.SchemaVersion > WorkspaceSchemaVersion {
+		return nil, fmt.Errorf("workspace %s needs a newer version of the app (schema %d)",
+			wkspName, info.SchemaVersion)
+	}
+
+	// Signature must match #wksp_meta <= #owner_cert
+	if err := a.validateData(data, sigCov); err != nil {
+		return nil, fmt.Errorf("workspace metadata is not signed by an owner: %w", err)
+	}
+	return info, nil
+}
+
+// metaRepos returns the repos in the workspace metadata.
+// Metadata of older versions of the app only has the main repo.
+func metaRepos(meta *tlv.WorkspaceMeta) ([]enc.Name, error) {
+	list := meta.Repos
+	if len(list) == 0 {
+		list = []string{meta.Repo}
+	}
+
+	repos := make([]enc.Name, 0, len(list))
+	for _, repoStr := range list {
+		repo, err := enc.NameFromStr(repoStr)
+		if err != nil || len(repo) == 0 {
+			return nil, fmt.Errorf("invalid workspace repo: %q", repoStr)
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// workspaceRepos returns the repos of a workspace from the stored metadata.
+// If there is no metadata, the repo of the network profile is used.
+func (a *App) workspaceRepos(wkspName enc.Name) []enc.Name {
+	defaultRepos := []enc.Name{a.workspaceProfile(wkspName).RepoPrefix}
+
+	wire, _ := a.store.Get(metaPrefix(wkspName), true)
+	if wire == nil {
+		return defaultRepos
+	}
+	data, _, err := spec.Spec{}.ReadData(enc.NewBufferView(wire))
+	if err != nil {
+		return defaultRepos
+	}
+	meta, err := tlv.ParseWorkspaceMeta(enc.NewWireView(data.Content()), true)
+	if err != nil {
+		return defaultRepos
+	}
+	repos, err := metaRepos(meta)
+	if err != nil {
+		return defaultRepos
+	}
+	return repos
+}
+
+// SetRepos changes the repos storing the workspace data.
+// Only the creator of the workspace can do this, since the metadata is theirs.
+// The first repo is the main repo, which older versions of the app use.
+// Running projects are joined by the new repos.
+func (w *Workspace) SetRepos(repos []enc.Name) error {
+	if !w.isCreator() {
+		return fmt.Errorf("only the creator of the workspace can set its repos")
+	}
+	if len(repos) == 0 {
+		return fmt.Errorf("workspace needs at least one repo")
+	}
+
+	info, err := w.app.fetchWorkspaceMeta(w.group)
+	if err != nil {
+		return err
+	}
+	repoStrs := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		repoStrs = append(repoStrs, repo.String())
+	}
+	err = w.app.signWorkspaceMeta(w.group, &tlv.WorkspaceMeta{
+		Label:         info.Label,
+		Owner:         info.Owner.String(),
+		Created:       uint64(info.Created.UnixMilli()),
+		SchemaVersion: info.SchemaVersion,
+		Repo:          repoStrs[0],
+		Algorithm:     info.Algorithm,
+		Repos:         repoStrs,
+	})
+	if err != nil {
+		return err
+	}
+	w.repoc.setRepos(repos)
+
+	// Send the new metadata to all repos and join the projects
+	w.alos.Range(func(key, _ any) bool {
+		s := key.(*SvsAlo)
+		w.NotifyRepo(s.alo.GroupPrefix(), s.alo.DataPrefix())
+		return true
+	})
+	if root := w.root.Load(); root != nil {
+		go w.pushMeta(root)
+	}
+	return nil
+}
+
+// ensureMeta makes sure that a workspace we own has metadata.
+// Workspaces created by older versions of the app have none.
+func (w *Workspace) ensureMeta() {
+	if _, err := w.app.fetchWorkspaceMeta(w.group); err == nil {
+		return
+	}
+	if err := w.app.publishWorkspaceMeta(w.group, w.idName, ""); err != nil {
+		log.Error(w, "Failed to publish workspace metadata", "err", err)
+		return
+	}
+	if root := w.root.Load(); root != nil {
+		w.pushMeta(root)
+	}
+}
+
+// pushMeta sends the latest workspace metadata to the repo through the root
+// project, unless this version was sent before.
+func (w *Workspace) pushMeta(root *SvsAlo) {
+	wire, _ := w.app.store.Get(metaPrefix(w.group), true)
+	if wire == nil {
+		return
+	}
+	data, _, err := spec.Spec{}.ReadData(enc.NewBufferView(wire))
+	if err != nil {
+		return
+	}
+
+	marker := metaStorePrefix.Append(w.group...)
+	version := []byte(data.Name().String())
+	if pushed, _ := w.app.store.Get(marker, false); bytes.Equal(pushed, version) {
+		return
+	}
+
+	if _, err := root.PubBlobFetch(nil, wire); err != nil {
+		log.Warn(w, "Failed to send workspace metadata to repo", "err", err)
+		return
+	}
+	if err := w.app.store.Put(marker, version); err != nil {
+		log.Warn(w, "Failed to store workspace metadata marker", "err", err)
+	}
+}
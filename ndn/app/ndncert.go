@@ -1,8 +1,7 @@
-//go:build js && wasm
-
 package app
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -16,14 +15,21 @@ import (
 	sig "github.com/named-data/ndnd/std/security/signer"
 )
 
+// errLanNdncert is returned when requesting a certificate in LAN-only mode
+var errLanNdncert = errors.New("NDNCERT is not available in LAN-only mode")
+
 func (a *App) NdncertEmail(email string, CodeCb func(status string) string) (err error) {
+	if a.lan {
+		return errLanNdncert
+	}
+
 	// Connect to the testbed
 	if err := a.WaitForConnectivity(time.Second * 5); err != nil {
 		return err
 	}
 
 	// Create NDNCERT client
-	certClient, err := ndncert.NewClient(a.engine, testbedRootCert)
+	certClient, err := ndncert.NewClient(a.engine, a.defaultProfile().AnchorCert)
 	if err != nil {
 		return err
 	}
@@ -76,11 +82,14 @@ func (a *App) NdncertEmail(email string, CodeCb func(status string) string) (err
 }
 
 func (a *App) NdncertDns(domain string, ConfirmCb func(recordName, expectedValue, status string) string) (err error) {
+	if a.lan {
+		return errLanNdncert
+	}
 	if err := a.WaitForConnectivity(time.Second * 5); err != nil {
 		return err
 	}
 
-	certClient, err := ndncert.NewClient(a.engine, testbedRootCert)
+	certClient, err := ndncert.NewClient(a.engine, a.defaultProfile().AnchorCert)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"encoding/binary"
+	"sync"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+)
+
+// Local store prefix for publications made while offline
+var outboxStorePrefix, _ = enc.NameFromStr("/localhost/ownly/32=outbox")
+
+// outbox holds the publications of an SvsAlo instance made while the app
+// is not connected. They are kept in the store until they are published,
+// so that they survive a restart.
+//
+// /localhost/ownly/32=outbox/<group>/32=next is the index of the next entry
+// /localhost/ownly/32=outbox/<group>/32=pub/<seg> are the entries
+type outbox struct {
+	mutex  sync.Mutex
+	store  ndn.Store
+	prefix enc.Name
+	// Index of the first and next entry
+	first uint64
+	next  uint64
+}
+
+func newOutbox(store ndn.Store, group enc.Name) *outbox {
+	o := &outbox{
+		store:  store,
+		prefix: outboxStorePrefix.Append(group...),
+	}
+	if buf, _ := store.Get(o.nextName(), false); len(buf) == 8 {
+		o.next = binary.BigEndian.Uint64(buf)
+	}
+	return o
+}
+
+func (o *outbox) nextName() enc.Name {
+	return o.prefix.Append(enc.NewKeywordComponent("next"))
+}
+
+func (o *outbox) entryName(i uint64) enc.Name {
+	return o.prefix.Append(enc.NewKeywordComponent("pub"), enc.NewSegmentComponent(i))
+}
+
+// empty returns true if no publication is queued.
+// The caller must hold the mutex.
+func (o *outbox) empty() bool {
+	return o.first == o.next
+}
+
+// push queues a publication.
+// The caller must hold the mutex.
+func (o *outbox) push(content enc.Wire) error {
+	if err := o.store.Put(o.entryName(o.next), content.Join()); err != nil {
+		return err
+	}
+	o.next++
+	return o.store.Put(o.nextName(), binary.BigEndian.AppendUint64(nil, o.next))
+}
+
+// drain publishes the queued publications in order, until the callback fails.
+// Publications are removed from the store after they are published.
+// The caller must hold the mutex.
+func (o *outbox) drain(publish func(content enc.Wire) error) error {
+	for ; o.first < o.next; o.first++ {
+		name := o.entryName(o.first)
+		wire, _ := o.store.Get(name, false)
+		if wire == nil {
+			continue // published before a restart
+		}
+		if err := publish(enc.Wire{wire}); err != nil {
+			return err
+		}
+		if err := o.store.Remove(name); err != nil {
+			log.Warn(nil, "Failed to remove published entry from outbox", "name", name, "err", err)
+		}
+	}
+
+	// Start over when everything was published
+	o.first, o.next = 0, 0
+	return o.store.RemovePrefix(o.prefix)
+}
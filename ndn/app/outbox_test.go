@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/object/storage"
+)
+
+func TestOutbox(t *testing.T) {
+	group, _ := enc.NameFromStr("/ndn/owner/ws/proj")
+	errOffline := fmt.Errorf("offline")
+
+	tests := []struct {
+		name    string
+		pushed  []string
+		failAt  []int // publish call that fails, for each drain
+		restart bool  // open the outbox again after the first drain
+		want    [][]string
+	}{
+		{
+			name:   "in order",
+			pushed: []string{"a", "b", "c"},
+			failAt: []int{-1},
+			want:   [][]string{{"a", "b", "c"}},
+		},
+		{
+			name:   "resumes after failure",
+			pushed: []string{"a", "b", "c"},
+			failAt: []int{1, -1},
+			want:   [][]string{{"a"}, {"b", "c"}},
+		},
+		{
+			name:    "resumes after restart",
+			pushed:  []string{"a", "b", "c"},
+			failAt:  []int{2, -1},
+			restart: true,
+			want:    [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name:   "empty",
+			failAt: []int{-1},
+			want:   [][]string{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			o := newOutbox(store, group)
+			for _, content := range tt.pushed {
+				if err := o.push(enc.Wire{[]byte(content)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i, failAt := range tt.failAt {
+				if i == 1 && tt.restart {
+					o = newOutbox(store, group)
+				}
+
+				var got []string
+				err := o.drain(func(content enc.Wire) error {
+					if len(got) == failAt {
+						return errOffline
+					}
+					got = append(got, string(content.Join()))
+					return nil
+				})
+				if (err != nil) != (failAt >= 0) {
+					t.Fatalf("drain %d: error = %v", i, err)
+				}
+				if !slices.Equal(got, tt.want[i]) {
+					t.Fatalf("drain %d: published %v, want %v", i, got, tt.want[i])
+				}
+			}
+
+			if !o.empty() {
+				t.Error("outbox is not empty after draining")
+			}
+			if wire, _ := store.Get(o.prefix, true); wire != nil {
+				t.Error("outbox entries are left in the store")
+			}
+		})
+	}
+}
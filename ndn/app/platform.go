@@ -0,0 +1,58 @@
+package app
+
+import (
+	"github.com/named-data/ndnd/std/ndn"
+)
+
+// Platform provides the environment-specific dependencies of the App.
+// The WASM build fills this with JS shims, while native builds can
+// use any store and keychain implementation from NDNd.
+type Platform struct {
+	// Store is the object store backing the app and keychain.
+	Store ndn.Store
+	// KeyChain holds the identity keys and certificates.
+	KeyChain ndn.KeyChain
+	// Dialer creates faces to NDN routers.
+	Dialer FaceDialer
+	// Yjs merges Yjs updates for snapshot compression (optional).
+	// If nil, snapshots are stored without compression.
+	Yjs YjsMerger
+	// UI receives notifications for the user interface (optional).
+	UI UI
+	// Profiles are the NDN networks known to the app (optional).
+	// The app connects to the first one. If empty, the testbed is used.
+	Profiles []*NetworkProfile
+}
+
+// FaceDialer creates faces to NDN routers.
+type FaceDialer interface {
+	// Transport returns the FCH transport type (e.g. "wss" or "udp").
+	// If empty, FCH is skipped and the default router is always used.
+	Transport() string
+	// DefaultRouter returns a router to use in addition to the routers
+	// of the network profile and FCH, or an empty string.
+	DefaultRouter() string
+	// Dial creates a new face to the given endpoint.
+	Dial(endpoint string) (ndn.Face, error)
+}
+
+// YjsMerger merges a list of Yjs updates (V2 encoding) into one update.
+type YjsMerger interface {
+	MergeUpdates(updates [][]byte) ([]byte, error)
+}
+
+// UI receives asynchronous notifications from the app.
+type UI interface {
+	// OnConnChange is called when the connection state changes,
+	// with the current router.
+	OnConnChange(state ConnState, router RouterInfo)
+	// OnAccessRequests is called when the pending access requests
+	// to a workspace we own change, with the full list of requests.
+	OnAccessRequests(wksp string, requests []AccessRequest)
+}
+
+// nullUI is used when the platform does not provide a UI.
+type nullUI struct{}
+
+func (nullUI) OnConnChange(ConnState, RouterInfo)       {}
+func (nullUI) OnAccessRequests(string, []AccessRequest) {}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+)
+
+// Local store prefix for the network profile selected for each workspace
+var profileStorePrefix, _ = enc.NameFromStr("/localhost/ownly/32=profile")
+
+// NetworkProfile describes an NDN network that workspaces live in,
+// e.g. the global NDN testbed or a private network.
+type NetworkProfile struct {
+	// Name identifies the profile, e.g. "testbed".
+	Name string
+	// AnchorCert is the trust anchor certificate of the network.
+	AnchorCert []byte
+	// IdentityPrefix is the prefix of identities certified by the anchor.
+	// This is a single component, which is the network in the trust schema.
+	IdentityPrefix enc.Name
+	// RepoPrefix is the repo of new workspaces.
+	RepoPrefix enc.Name
+	// MulticastPrefix is the prefix of multicast sync Interests.
+	MulticastPrefix enc.Name
+	// Routers are used if FCH is skipped or returns nothing, e.g. wss://host/ws/
+	Routers []string
+	// FchServer is the URL of the FCH service. If empty, the default is used.
+	FchServer string
+	// FchNetwork is the network name for FCH queries. If empty, FCH is skipped.
+	FchNetwork string
+
+	// Name of the anchor certificate, from AnchorCert
+	anchorName enc.Name
+}
+
+// TestbedProfile returns the profile of the global NDN testbed.
+func TestbedProfile() *NetworkProfile {
+	repo, _ := enc.NameFromStr("/ndnd/ucla/repo")
+	multicast, _ := enc.NameFromStr("/ndn/multicast")
+	return &NetworkProfile{
+		Name:            "testbed",
+		AnchorCert:      testbedRootCert,
+		IdentityPrefix:  enc.Name{enc.NewGenericComponent("ndn")},
+		RepoPrefix:      repo,
+		MulticastPrefix: multicast,
+		Routers:         []string{"wss://suns.cs.ucla.edu/ws/"},
+		FchNetwork:      "ndn",
+	}
+}
+
+// networkProfileJson is the JSON form of a network profile.
+// The anchor certificate is base64 encoded.
+type networkProfileJson struct {
+	Name           string   `json:"name"`
+	Anchor         string   `json:"anchor"`
+	IdentityPrefix string   `json:"identityPrefix"`
+	Repo           string   `json:"repo"`
+	Multicast      string   `json:"multicast"`
+	Routers        []string `json:"routers,omitempty"`
+	FchServer      string   `json:"fchServer,omitempty"`
+	FchNetwork     string   `json:"fchNetwork,omitempty"`
+}
+
+// ParseNetworkProfiles parses a JSON profile, or a JSON array of profiles.
+func ParseNetworkProfiles(buf []byte) ([]*NetworkProfile, error) {
+	var list []networkProfileJson
+	if buf = bytes.TrimSpace(buf); len(buf) > 0 && buf[0] == '{' {
+		list = make([]networkProfileJson, 1)
+		if err := json.Unmarshal(buf, &list[0]); err != nil {
+			return nil, fmt.Errorf("invalid network profile: %w", err)
+		}
+	} else if err := json.Unmarshal(buf, &list); err != nil {
+		return nil, fmt.Errorf("invalid network profiles: %w", err)
+	}
+
+	profiles := make([]*NetworkProfile, 0, len(list))
+	for _, pj := range list {
+		anchor, err := base64.StdEncoding.DecodeString(pj.Anchor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid anchor of network %s: %w", pj.Name, err)
+		}
+		p := &NetworkProfile{
+			Name:       pj.Name,
+			AnchorCert: anchor,
+			Routers:    pj.Routers,
+			FchServer:  pj.FchServer,
+			FchNetwork: pj.FchNetwork,
+		}
+		if p.IdentityPrefix, err = enc.NameFromStr(pj.IdentityPrefix); err != nil {
+			return nil, fmt.Errorf("invalid identity prefix of network %s: %w", pj.Name, err)
+		}
+		if p.RepoPrefix, err = enc.NameFromStr(pj.Repo); err != nil {
+			return nil, fmt.Errorf("invalid repo of network %s: %w", pj.Name, err)
+		}
+		if p.MulticastPrefix, err = enc.NameFromStr(pj.Multicast); err != nil {
+			return nil, fmt.Errorf("invalid multicast prefix of network %s: %w", pj.Name, err)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+// init checks the profile and reads the name of the anchor certificate.
+func (p *NetworkProfile) init() error {
+	if p.Name == "" {
+		return fmt.Errorf("network profile has no name")
+	}
+	if len(p.IdentityPrefix) != 1 {
+		return fmt.Errorf("identity prefix of network %s must be one component", p.Name)
+	}
+	if len(p.RepoPrefix) == 0 || len(p.MulticastPrefix) == 0 {
+		return fmt.Errorf("network %s needs a repo and multicast prefix", p.Name)
+	}
+
+	anchor, _, err := spec.Spec{}.ReadData(enc.NewBufferView(p.AnchorCert))
+	if err != nil {
+		return fmt.Errorf("invalid anchor of network %s: %w", p.Name, err)
+	}
+	if !p.IdentityPrefix.IsPrefix(anchor.Name()) {
+		return fmt.Errorf("anchor of network %s is outside %s", p.Name, p.IdentityPrefix)
+	}
+	p.anchorName = anchor.Name()
+	return nil
+}
+
+// NetworkProfiles returns the profiles known to the app.
+// The first profile is the network the app connects to.
+func (a *App) NetworkProfiles() []*NetworkProfile {
+	return a.profiles
+}
+
+// defaultProfile is the network the app connects to.
+func (a *App) defaultProfile() *NetworkProfile {
+	return a.profiles[0]
+}
+
+// findProfile returns the profile with the given name, or nil.
+func (a *App) findProfile(name string) *NetworkProfile {
+	for _, p := range a.profiles {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// SetWorkspaceProfile selects the network profile of a workspace.
+// This must be done before joining the workspace.
+func (a *App) SetWorkspaceProfile(wkspStr string, profile string) error {
+	wkspName, err := enc.NameFromStr(wkspStr)
+	if err != nil {
+		return err
+	}
+	if a.findProfile(profile) == nil {
+		return fmt.Errorf("unknown network profile: %s", profile)
+	}
+	return a.store.Put(profileStorePrefix.Append(wkspName...), []byte(profile))
+}
+
+// WorkspaceProfile returns the name of the network profile of a workspace.
+func (a *App) WorkspaceProfile(wkspStr string) (string, error) {
+	wkspName, err := enc.NameFromStr(wkspStr)
+	if err != nil {
+		return "", err
+	}
+	return a.workspaceProfile(wkspName).Name, nil
+}
+
+// workspaceProfile returns the network profile of a workspace.
+// This is the selected profile, or the profile with the identity prefix of
+// the workspace name, or the default profile.
+func (a *App) workspaceProfile(wkspName enc.Name) *NetworkProfile {
+	if name, _ := a.store.Get(profileStorePrefix.Append(wkspName...), false); name != nil {
+		if p := a.findProfile(string(name)); p != nil {
+			return p
+		}
+		log.Warn(a, "Workspace has unknown network profile", "name", wkspName, "profile", string(name))
+	}
+
+	for _, p := range a.profiles {
+		if p.IdentityPrefix.IsPrefix(wkspName) {
+			return p
+		}
+	}
+	return a.defaultProfile()
+}
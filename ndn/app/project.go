@@ -0,0 +1,280 @@
+package app
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	"github.com/named-data/ndnd/std/types/optional"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+// How long a fetched member list is used before fetching it again
+const projectAclRefresh = 5 * time.Minute
+
+// How long the last known member list is used after a failed fetch
+const projectAclRetry = 30 * time.Second
+
+// Local store prefix for projects that were found to be public
+var publicProjStorePrefix, _ = enc.NameFromStr("/localhost/ownly/32=public")
+
+// projectAcl is the member list of a project.
+// Projects without a list are public to all members of the workspace.
+type projectAcl struct {
+	members map[string]bool
+	expiry  time.Time
+}
+
+// private returns true if the project has a member list.
+func (acl *projectAcl) private() bool {
+	return acl != nil && acl.members != nil
+}
+
+// allows returns true if the member is in the list, or the project is public.
+// Owners are not in the list unless added, the caller must check them.
+func (acl *projectAcl) allows(member enc.Name) bool {
+	return !acl.private() || acl.members[member.String()]
+}
+
+// projectAclPrefix is the name prefix of the member list of a private project.
+// /<wksp>/root/32=PROJ/<proj>
+func projectAclPrefix(wkspName enc.Name, proj string) enc.Name {
+	prefix := wkspName.
+		Append(enc.NewGenericComponent("root")).
+		Append(enc.NewKeywordComponent("PROJ"))
+	if proj == "" {
+		return prefix
+	}
+	return prefix.Append(enc.NewGenericComponent(proj))
+}
+
+// projectOf returns the name of the project with the given sync group,
+// or an empty string if the group is not a project of this workspace.
+func (w *Workspace) projectOf(group enc.Name) string {
+	if len(group) != len(w.group)+1 || !w.group.IsPrefix(group) {
+		return ""
+	}
+	return group.At(-1).String()
+}
+
+// SetProjectMembers makes a project private to the given members and the owners.
+// The signed member list is served by the owners and sent to the repo.
+// If we do not have a key for the project yet, a new key is made, which is
+// given to the members on request. Members that are removed from the list
+// keep the key they have, but do not get it anymore.
+func (w *Workspace) SetProjectMembers(proj string, members []enc.Name) error {
+	if !w.owner {
+		return fmt.Errorf("only owners can set project members")
+	}
+	if proj == "" || proj == "root" {
+		return fmt.Errorf("invalid private project: %q", proj)
+	}
+
+	// Make the key of the project before anyone is told that it is private
+	if !w.crypto.HasProjectKey(proj) {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		if err := w.crypto.SetProjectKey(proj, key); err != nil {
+			return err
+		}
+		if w.onProjectKey != nil {
+			w.onProjectKey(proj, key)
+		}
+	}
+
+	// Keep ourselves in the list, so our old publications are still accepted
+	acl := &tlv.ProjectAcl{Members: []string{w.idName.String()}}
+	for _, member := range members {
+		if !member.Equal(w.idName) {
+			acl.Members = append(acl.Members, member.String())
+		}
+	}
+
+	// /<wksp>/root/32=PROJ/<proj>/v=<time>
+	name := projectAclPrefix(w.group, proj).WithVersion(enc.VersionUnixMicro)
+	signer := w.client.SuggestSigner(name)
+	if signer == nil {
+		return fmt.Errorf("no valid key to sign project members")
+	}
+	data, err := spec.Spec{}.MakeData(name, &ndn.DataConfig{}, acl.Encode(), signer)
+	if err != nil {
+		return err
+	}
+	wire := data.Wire.Join()
+	if err := w.client.Store().Put(name, wire); err != nil {
+		return err
+	}
+
+	w.acls.Store(proj, newProjectAcl(acl))
+	log.Info(w, "Set project members", "project", proj, "members", len(acl.Members))
+
+	// Keep the member list in the repo
+	if root := w.root.Load(); root != nil {
+		if _, err := root.PubBlobFetch(nil, wire); err != nil {
+			log.Warn(w, "Failed to send project members to repo", "err", err)
+		}
+	}
+	return nil
+}
+
+// ProjectMembers returns the members of a private project,
+// or nil if the project is public.
+func (w *Workspace) ProjectMembers(proj string) ([]enc.Name, error) {
+	acl, err := w.projectAcl(proj)
+	if err != nil || !acl.private() {
+		return nil, err
+	}
+
+	members := make([]enc.Name, 0, len(acl.members))
+	for member := range acl.members {
+		name, err := enc.NameFromStr(member)
+		if err != nil {
+			continue
+		}
+		members = append(members, name)
+	}
+	return members, nil
+}
+
+// IsProjectMember returns true if we can open the project.
+func (w *Workspace) IsProjectMember(proj string) bool {
+	return w.owner || w.isProjectMember(proj, w.idName)
+}
+
+// isProjectMember returns true if the member can get the key of the project.
+// Owners are members of all projects, which may need a fetch to check.
+func (w *Workspace) isProjectMember(proj string, member enc.Name) bool {
+	acl, err := w.projectAcl(proj)
+	if err != nil {
+		log.Warn(w, "Failed to get project members", "project", proj, "err", err)
+		return false
+	}
+	return acl.allows(member) || w.isOwnerName(member)
+}
+
+// projectAcl returns the member list of a project.
+// The list is cached, and fetched again after projectAclRefresh.
+// If the fetch fails, the last known list is kept for projectAclRetry.
+func (w *Workspace) projectAcl(proj string) (*projectAcl, error) {
+	if proj == "" || proj == "root" {
+		return nil, nil
+	}
+	cached, ok := w.acls.Load(proj)
+	if ok && time.Now().Before(cached.(*projectAcl).expiry) {
+		return cached.(*projectAcl), nil
+	}
+
+	acl, err := w.fetchProjectAcl(proj)
+	if err != nil {
+		if !ok {
+			return nil, err
+		}
+		log.Warn(w, "Failed to fetch project members, keeping the last list", "project", proj, "err", err)
+		acl = &projectAcl{members: cached.(*projectAcl).members, expiry: time.Now().Add(projectAclRetry)}
+	}
+	w.acls.Store(proj, acl)
+	return acl, nil
+}
+
+// fetchProjectAcl fetches and validates the latest member list of a project.
+// The local store is checked first, then the repo and the owners are asked.
+// The project is public only if all of them answered that there is no
+// member list, or if that was the last answer and none can be fetched now.
+func (w *Workspace) fetchProjectAcl(proj string) (*projectAcl, error) {
+	prefix := projectAclPrefix(w.group, proj)
+	marker := publicProjStorePrefix.Append(prefix...)
+	args := w.app.fetchLatest(prefix, w.Repos(), 0, true)
+	switch args.Result {
+	case ndn.InterestResultData:
+	case ndn.InterestResultNack:
+		if err := w.client.Store().Put(marker, []byte{1}); err != nil {
+			log.Warn(w, "Failed to store public project marker", "err", err)
+		}
+		return &projectAcl{expiry: time.Now().Add(projectAclRefresh)}, nil
+	default:
+		// A member list would be in the store if we had seen one
+		if wire, _ := w.client.Store().Get(marker, false); wire != nil {
+			return &projectAcl{expiry: time.Now().Add(projectAclRetry)}, nil
+		}
+		return nil, fmt.Errorf("failed to fetch members of project %s: %s", proj, args.Result)
+	}
+
+	// Name must be /<wksp>/root/32=PROJ/<proj>/v=<time>
+	name := args.Data.Name()
+	if len(name) != len(prefix)+1 || !name.At(-1).IsVersion() {
+		return nil, fmt.Errorf("invalid project members name: %s", name)
+	}
+	content, err := tlv.ParseProjectAcl(enc.NewWireView(args.Data.Content()), true)
+	if err != nil {
+		return nil, fmt.Errorf("malformed project members: %w", err)
+	}
+
+	// Signature must match #proj_acl <= #owner_cert | #coowner_cert
+	if err := w.validate(args.Data, args.SigCovered); err != nil {
+		return nil, fmt.Errorf("project members are not signed by an owner: %w", err)
+	}
+
+	if err := w.client.Store().Put(name, args.RawData.Join()); err != nil {
+		log.Warn(w, "Failed to store project members", "err", err)
+	}
+	if err := w.client.Store().Remove(marker); err != nil {
+		log.Warn(w, "Failed to remove public project marker", "err", err)
+	}
+	return newProjectAcl(content), nil
+}
+
+func newProjectAcl(content *tlv.ProjectAcl) *projectAcl {
+	acl := &projectAcl{
+		members: make(map[string]bool, len(content.Members)),
+		expiry:  time.Now().Add(projectAclRefresh),
+	}
+	for _, member := range content.Members {
+		acl.members[member] = true
+	}
+	return acl
+}
+
+// fetchProjectKey requests the key of a private project in the background.
+// Other members of the project answer after checking the member list.
+func (w *Workspace) fetchProjectKey(proj string) {
+	root := w.root.Load()
+	if root == nil || !w.crypto.startProjectReq(proj) {
+		return
+	}
+
+	go func() {
+		defer w.crypto.endProjectReq(proj)
+
+		if !w.owner && !w.isProjectMember(proj, w.idName) {
+			log.Debug(w, "Not a member of private project", "project", proj)
+			return
+		}
+
+		_, key, priv, err := w.requestKey(root, &tlv.DSKRequest{
+			Project: optional.Some(proj),
+		})
+		if err != nil {
+			log.Error(w, "Failed to get project key", "project", proj, "err", err)
+			return
+		}
+
+		if w.onProjectKey != nil {
+			w.onProjectKey(proj, key)
+		}
+		if err := w.crypto.SetProjectKey(proj, key); err != nil {
+			log.Error(w, "Failed to set project key", "project", proj, "err", err)
+			return
+		}
+		log.Info(w, "Got project key", "project", proj)
+
+		if err := root.PubDskAck(priv); err != nil {
+			log.Warn(w, "Failed to acknowledge project key", "err", err)
+		}
+	}()
+}
@@ -0,0 +1,426 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	spec_repo "github.com/named-data/ndnd/repo/tlv"
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	"github.com/named-data/ndnd/std/object"
+	"github.com/named-data/ndnd/std/types/optional"
+)
+
+// Bounds of the backoff between SyncJoin attempts
+const repoJoinRetryMin = 2 * time.Second
+const repoJoinRetryMax = 5 * time.Minute
+
+// How often and how long the repo is asked for a blob after BlobFetch
+const repoBlobProbes = 6
+const repoBlobProbeInterval = 10 * time.Second
+
+// Longest time a blob is tracked after BlobFetch while we are offline
+const repoBlobOfflineWait = time.Hour
+
+// Keyword of the history snapshots under the data prefix of a publisher
+const repoSnapshotKeyword = "SNAP"
+
+// Status code of a successful repo command
+const repoStatusOk = 200
+
+// RepoCmdStatus is the status of a command sent to the repo.
+type RepoCmdStatus int
+
+const (
+	// RepoCmdNone means that the command was not sent.
+	RepoCmdNone RepoCmdStatus = iota
+	// RepoCmdPending means that the command was sent and is not acknowledged yet.
+	RepoCmdPending
+	// RepoCmdAcked means that the repo acknowledged the command.
+	// For BlobFetch, this means that the repo serves the blob.
+	RepoCmdAcked
+	// RepoCmdFailed means that the repo did not serve a blob after BlobFetch.
+	// SyncJoin is retried until acknowledged, so it never fails.
+	RepoCmdFailed
+)
+
+func (s RepoCmdStatus) String() string {
+	switch s {
+	case RepoCmdNone:
+		return "none"
+	case RepoCmdPending:
+		return "pending"
+	case RepoCmdAcked:
+		return "acked"
+	case RepoCmdFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// RepoStatus is the state of a repo for a project.
+type RepoStatus struct {
+	// Repo is the name of the repo.
+	Repo enc.Name
+	// Join is the status of the SyncJoin command for the project.
+	Join RepoCmdStatus
+	// JoinAttempts is the number of SyncJoin commands sent.
+	JoinAttempts int
+	// JoinedAt is the time the repo acknowledged the SyncJoin.
+	JoinedAt time.Time
+	// Error is the last error of a SyncJoin command, if any.
+	Error string
+	// Snapshot is the latest history snapshot of our publications
+	// held by the repo, or nil if it has none.
+	Snapshot enc.Name
+	// Number of blobs by the status of their BlobFetch command
+	BlobsPending int
+	BlobsStored  int
+	// FailedBlobs are the blobs the repo did not fetch.
+	FailedBlobs []enc.Name
+}
+
+// repoState tracks the commands sent to one repo for an SVS group.
+type repoState struct {
+	join     RepoCmdStatus
+	attempts int
+	joinedAt time.Time
+	err      error
+	// Closed to stop retrying the SyncJoin
+	cancel chan struct{}
+	// Status of BlobFetch commands by blob name
+	blobs map[string]RepoCmdStatus
+}
+
+// repoGroup tracks the commands sent to the repos for an SVS group.
+type repoGroup struct {
+	dataPrefix enc.Name
+	// State of each repo by name
+	repos map[string]*repoState
+}
+
+// repoClient sends commands to the repos of a workspace and tracks their status.
+// Every command is sent to all repos, so that each one has a full copy.
+type repoClient struct {
+	wksp   *Workspace
+	mutex  sync.Mutex
+	list   []enc.Name
+	groups map[string]*repoGroup
+}
+
+func newRepoClient(w *Workspace, repos []enc.Name) *repoClient {
+	return &repoClient{
+		wksp:   w,
+		list:   repos,
+		groups: make(map[string]*repoGroup),
+	}
+}
+
+func (r *repoClient) String() string {
+	return "repo-client"
+}
+
+// repos returns the repos of the workspace.
+func (r *repoClient) repos() []enc.Name {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return slices.Clone(r.list)
+}
+
+// setRepos replaces the repos of the workspace.
+// Running groups must be joined again to reach new repos.
+func (r *repoClient) setRepos(repos []enc.Name) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.list = slices.Clone(repos)
+}
+
+// group returns the tracked state of a group. The caller must hold the mutex.
+func (r *repoClient) group(group enc.Name) *repoGroup {
+	g := r.groups[group.String()]
+	if g == nil {
+		g = &repoGroup{repos: make(map[string]*repoState)}
+		r.groups[group.String()] = g
+	}
+	return g
+}
+
+// state returns the tracked state of a repo in a group.
+// The caller must hold the mutex.
+func (r *repoClient) state(group enc.Name, repo enc.Name) *repoState {
+	g := r.group(group)
+	st := g.repos[repo.String()]
+	if st == nil {
+		st = &repoState{blobs: make(map[string]RepoCmdStatus)}
+		g.repos[repo.String()] = st
+	}
+	return st
+}
+
+// join sends SyncJoin for the group to every repo until it acknowledges.
+// Joining again restarts the attempts, e.g. after reconnecting.
+func (r *repoClient) join(group enc.Name, dataPrefix enc.Name) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.group(group).dataPrefix = dataPrefix
+	for _, repo := range r.list {
+		st := r.state(group, repo)
+		if st.cancel != nil {
+			close(st.cancel)
+		}
+		cancel := make(chan struct{})
+		st.cancel = cancel
+		st.join = RepoCmdPending
+		st.attempts = 0
+		go r.joinLoop(st, group, dataPrefix, repo, cancel)
+	}
+}
+
+// leave stops sending SyncJoin for the group.
+// The repos stay in the group, since other members rely on them.
+func (r *repoClient) leave(group enc.Name) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	g := r.groups[group.String()]
+	if g == nil {
+		return
+	}
+	for _, st := range g.repos {
+		if st.cancel != nil {
+			close(st.cancel)
+			st.cancel = nil
+		}
+	}
+}
+
+func (r *repoClient) joinLoop(st *repoState, group enc.Name, dataPrefix enc.Name, repo enc.Name, cancel chan struct{}) {
+	// Wait for 1s so that routes get registered
+	delay := time.Second
+	for {
+		select {
+		case <-cancel:
+			return
+		case <-time.After(delay):
+		}
+		delay = min(max(2*delay, repoJoinRetryMin), repoJoinRetryMax)
+
+		// Attempts without a face would fail anyway
+		if !r.wksp.app.isOnline() {
+			continue
+		}
+
+		r.mutex.Lock()
+		st.attempts++
+		r.mutex.Unlock()
+
+		err := r.sendJoin(group, dataPrefix, repo)
+
+		r.mutex.Lock()
+		if st.cancel != cancel { // joined again or left
+			r.mutex.Unlock()
+			return
+		}
+		st.err = err
+		if err == nil {
+			st.join = RepoCmdAcked
+			st.joinedAt = time.Now()
+			st.cancel = nil
+		}
+		r.mutex.Unlock()
+
+		if err == nil {
+			log.Info(r, "Repo joined SVS group", "repo", repo, "group", group)
+			return
+		}
+		log.Warn(r, "Repo sync join command failed, retrying", "repo", repo, "group", group, "err", err, "retry", delay)
+	}
+}
+
+// sendJoin sends a SyncJoin command to a repo and waits for the response.
+func (r *repoClient) sendJoin(group enc.Name, dataPrefix enc.Name, repo enc.Name) error {
+	repoCmd := spec_repo.RepoCmd{
+		SyncJoin: &spec_repo.SyncJoin{
+			Protocol: &spec.NameContainer{Name: spec_repo.SyncProtocolSvsV3},
+			Group:    &spec.NameContainer{Name: group},
+			HistorySnapshot: &spec_repo.HistorySnapshotConfig{
+				Threshold: SnapshotThreshold,
+			},
+			MulticastPrefix: &spec.NameContainer{Name: r.wksp.profile.MulticastPrefix},
+		},
+	}
+
+	ch := make(chan error, 1)
+	r.wksp.client.ExpressCommand(
+		repo,
+		dataPrefix.Append(enc.NewKeywordComponent("repo-cmd")),
+		repoCmd.Encode(),
+		func(wire enc.Wire, err error) {
+			if err != nil {
+				ch <- err
+				return
+			}
+			res, err := spec_repo.ParseRepoCmdRes(enc.NewWireView(wire), true)
+			if err != nil {
+				ch <- fmt.Errorf("invalid repo response: %w", err)
+			} else if res.Status != repoStatusOk {
+				ch <- fmt.Errorf("repo refused with status %d: %s", res.Status, res.Message)
+			} else {
+				ch <- nil
+			}
+		})
+	return <-ch
+}
+
+// blobFetched tracks a BlobFetch command published in the group.
+// The repos do not answer these, so each repo is asked for the blob
+// until it serves it, or the blob is marked as failed for that repo.
+// Probes are not counted while offline, for at most repoBlobOfflineWait.
+// Tracking ends when the workspace is stopped.
+func (r *repoClient) blobFetched(group enc.Name, blobName enc.Name) {
+	key := blobName.String()
+	stop := r.wksp.stop
+	for _, repo := range r.repos() {
+		r.mutex.Lock()
+		st := r.state(group, repo)
+		st.blobs[key] = RepoCmdPending
+		r.mutex.Unlock()
+
+		go func() {
+			ticker := time.NewTicker(repoBlobProbeInterval)
+			defer ticker.Stop()
+
+			status := RepoCmdFailed
+			offlineUntil := time.Now().Add(repoBlobOfflineWait)
+			for probes := 0; probes < repoBlobProbes; {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+				}
+
+				// The command may be queued until we are online
+				if !r.wksp.app.isOnline() {
+					if time.Now().Before(offlineUntil) {
+						continue
+					}
+					break
+				}
+				probes++
+				if r.fetchRepo(blobName, repo, false) != nil {
+					status = RepoCmdAcked
+					break
+				}
+			}
+			if status == RepoCmdFailed {
+				log.Warn(r, "Repo did not fetch blob", "repo", repo, "name", blobName)
+			}
+
+			r.mutex.Lock()
+			defer r.mutex.Unlock()
+			st.blobs[key] = status
+		}()
+	}
+}
+
+// hasBlob checks whether any repo serves the blob.
+func (r *repoClient) hasBlob(blobName enc.Name) bool {
+	for _, repo := range r.repos() {
+		if r.fetchRepo(blobName, repo, false) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetBlob stops tracking the BlobFetch status of a blob in the group.
+func (r *repoClient) forgetBlob(group enc.Name, blobName enc.Name) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, repo := range r.list {
+		delete(r.state(group, repo).blobs, blobName.String())
+	}
+}
+
+// fetchRepo asks a repo (and only the repo) for a Data packet.
+// Returns the name of the Data, or nil.
+func (r *repoClient) fetchRepo(name enc.Name, repo enc.Name, fresh bool) enc.Name {
+	ch := make(chan ndn.ExpressCallbackArgs, 1)
+	object.ExpressR(r.wksp.client.Engine(), ndn.ExpressRArgs{
+		Name: name,
+		Config: &ndn.InterestConfig{
+			MustBeFresh:    fresh,
+			CanBePrefix:    true,
+			ForwardingHint: []enc.Name{repo},
+			Lifetime:       optional.Some(2 * time.Second),
+		},
+		Callback: func(args ndn.ExpressCallbackArgs) { ch <- args },
+	})
+	if args := <-ch; args.Result == ndn.InterestResultData {
+		return args.Data.Name()
+	}
+	return nil
+}
+
+// status returns the state of each repo for the group.
+// This asks the repos for our latest snapshot, so it may take a while.
+func (r *repoClient) status(group enc.Name) []RepoStatus {
+	r.mutex.Lock()
+	list := make([]RepoStatus, 0, len(r.list))
+	for _, repo := range r.list {
+		st := r.state(group, repo)
+		status := RepoStatus{
+			Repo:         repo,
+			Join:         st.join,
+			JoinAttempts: st.attempts,
+			JoinedAt:     st.joinedAt,
+		}
+		if st.err != nil {
+			status.Error = st.err.Error()
+		}
+		for key, blob := range st.blobs {
+			switch blob {
+			case RepoCmdPending:
+				status.BlobsPending++
+			case RepoCmdAcked:
+				status.BlobsStored++
+			case RepoCmdFailed:
+				if name, err := enc.NameFromStr(key); err == nil {
+					status.FailedBlobs = append(status.FailedBlobs, name)
+				}
+			}
+		}
+		list = append(list, status)
+	}
+	dataPrefix := r.group(group).dataPrefix
+	r.mutex.Unlock()
+
+	if dataPrefix != nil && r.wksp.app.isOnline() {
+		snapPrefix := dataPrefix.Append(enc.NewKeywordComponent(repoSnapshotKeyword))
+		for i := range list {
+			list[i].Snapshot = r.fetchRepo(snapPrefix, list[i].Repo, true)
+		}
+	}
+	return list
+}
+
+// Repos returns the repos storing the workspace data.
+func (w *Workspace) Repos() []enc.Name {
+	return w.repoc.repos()
+}
+
+// RepoStatus returns the state of each repo for a project of the workspace.
+func (w *Workspace) RepoStatus(proj string) ([]RepoStatus, error) {
+	if w.app.lan {
+		return nil, fmt.Errorf("there is no repo in LAN-only mode")
+	}
+	if proj == "" {
+		return nil, fmt.Errorf("invalid project: %q", proj)
+	}
+	return w.repoc.status(w.group.Append(enc.NewGenericComponent(proj))), nil
+}
@@ -0,0 +1,256 @@
+package app
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+// How often the revocation list is fetched again
+const revocationRefresh = 10 * time.Minute
+
+// revokePrefix is the name prefix of the revocation list of a workspace.
+// /<wksp>/root/32=REVOKE
+func revokePrefix(wkspName enc.Name) enc.Name {
+	return wkspName.
+		Append(enc.NewGenericComponent("root")).
+		Append(enc.NewKeywordComponent("REVOKE"))
+}
+
+// revocationList is the latest known revocation list of a workspace.
+type revocationList struct {
+	mutex   sync.RWMutex
+	version uint64
+	members []enc.Name
+}
+
+// has returns true if the member is in the list.
+func (r *revocationList) has(member enc.Name) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, revoked := range r.members {
+		if revoked.Equal(member) {
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the members in the list.
+func (r *revocationList) list() []enc.Name {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return append([]enc.Name{}, r.members...)
+}
+
+// newer returns true if the version is newer than the current list.
+func (r *revocationList) newer(version uint64) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return version > r.version
+}
+
+// update replaces the list if the version is newer than the current one.
+func (r *revocationList) update(version uint64, members []enc.Name) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if version <= r.version {
+		return false
+	}
+	r.version, r.members = version, members
+	return true
+}
+
+// RevokeMembers adds members to the revocation list of the workspace.
+// Publications, awareness updates and keys from revoked members are rejected
+// by everyone who has the new list. This does not rotate the DSK, which should
+// be done next so that revoked members cannot read new publications.
+func (w *Workspace) RevokeMembers(members []enc.Name) error {
+	if !w.owner {
+		return fmt.Errorf("only owners can revoke members")
+	}
+	for _, member := range members {
+		if member.IsPrefix(w.group) || member.Equal(w.idName) {
+			return fmt.Errorf("cannot revoke %s", member)
+		}
+	}
+
+	// Start from the latest list, so that no revocation is lost
+	w.refreshRevocations()
+	list := &tlv.RevocationList{}
+	revoked := w.revoked.list()
+	for _, member := range members {
+		if !w.revoked.has(member) {
+			revoked = append(revoked, member)
+		}
+	}
+	for _, member := range revoked {
+		list.Members = append(list.Members, member.String())
+	}
+
+	// /<wksp>/root/32=REVOKE/v=<time>
+	name := revokePrefix(w.group).WithVersion(enc.VersionUnixMicro)
+	signer := w.client.SuggestSigner(name)
+	if signer == nil {
+		return fmt.Errorf("no valid key to sign revocation list")
+	}
+	data, err := spec.Spec{}.MakeData(name, &ndn.DataConfig{}, list.Encode(), signer)
+	if err != nil {
+		return err
+	}
+	wire := data.Wire.Join()
+	if err := w.client.Store().Put(name, wire); err != nil {
+		return err
+	}
+
+	w.revoked.update(name.At(-1).NumberVal(), revoked)
+	w.crypto.RemoveMembers(members)
+	log.Info(w, "Revoked members", "revoked", len(members), "total", len(revoked))
+
+	// Keep the revocation list in the repo
+	if root := w.root.Load(); root != nil {
+		if _, err := root.PubBlobFetch(nil, wire); err != nil {
+			log.Warn(w, "Failed to send revocation list to repo", "err", err)
+		}
+	}
+	return nil
+}
+
+// RevokedMembers returns the members in the latest known revocation list.
+func (w *Workspace) RevokedMembers() []enc.Name {
+	return w.revoked.list()
+}
+
+// isRevoked returns true if the member was revoked.
+func (w *Workspace) isRevoked(member enc.Name) bool {
+	return w.revoked.has(member)
+}
+
+// isRevokedKey returns true if the key is the member key or the co-owner key
+// of a revoked member in this workspace.
+func (w *Workspace) isRevokedKey(keyName enc.Name) bool {
+	isKeyOf := func(id enc.Name) bool {
+		return len(keyName) > len(id) && id.IsPrefix(keyName) &&
+			keyName.At(len(id)).Equal(enc.NewGenericComponent("KEY"))
+	}
+	for _, member := range w.revoked.list() {
+		if isKeyOf(w.group.Append(member...)) || isKeyOf(coOwnerPrefix(w.group, member)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks data of the workspace against the trust schema and the
+// revocation list. The trust schema does not know about revocations, so all
+// data of members is validated here instead of with the client directly.
+// Data signed by a revoked key and certificates of revoked members are rejected.
+func (w *Workspace) validate(data ndn.Data, sigCov enc.Wire) error {
+	if keyName := data.Signature().KeyName(); w.isRevokedKey(keyName) {
+		return fmt.Errorf("%s is signed by a revoked key: %s", data.Name(), keyName)
+	}
+	if w.isRevokedKey(data.Name()) {
+		return fmt.Errorf("%s is a key of a revoked member", data.Name())
+	}
+
+	valid := make(chan error, 1)
+	w.client.Validate(data, sigCov, func(ok bool, err error) {
+		if err == nil && !ok {
+			err = fmt.Errorf("%s is not trusted", data.Name())
+		}
+		valid <- err
+	})
+	return <-valid
+}
+
+// loadRevocations loads the revocation list from the local store.
+// Lists are only stored after they were validated.
+func (w *Workspace) loadRevocations() {
+	wire, _ := w.client.Store().Get(revokePrefix(w.group), true)
+	if wire == nil {
+		return
+	}
+	data, _, err := spec.Spec{}.ReadData(enc.NewBufferView(wire))
+	if err != nil {
+		return
+	}
+	if version, members, err := w.parseRevocations(data); err == nil {
+		w.revoked.update(version, members)
+	}
+}
+
+// refreshRevocations fetches the latest revocation list from the repo or the owners.
+func (w *Workspace) refreshRevocations() {
+	args := w.app.fetchLatest(revokePrefix(w.group), w.Repos(), 0, false)
+	if args.Result != ndn.InterestResultData {
+		return
+	}
+
+	version, members, err := w.parseRevocations(args.Data)
+	if err != nil {
+		log.Warn(w, "Invalid revocation list", "err", err)
+		return
+	}
+	if !w.revoked.newer(version) {
+		return // keep what we have, e.g. a stale list in the repo
+	}
+
+	// Signature must match #revocations <= #owner_cert | #coowner_cert
+	if err := w.validate(args.Data, args.SigCovered); err != nil {
+		log.Warn(w, "Revocation list is not signed by an owner", "err", err)
+		return
+	}
+
+	if w.revoked.update(version, members) {
+		log.Info(w, "Updated revocation list", "revoked", len(members))
+		w.crypto.RemoveMembers(members)
+		if err := w.client.Store().Put(args.Data.Name(), args.RawData.Join()); err != nil {
+			log.Warn(w, "Failed to store revocation list", "err", err)
+		}
+	}
+}
+
+// parseRevocations checks the name of a revocation list and parses it.
+// Returns the version and the revoked members.
+func (w *Workspace) parseRevocations(data ndn.Data) (uint64, []enc.Name, error) {
+	// Name must be /<wksp>/root/32=REVOKE/v=<time>
+	name := data.Name()
+	prefix := revokePrefix(w.group)
+	if len(name) != len(prefix)+1 || !prefix.IsPrefix(name) || !name.At(-1).IsVersion() {
+		return 0, nil, fmt.Errorf("invalid revocation list name: %s", name)
+	}
+
+	list, err := tlv.ParseRevocationList(enc.NewWireView(data.Content()), true)
+	if err != nil {
+		return 0, nil, fmt.Errorf("malformed revocation list: %w", err)
+	}
+	members := make([]enc.Name, 0, len(list.Members))
+	for _, member := range list.Members {
+		if name, err := enc.NameFromStr(member); err == nil {
+			members = append(members, name)
+		}
+	}
+	return name.At(-1).NumberVal(), members, nil
+}
+
+// watchRevocations periodically fetches the revocation list until stop is closed.
+func (w *Workspace) watchRevocations(stop chan struct{}) {
+	ticker := time.NewTicker(revocationRefresh)
+	defer ticker.Stop()
+
+	for {
+		w.refreshRevocations()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	"github.com/named-data/ndnd/std/security/trust_schema"
+)
+
+// Role is the permission level of a member in a workspace.
+type Role int
+
+const (
+	// RoleEditor can publish to all projects.
+	RoleEditor Role = iota
+	// RoleCommenter can read all projects, and only publish to the chat.
+	RoleCommenter
+	// RoleViewer can only read the workspace.
+	RoleViewer
+)
+
+// Document of the root project that commenters can publish to
+const commentDoc = "chat"
+
+// How long the role of another member is cached
+const memberRoleTtl = 10 * time.Minute
+
+// How long to wait before looking up a role again after a failure
+const memberRoleRetry = 20 * time.Second
+
+// Number of failed lookups after which a member is treated as a viewer
+const memberRoleAttempts = 3
+
+// memberRole is the cached role of another member of the workspace.
+// The role is unknown while it is looked up, or after a lookup failed
+// fewer than memberRoleAttempts times.
+type memberRole struct {
+	role     Role
+	known    bool
+	failures int
+	expiry   time.Time
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleEditor:
+		return "editor"
+	case RoleCommenter:
+		return "commenter"
+	case RoleViewer:
+		return "viewer"
+	}
+	return fmt.Sprintf("role-%d", int(r))
+}
+
+// ParseRole parses the name of a role.
+// An empty string is the default role (editor).
+func ParseRole(s string) (Role, error) {
+	switch s {
+	case "", "editor":
+		return RoleEditor, nil
+	case "commenter":
+		return RoleCommenter, nil
+	case "viewer":
+		return RoleViewer, nil
+	}
+	return RoleEditor, fmt.Errorf("unknown role: %s", s)
+}
+
+// inviteRules returns the CrossSchema rules of an invitation with a role.
+// Editors get the namespace of the invitee in the workspace. Other roles
+// only get the key of the invitee, and a rule naming the role:
+// /<wksp>/<invitee>/32=ROLE/<role>
+func inviteRules(wkspName enc.Name, invitee enc.Name, role Role) []*trust_schema.SimpleSchemaRule {
+	keyLocator := &spec.KeyLocator{
+		Name: invitee.Append(enc.NewGenericComponent("KEY")),
+	}
+	wkspIdName := wkspName.Append(invitee...)
+
+	if role == RoleEditor {
+		return []*trust_schema.SimpleSchemaRule{{
+			// Authorize invitee's identity key to sign data
+			NamePrefix: wkspIdName,
+			KeyLocator: keyLocator,
+		}}
+	}
+
+	return []*trust_schema.SimpleSchemaRule{{
+		// Authorize invitee's identity key to sign the workspace key only
+		NamePrefix: wkspIdName.Append(enc.NewGenericComponent("KEY")),
+		KeyLocator: keyLocator,
+	}, {
+		NamePrefix: wkspIdName.
+			Append(enc.NewKeywordComponent("ROLE")).
+			Append(enc.NewGenericComponent(role.String())),
+		KeyLocator: keyLocator,
+	}}
+}
+
+// inviteRole returns the role granted by an invitation.
+// Invitations without a role rule are for editors.
+func inviteRole(wkspName enc.Name, invitee enc.Name, invitation enc.Wire) (Role, error) {
+	data, _, err := spec.Spec{}.ReadData(enc.NewWireView(invitation))
+	if err != nil {
+		return RoleEditor, fmt.Errorf("malformed invitation: %w", err)
+	}
+	content, err := trust_schema.ParseCrossSchemaContent(enc.NewWireView(data.Content()), true)
+	if err != nil {
+		return RoleEditor, fmt.Errorf("malformed invitation content: %w", err)
+	}
+
+	rolePrefix := wkspName.Append(invitee...).Append(enc.NewKeywordComponent("ROLE"))
+	for _, rule := range content.SimpleSchemaRules {
+		if len(rule.NamePrefix) == len(rolePrefix)+1 && rolePrefix.IsPrefix(rule.NamePrefix) {
+			return ParseRole(rule.NamePrefix.At(-1).String())
+		}
+	}
+	return RoleEditor, nil
+}
+
+// Role is the role of the current user in the workspace.
+func (w *Workspace) Role() Role {
+	return w.role
+}
+
+// memberRole returns the role of a member of the workspace, if known.
+// Owners and co-owners are editors, other members have the role of their
+// invitation. Roles are looked up in the background, and onRole is called
+// when the lookup is done; ok is false until then. Members whose invitation
+// cannot be found after memberRoleAttempts lookups are viewers until the
+// next lookup, so that their publications are dropped instead of waiting.
+func (w *Workspace) memberRole(member enc.Name, onRole func()) (role Role, ok bool) {
+	if member.Equal(w.idName) {
+		return w.role, true
+	}
+	if member.IsPrefix(w.group) {
+		return RoleEditor, true
+	}
+
+	// Only one lookup at a time, and the old role is kept while looking up again
+	key, now := member.String(), time.Now()
+	lookup := memberRole{role: RoleViewer, expiry: now.Add(memberRoleRetry)}
+	if cached, loaded := w.roles.Load(key); loaded {
+		entry := cached.(memberRole)
+		if now.Before(entry.expiry) {
+			return entry.role, entry.known
+		}
+		lookup.role, lookup.known, lookup.failures = entry.role, entry.known, entry.failures
+		if !w.roles.CompareAndSwap(key, cached, lookup) {
+			return entry.role, entry.known
+		}
+	} else if _, loaded := w.roles.LoadOrStore(key, lookup); loaded {
+		return RoleViewer, false
+	}
+
+	go func() {
+		role, err := w.lookupRole(member)
+		if err != nil {
+			log.Warn(w, "Failed to look up role", "member", member, "err", err)
+			retry := time.Now().Add(memberRoleRetry)
+			switch {
+			case lookup.known:
+				// Keep the old role until the next lookup
+				w.roles.Store(key, memberRole{role: lookup.role, known: true, expiry: retry})
+			case lookup.failures+1 < memberRoleAttempts:
+				w.roles.Store(key, memberRole{role: RoleViewer, failures: lookup.failures + 1})
+				time.AfterFunc(memberRoleRetry, onRole)
+			default:
+				log.Warn(w, "No invitation found, treating member as viewer", "member", member)
+				w.roles.Store(key, memberRole{role: RoleViewer, known: true, expiry: retry})
+				onRole()
+			}
+			return
+		}
+		w.roles.Store(key, memberRole{role: role, known: true, expiry: time.Now().Add(memberRoleTtl)})
+		onRole()
+	}()
+	return lookup.role, lookup.known
+}
+
+// lookupRole finds the role of a member from the co-owner grant or the invitation.
+// This may block on fetching them.
+func (w *Workspace) lookupRole(member enc.Name) (Role, error) {
+	if w.isOwnerName(member) {
+		return RoleEditor, nil
+	}
+
+	// Name must be /<wksp>/root/32=INVITE/<member>/v=<time>
+	prefix := invitePrefix(w.group, member)
+	args := w.app.fetchLatest(prefix, w.app.workspaceRepos(w.group), 1, true)
+	if args.Result != ndn.InterestResultData {
+		return RoleViewer, fmt.Errorf("no invitation found: %s", args.Result)
+	}
+	if name := args.Data.Name(); len(name) != len(prefix)+1 || !name.At(-1).IsVersion() {
+		return RoleViewer, fmt.Errorf("invalid invitation name: %s", name)
+	}
+
+	// Signature must match #invite <= #owner_cert | #coowner_cert
+	if err := w.validate(args.Data, args.SigCovered); err != nil {
+		return RoleViewer, fmt.Errorf("invitation is not signed by an owner: %w", err)
+	}
+	return inviteRole(w.group, member, args.RawData)
+}
+
+// checkPublish returns an error if our role does not allow publishing
+// the document with the given uuid (empty for blobs) to the project.
+func (s *SvsAlo) checkPublish(uuid string) error {
+	return s.checkRole(s.wksp.role, uuid)
+}
+
+// checkRole returns an error if the role does not allow publishing
+// the document with the given uuid (empty for blobs) to the project.
+func (s *SvsAlo) checkRole(role Role, uuid string) error {
+	switch role {
+	case RoleEditor:
+		return nil
+	case RoleCommenter:
+		if s.isRoot() && uuid == commentDoc {
+			return nil
+		}
+		return fmt.Errorf("commenters can only publish to the chat of %s", s.wksp.group)
+	default:
+		return fmt.Errorf("%ss cannot publish to %s", role, s.wksp.group)
+	}
+}
@@ -0,0 +1,455 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+	"github.com/named-data/ndnd/std/ndn/fch"
+)
+
+// Number of routers requested from FCH
+const routerCandidates = 4
+
+// How long to wait for a router to come up when probing it
+const routerProbeTimeout = 3 * time.Second
+
+// How often the routers are probed again
+const routerProbeInterval = 10 * time.Minute
+
+// RouterInfo is a router that the app can connect to.
+type RouterInfo struct {
+	// URI is the endpoint of the router, e.g. wss://host/ws/
+	URI string
+	// RTT is the time to connect to the router at the last probe.
+	// Zero if the router was not probed.
+	RTT time.Duration
+	// Reachable is false if the router failed at the last probe or connection.
+	Reachable bool
+	// Current is true if the app is connected to this router.
+	Current bool
+}
+
+// routerSet is the list of routers, best first.
+type routerSet struct {
+	mutex   sync.Mutex
+	routers []RouterInfo
+}
+
+// update replaces the routers with probed ones, sorted by RTT.
+// Unreachable routers are last.
+func (s *routerSet) update(routers []RouterInfo) {
+	slices.SortStableFunc(routers, func(a, b RouterInfo) int {
+		if a.Reachable != b.Reachable {
+			if a.Reachable {
+				return -1
+			}
+			return 1
+		}
+		return int(a.RTT - b.RTT)
+	})
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.routers = routers
+}
+
+// list returns the routers, marking the current one.
+func (s *routerSet) list(current string) []RouterInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := slices.Clone(s.routers)
+	for i := range list {
+		list[i].Current = list[i].URI == current
+	}
+	return list
+}
+
+// get returns the router with the given URI, adding it if unknown.
+func (s *routerSet) get(uri string) RouterInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, r := range s.routers {
+		if r.URI == uri {
+			return r
+		}
+	}
+	r := RouterInfo{URI: uri, Reachable: true}
+	s.routers = append(s.routers, r)
+	return r
+}
+
+// uris returns the URIs of all routers.
+func (s *routerSet) uris() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	uris := make([]string, 0, len(s.routers))
+	for _, r := range s.routers {
+		uris = append(uris, r.URI)
+	}
+	return uris
+}
+
+// failed marks a router as unreachable and returns the next router to try.
+// Reachable routers are preferred, in order after the failed one.
+// Returns an empty string if there is no other router.
+func (s *routerSet) failed(uri string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	idx := slices.IndexFunc(s.routers, func(r RouterInfo) bool { return r.URI == uri })
+	if idx >= 0 {
+		s.routers[idx].Reachable = false
+	}
+
+	next := ""
+	for i := range len(s.routers) {
+		r := s.routers[(idx+1+i)%len(s.routers)]
+		if r.URI == uri {
+			continue
+		}
+		if r.Reachable {
+			return r.URI
+		}
+		if next == "" {
+			next = r.URI
+		}
+	}
+	return next
+}
+
+// ListRouters returns the known routers of the network, best first.
+func (a *App) ListRouters() []RouterInfo {
+	if a.rface == nil {
+		return nil
+	}
+	return a.routers.list(a.rface.Router())
+}
+
+// SetRouter connects to the given router instead of the current one.
+// The router does not need to be in the list, e.g. for debugging.
+// If it stays down, the app reconnects to the next router as usual.
+func (a *App) SetRouter(uri string) error {
+	if a.rface == nil {
+		return fmt.Errorf("not connected to a network")
+	}
+	if uri == a.rface.Router() {
+		return nil
+	}
+	a.routers.get(uri)
+	return a.switchRouter(uri)
+}
+
+// currentRouter returns the router we are connected to.
+func (a *App) currentRouter() RouterInfo {
+	info := a.routers.get(a.rface.Router())
+	info.Current = true
+	return info
+}
+
+// findRouters returns the routers of the network the app connects to.
+// If there is more than one, they are probed and sorted by RTT.
+func (a *App) findRouters() ([]RouterInfo, error) {
+	profile := a.defaultProfile()
+	uris := make([]string, 0, routerCandidates+len(profile.Routers)+1)
+	add := func(uri string) {
+		if uri != "" && !slices.Contains(uris, uri) {
+			uris = append(uris, uri)
+		}
+	}
+
+	if transport := a.dialer.Transport(); transport != "" {
+		// Routers from FCH first, if the network has FCH
+		if profile.FchNetwork != "" {
+			res, err := fch.Query(context.Background(), fch.Request{
+				Server:    profile.FchServer,
+				Transport: transport,
+				Count:     routerCandidates,
+				Network:   profile.FchNetwork,
+			})
+			if err != nil {
+				log.Warn(a, "FCH query failed, using known routers", "err", err)
+			} else {
+				for _, router := range res.Routers {
+					add(router.Connect)
+				}
+			}
+		}
+
+		for _, router := range profile.Routers {
+			if uri, err := url.Parse(router); err == nil && uri.Scheme == transport {
+				add(router)
+			}
+		}
+	}
+	add(a.dialer.DefaultRouter())
+
+	switch len(uris) {
+	case 0:
+		return nil, fmt.Errorf("no router for network %s", profile.Name)
+	case 1:
+		return []RouterInfo{{URI: uris[0], Reachable: true}}, nil
+	default:
+		return a.probeRouters(uris), nil
+	}
+}
+
+// probeRouters measures the RTT to the routers in parallel.
+func (a *App) probeRouters(uris []string) []RouterInfo {
+	routers := make([]RouterInfo, len(uris))
+	var wg sync.WaitGroup
+	for i, uri := range uris {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rtt, err := a.probeRouter(uri)
+			if err != nil {
+				log.Debug(a, "Router probe failed", "router", uri, "err", err)
+			}
+			routers[i] = RouterInfo{URI: uri, RTT: rtt, Reachable: err == nil}
+		}()
+	}
+	wg.Wait()
+	return routers
+}
+
+// probeRouter measures the time to connect to a router with a new face.
+func (a *App) probeRouter(uri string) (time.Duration, error) {
+	face, err := a.dialer.Dial(uri)
+	if err != nil {
+		return 0, err
+	}
+	face.OnPacket(func([]byte) {})
+	face.OnError(func(error) {})
+
+	up := make(chan struct{})
+	var once sync.Once
+	cancel := face.OnUp(func() { once.Do(func() { close(up) }) })
+	defer cancel()
+
+	start := time.Now()
+	if err := face.Open(); err != nil {
+		return 0, err
+	}
+	defer face.Close()
+
+	select {
+	case <-up:
+		return time.Since(start), nil
+	case <-time.After(routerProbeTimeout):
+		return 0, fmt.Errorf("timeout connecting to %s", uri)
+	}
+}
+
+// watchRouters probes the routers periodically, so that the order
+// is up to date when the app needs to move to another router.
+// Returns when the app is stopped.
+func (a *App) watchRouters() {
+	ticker := time.NewTicker(routerProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			a.routers.update(a.probeRouters(a.routers.uris()))
+		}
+	}
+}
+
+// switchRouter moves the face of the app to another router.
+func (a *App) switchRouter(uri string) error {
+	a.switchMutex.Lock()
+	defer a.switchMutex.Unlock()
+	return a.switchRouterLocked(uri)
+}
+
+func (a *App) switchRouterLocked(uri string) error {
+	face, err := a.dialer.Dial(uri)
+	if err != nil {
+		return err
+	}
+	log.Info(a, "Switching router", "from", a.rface.Router(), "to", uri)
+	return a.rface.switchTo(uri, face)
+}
+
+// routerFace is the face of the engine. It forwards to the face of the
+// current router, which can be replaced without restarting the engine.
+// Handlers registered on the routerFace only see the current face.
+type routerFace struct {
+	mutex  sync.Mutex
+	face   ndn.Face
+	router string
+	opened bool
+
+	onPkt   func(frame []byte)
+	onError func(err error)
+	onUp    map[int]func()
+	onDown  map[int]func()
+	nextId  int
+}
+
+func newRouterFace(router string, face ndn.Face) *routerFace {
+	f := &routerFace{
+		router: router,
+		onUp:   make(map[int]func()),
+		onDown: make(map[int]func()),
+	}
+	f.attach(face)
+	return f
+}
+
+func (f *routerFace) String() string {
+	return fmt.Sprintf("router-face (%s)", f.Router())
+}
+
+// Router returns the URI of the current router.
+func (f *routerFace) Router() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.router
+}
+
+func (f *routerFace) current() ndn.Face {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.face
+}
+
+func (f *routerFace) IsRunning() bool {
+	return f.current().IsRunning()
+}
+
+func (f *routerFace) IsLocal() bool {
+	return f.current().IsLocal()
+}
+
+func (f *routerFace) OnPacket(onPkt func(frame []byte)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.onPkt = onPkt
+}
+
+func (f *routerFace) OnError(onError func(err error)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.onError = onError
+}
+
+func (f *routerFace) Open() error {
+	f.mutex.Lock()
+	f.opened = true
+	face := f.face
+	f.mutex.Unlock()
+	return face.Open()
+}
+
+func (f *routerFace) Close() error {
+	f.mutex.Lock()
+	f.opened = false
+	face := f.face
+	f.mutex.Unlock()
+	return face.Close()
+}
+
+func (f *routerFace) Send(pkt enc.Wire) error {
+	return f.current().Send(pkt)
+}
+
+func (f *routerFace) OnUp(onUp func()) (cancel func()) {
+	return f.addHandler(f.onUp, onUp)
+}
+
+func (f *routerFace) OnDown(onDown func()) (cancel func()) {
+	return f.addHandler(f.onDown, onDown)
+}
+
+func (f *routerFace) addHandler(handlers map[int]func(), handler func()) (cancel func()) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	id := f.nextId
+	f.nextId++
+	handlers[id] = handler
+	return func() {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		delete(handlers, id)
+	}
+}
+
+// attach sets the handlers of a new face, which only fire
+// while the face is the current one.
+func (f *routerFace) attach(face ndn.Face) {
+	f.face = face
+	face.OnPacket(func(frame []byte) {
+		if onPkt := handlerOf(f, face, func() func(frame []byte) { return f.onPkt }); onPkt != nil {
+			onPkt(frame)
+		}
+	})
+	face.OnError(func(err error) {
+		if onError := handlerOf(f, face, func() func(err error) { return f.onError }); onError != nil {
+			onError(err)
+		}
+	})
+	face.OnUp(func() { f.fire(face, f.onUp) })
+	face.OnDown(func() { f.fire(face, f.onDown) })
+}
+
+// handlerOf returns the handler if the face is current, or nil.
+func handlerOf[T any](f *routerFace, face ndn.Face, get func() T) (handler T) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.face == face {
+		handler = get()
+	}
+	return
+}
+
+// fire calls the handlers if the face is current.
+func (f *routerFace) fire(face ndn.Face, handlers map[int]func()) {
+	f.mutex.Lock()
+	if f.face != face {
+		f.mutex.Unlock()
+		return
+	}
+	list := make([]func(), 0, len(handlers))
+	for _, handler := range handlers {
+		list = append(list, handler)
+	}
+	f.mutex.Unlock()
+
+	for _, handler := range list {
+		handler()
+	}
+}
+
+// switchTo replaces the current face with a face to another router.
+// The old face is closed, and the new face is opened if the routerFace is.
+func (f *routerFace) switchTo(router string, face ndn.Face) error {
+	f.mutex.Lock()
+	old, wasUp := f.face, f.face.IsRunning()
+	f.router = router
+	f.attach(face)
+	opened := f.opened
+	f.mutex.Unlock()
+
+	if err := old.Close(); err != nil {
+		log.Debug(f, "Failed to close old face", "err", err)
+	}
+
+	// The old face is not current anymore, so its down handlers did not fire
+	if wasUp {
+		f.fire(face, f.onDown)
+	}
+
+	if opened {
+		return face.Open()
+	}
+	return nil
+}
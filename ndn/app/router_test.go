@@ -0,0 +1,66 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRouterSetUpdate(t *testing.T) {
+	s := &routerSet{}
+	s.update([]RouterInfo{
+		{URI: "wss://down/", Reachable: false},
+		{URI: "wss://slow/", RTT: 300 * time.Millisecond, Reachable: true},
+		{URI: "wss://fast/", RTT: 20 * time.Millisecond, Reachable: true},
+	})
+
+	want := []string{"wss://fast/", "wss://slow/", "wss://down/"}
+	if got := s.uris(); !slices.Equal(got, want) {
+		t.Fatalf("uris() = %v, want %v", got, want)
+	}
+
+	list := s.list("wss://slow/")
+	for _, r := range list {
+		if r.Current != (r.URI == "wss://slow/") {
+			t.Errorf("list() marks %s as current = %v", r.URI, r.Current)
+		}
+	}
+}
+
+func TestRouterSetFailed(t *testing.T) {
+	routers := func(reachable ...bool) []RouterInfo {
+		list := make([]RouterInfo, 0, len(reachable))
+		for i, ok := range reachable {
+			list = append(list, RouterInfo{URI: string(rune('a' + i)), Reachable: ok})
+		}
+		return list
+	}
+
+	tests := []struct {
+		name    string
+		routers []RouterInfo
+		failed  string
+		want    string
+	}{
+		{"next reachable", routers(true, true, true), "a", "b"},
+		{"wraps around", routers(true, true, true), "c", "a"},
+		{"skips unreachable", routers(true, false, true), "a", "c"},
+		{"unreachable if nothing else", routers(true, false, false), "a", "b"},
+		{"only router", routers(true), "a", ""},
+		{"unknown router", routers(false, true), "x", "b"},
+		{"no routers", nil, "a", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &routerSet{routers: tt.routers}
+			if got := s.failed(tt.failed); got != tt.want {
+				t.Errorf("failed(%q) = %q, want %q", tt.failed, got, tt.want)
+			}
+			for _, r := range s.routers {
+				if r.URI == tt.failed && r.Reachable {
+					t.Errorf("failed(%q) did not mark the router unreachable", tt.failed)
+				}
+			}
+		})
+	}
+}
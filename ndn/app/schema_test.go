@@ -0,0 +1,63 @@
+package app
+
+import (
+	"testing"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/security/trust_schema"
+)
+
+// The network is a pattern in the trust schema, so one schema serves every
+// network profile. The pattern is bound once per chain, so a packet can only
+// be signed by keys of its own network, up to the anchor of that network.
+func TestSchemaNetwork(t *testing.T) {
+	schema, err := trust_schema.NewLvsSchema(SchemaBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		pkt  string
+		key  string
+		want bool
+	}{
+		// Chains within one network
+		{"identity by root", "/lab/alice/KEY/k/r/v=1", "/lab/KEY/r/self/v=1", true},
+		{"site by root", "/lab/ucla/cs/KEY/k/r/v=1", "/lab/KEY/r/self/v=1", true},
+		{"identity by site", "/lab/alice/KEY/k/r/v=1", "/lab/ucla/cs/KEY/k/r/v=1", true},
+		{"owner by identity", "/lab/alice/ws/lab/alice/KEY/k/self/v=1", "/lab/alice/KEY/k/r/v=1", true},
+		{"member by owner", "/lab/alice/ws/lab/bob/KEY/k/NDNCERT/v=1", "/lab/alice/ws/lab/alice/KEY/k/self/v=1", true},
+		{"data by member", "/lab/alice/ws/proj/lab/bob/doc/v=1", "/lab/alice/ws/lab/bob/KEY/k/NDNCERT/v=1", true},
+		{"testbed data by member", "/ndn/alice/ws/proj/ndn/bob/doc/v=1", "/ndn/alice/ws/ndn/bob/KEY/k/NDNCERT/v=1", true},
+		{"invitation by owner", "/lab/alice/ws/root/32=INVITE/lab/bob/v=1", "/lab/alice/ws/lab/alice/KEY/k/self/v=1", true},
+
+		// Every step of a chain must stay in the network
+		{"identity by other root", "/lab/alice/KEY/k/r/v=1", "/ndn/KEY/r/self/v=1", false},
+		{"site by other root", "/lab/ucla/cs/KEY/k/r/v=1", "/ndn/KEY/r/self/v=1", false},
+		{"owner by other identity", "/lab/alice/ws/lab/alice/KEY/k/self/v=1", "/ndn/alice/KEY/k/r/v=1", false},
+		{"member of other network", "/lab/alice/ws/ndn/bob/KEY/k/NDNCERT/v=1", "/lab/alice/ws/lab/alice/KEY/k/self/v=1", false},
+		{"member by other owner", "/lab/alice/ws/lab/bob/KEY/k/NDNCERT/v=1", "/ndn/alice/ws/ndn/alice/KEY/k/self/v=1", false},
+		{"data of other network", "/lab/alice/ws/proj/ndn/bob/doc/v=1", "/lab/alice/ws/lab/bob/KEY/k/NDNCERT/v=1", false},
+		{"data by other member", "/lab/alice/ws/proj/lab/bob/doc/v=1", "/ndn/alice/ws/ndn/bob/KEY/k/NDNCERT/v=1", false},
+
+		// Members cannot sign for others
+		{"member by member", "/lab/alice/ws/lab/eve/KEY/k/NDNCERT/v=1", "/lab/alice/ws/lab/bob/KEY/k/NDNCERT/v=1", false},
+		{"invitation by member", "/lab/alice/ws/root/32=INVITE/lab/bob/v=1", "/lab/alice/ws/lab/bob/KEY/k/NDNCERT/v=1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkt, err := enc.NameFromStr(tt.pkt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := enc.NameFromStr(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schema.Check(pkt, key); got != tt.want {
+				t.Errorf("Check(%s, %s) = %v, want %v", tt.pkt, tt.key, got, tt.want)
+			}
+		})
+	}
+}
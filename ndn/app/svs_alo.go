@@ -0,0 +1,681 @@
+package app
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	math_rand "math/rand/v2"
+	"sync"
+	"time"
+
+	spec_repo "github.com/named-data/ndnd/repo/tlv"
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/named-data/ndnd/std/ndn"
+	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
+	"github.com/named-data/ndnd/std/ndn/svs_ps"
+	ndn_sync "github.com/named-data/ndnd/std/sync"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+// Bounds of the backoff between attempts to publish queued publications
+const flushRetryMin = time.Second
+const flushRetryMax = time.Minute
+
+// SvsAlo is an SVS ALO instance of a project in a workspace.
+type SvsAlo struct {
+	wksp   *Workspace
+	client ndn.Client
+	alo    *ndn_sync.SvsALO
+	// Name of the project, empty if the group is not a project
+	proj string
+	// Member list of the project when the instance was created
+	acl *projectAcl
+
+	// List of SVS routes to announce
+	routes []enc.Name
+	// Callback to persist the SVS state
+	persistState func(enc.Wire)
+	// Publications made while offline
+	outbox *outbox
+	// Backoff and timer of the next attempt to publish the outbox,
+	// protected by the outbox mutex
+	flushRetry time.Duration
+	flushTimer *time.Timer
+
+	// Publications waiting for the key of their epoch.
+	// The state is held back until these are delivered.
+	mutex     sync.Mutex
+	pending   []ndn_sync.SvsPub
+	heldState enc.Wire
+	// Publications waiting for the role of their publisher.
+	// The lookup always ends in a decision, so the state is not held back.
+	roleWaiting []ndn_sync.SvsPub
+	// Cancels the epoch key callback of the subscription
+	cancelOnKey func()
+}
+
+// SvsAloSubscriber receives publications from an SvsAlo instance.
+type SvsAloSubscriber struct {
+	// OnYjsDelta is called with a batch of Yjs updates.
+	OnYjsDelta func(deltas []*tlv.YjsDelta)
+	// OnChat is called with a batch of chat messages.
+	OnChat func(msgs []*tlv.ChatMessage)
+}
+
+func newSvsAlo(
+	w *Workspace,
+	alo *ndn_sync.SvsALO,
+	proj string,
+	acl *projectAcl,
+	persistState func(enc.Wire),
+) *SvsAlo {
+	if persistState == nil {
+		persistState = func(enc.Wire) {}
+	}
+	return &SvsAlo{
+		wksp:   w,
+		client: w.client,
+		alo:    alo,
+		proj:   proj,
+		acl:    acl,
+		routes: []enc.Name{
+			alo.SyncPrefix(),
+			alo.DataPrefix(),
+		},
+		persistState: persistState,
+		outbox:       newOutbox(w.app.store, alo.GroupPrefix()),
+	}
+}
+
+func (s *SvsAlo) String() string {
+	return "svs-alo"
+}
+
+// SyncPrefix is the sync prefix of the instance.
+func (s *SvsAlo) SyncPrefix() enc.Name {
+	return s.alo.SyncPrefix()
+}
+
+// DataPrefix is the data prefix of the instance.
+func (s *SvsAlo) DataPrefix() enc.Name {
+	return s.alo.DataPrefix()
+}
+
+// Start announces the SVS prefixes and starts the instance.
+func (s *SvsAlo) Start() error {
+	s.announce()
+
+	// Notify repo to start, and publish what was queued before the last restart
+	s.wksp.NotifyRepo(s.alo.GroupPrefix(), s.alo.DataPrefix())
+	s.wksp.app.ExecWithConnectivity(s.flush)
+
+	if err := s.alo.Start(); err != nil {
+		return err
+	}
+	s.wksp.alos.Store(s, struct{}{})
+
+	// Key requests are made in the root project
+	if s.isRoot() {
+		s.wksp.root.Store(s)
+
+		// Keep the workspace metadata in the repo
+		if s.wksp.owner {
+			go s.wksp.pushMeta(s)
+		}
+	}
+
+	// Get the key of a private project from the other members
+	if s.acl.private() && !s.wksp.crypto.HasProjectKey(s.proj) {
+		s.wksp.fetchProjectKey(s.proj)
+	}
+	return nil
+}
+
+// Stop stops the instance and withdraws the SVS prefixes.
+func (s *SvsAlo) Stop() error {
+	if err := s.alo.Stop(); err != nil {
+		return err
+	}
+
+	s.wksp.alos.Delete(s)
+	s.outbox.mutex.Lock()
+	if s.flushTimer != nil {
+		s.flushTimer.Stop()
+	}
+	s.outbox.mutex.Unlock()
+	s.wksp.repoc.leave(s.alo.GroupPrefix())
+	s.wksp.root.CompareAndSwap(s, nil)
+	if s.cancelOnKey != nil {
+		s.cancelOnKey()
+	}
+
+	for _, route := range s.routes {
+		s.client.WithdrawPrefix(route, nil)
+	}
+	return nil
+}
+
+// SetOnError sets the error callback of the instance.
+func (s *SvsAlo) SetOnError(callback func(error)) {
+	s.alo.SetOnError(callback)
+}
+
+// Names returns the list of names in the group.
+func (s *SvsAlo) Names() []enc.Name {
+	return s.alo.SVS().GetNames()
+}
+
+// isRoot returns true if this is the root project of the workspace.
+func (s *SvsAlo) isRoot() bool {
+	return s.alo.GroupPrefix().Equal(s.wksp.group.Append(enc.NewGenericComponent("root")))
+}
+
+// announce announces the SVS prefixes to the network.
+func (s *SvsAlo) announce() {
+	for _, route := range s.routes {
+		s.client.AnnouncePrefix(ndn.Announcement{
+			Name:    route,
+			Expose:  true,
+			OnError: nil, // TODO
+		})
+		log.Info(nil, "Announcing prefix", "name", "prefix", route)
+	}
+}
+
+// replay is called when the app is connected again after an outage.
+// The prefixes are announced and the repo is notified again, since the
+// router may have lost both, and the queued publications are published.
+// Publishing also sends a sync Interest, so the other members learn our
+// state right away instead of at the next periodic sync.
+func (s *SvsAlo) replay() {
+	s.announce()
+	s.wksp.NotifyRepo(s.alo.GroupPrefix(), s.alo.DataPrefix())
+	s.flush()
+}
+
+// publish publishes the content and persists the new state.
+// While the app is not connected, the content is queued and published
+// after reconnecting, in order. Queued publications return no name.
+func (s *SvsAlo) publish(content enc.Wire) (enc.Name, error) {
+	s.outbox.mutex.Lock()
+	if !s.outbox.empty() || !s.wksp.app.isOnline() {
+		defer s.outbox.mutex.Unlock()
+		log.Info(s, "Offline, queueing publication", "group", s.alo.GroupPrefix())
+		return nil, s.outbox.push(content)
+	}
+	s.outbox.mutex.Unlock()
+
+	return s.publishNow(content)
+}
+
+// publishNow publishes the content regardless of the connection.
+func (s *SvsAlo) publishNow(content enc.Wire) (enc.Name, error) {
+	name, state, err := s.alo.Publish(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Persist state
+	s.setState(state)
+
+	return name, nil
+}
+
+// flush publishes the queued publications, if connected.
+// If publishing fails while connected, flush is tried again with backoff;
+// otherwise replay flushes again after reconnecting.
+func (s *SvsAlo) flush() {
+	s.outbox.mutex.Lock()
+	defer s.outbox.mutex.Unlock()
+
+	if s.outbox.empty() {
+		return
+	}
+	err := s.outbox.drain(func(content enc.Wire) error {
+		if !s.wksp.app.isOnline() {
+			return fmt.Errorf("offline")
+		}
+		_, err := s.publishNow(content)
+		return err
+	})
+	if err == nil {
+		s.flushRetry = 0
+		return
+	}
+	log.Warn(s, "Failed to publish queued publications", "group", s.alo.GroupPrefix(), "err", err)
+
+	if !s.wksp.app.isOnline() {
+		return
+	}
+	s.flushRetry = min(max(2*s.flushRetry, flushRetryMin), flushRetryMax)
+	if s.flushTimer == nil {
+		s.flushTimer = time.AfterFunc(s.flushRetry, s.retryFlush)
+	} else {
+		s.flushTimer.Reset(s.flushRetry)
+	}
+}
+
+// retryFlush publishes the queued publications again, unless stopped.
+func (s *SvsAlo) retryFlush() {
+	if _, running := s.wksp.alos.Load(s); running {
+		s.flush()
+	}
+}
+
+// setState persists the state, unless publications are waiting for keys.
+// Otherwise these would be lost if the application restarts.
+func (s *SvsAlo) setState(state enc.Wire) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.pending) > 0 {
+		s.heldState = state
+		return
+	}
+	s.persistState(state)
+}
+
+// PubYjsDelta publishes an encrypted Yjs update for the document uuid.
+// Fails if our role in the workspace does not allow editing the document.
+func (s *SvsAlo) PubYjsDelta(uuid string, binary []byte) (enc.Name, error) {
+	if err := s.checkPublish(uuid); err != nil {
+		return nil, err
+	}
+
+	pub := &tlv.Message{
+		YjsDelta: &tlv.YjsDelta{
+			UUID:   uuid,
+			Binary: binary,
+		},
+	}
+
+	// Encrypt the publication
+	epub, err := s.wksp.crypto.encryptPub(pub, s.proj)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publish(epub.Encode())
+}
+
+// PubBlobFetch publishes a blob fetch command for the repo.
+// If encapsulate is not nil, the Data packet is sent inline instead of the name.
+// Only editors can publish blobs.
+func (s *SvsAlo) PubBlobFetch(blobName enc.Name, encapsulate []byte) (enc.Name, error) {
+	if err := s.checkPublish(""); err != nil {
+		return nil, err
+	}
+
+	// This message is special, in the sense that it is purely intended for repo.
+	// So subscribers will never see this message.
+	cmd := spec_repo.RepoCmd{
+		BlobFetch: &spec_repo.BlobFetch{},
+	}
+	if encapsulate != nil {
+		// For now this only supports a single encapsulated Data
+		cmd.BlobFetch.Data = [][]byte{encapsulate}
+		data, _, err := spec.Spec{}.ReadData(enc.NewBufferView(encapsulate))
+		if err == nil {
+			blobName = data.Name()
+		}
+	} else { // pointer only
+		cmd.BlobFetch.Name = &spec.NameContainer{Name: blobName}
+	}
+
+	name, err := s.publish(cmd.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	if blobName != nil {
+		s.indexBlob(blobName)
+	}
+	// Check that the repo gets the blob
+	if blobName != nil && !s.wksp.app.lan {
+		s.wksp.repoc.blobFetched(s.alo.GroupPrefix(), blobName)
+	}
+	return name, nil
+}
+
+// PubDskRequest publishes a request for the DSK of the current epoch.
+// Returns the X25519 private key used for the request.
+func (s *SvsAlo) PubDskRequest() ([]byte, error) {
+	return s.pubDskRequest(&tlv.DSKRequest{})
+}
+
+// pubDskRequest publishes a key request with a new X25519 key.
+// The request may name an epoch or a private project.
+func (s *SvsAlo) pubDskRequest(req *tlv.DSKRequest) ([]byte, error) {
+	sk, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	req.X25519Pub = sk.PublicKey().Bytes()
+	req.Expiry = uint64(time.Now().Add(24 * time.Hour).Unix())
+
+	pub := &tlv.Message{DSKRequest: req}
+	if _, err := s.publish(pub.Encode()); err != nil {
+		return nil, err
+	}
+
+	return sk.Bytes(), nil
+}
+
+// PubDskAck publishes an acknowledgement for the DSK response.
+func (s *SvsAlo) PubDskAck(priv []byte) error {
+	sk, err := ecdh.X25519().NewPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	pub := &tlv.Message{
+		DSKACK: &tlv.DSKACK{
+			X25519Peer: sk.PublicKey().Bytes(),
+		},
+	}
+	_, err = s.publish(pub.Encode())
+	return err
+}
+
+// PubDskRotate mints the DSK of a new epoch and announces it in the root project.
+// Members fetch the new key from the owners, except for the removed members.
+// Returns the new epoch and key, which must be persisted by the application.
+func (s *SvsAlo) PubDskRotate(remove []enc.Name) (uint64, []byte, error) {
+	if !s.wksp.owner {
+		return 0, nil, fmt.Errorf("only owners can rotate the DSK")
+	}
+	if !s.isRoot() {
+		return 0, nil, fmt.Errorf("DSK must be rotated in the root project")
+	}
+
+	dsk := make([]byte, 32)
+	if _, err := rand.Read(dsk); err != nil {
+		return 0, nil, err
+	}
+
+	// Use the new key immediately, so the announcement can be answered.
+	// The epoch is a timestamp, so that co-owners rotating at the same time
+	// do not pick the same epoch for different keys.
+	crypto := s.wksp.crypto
+	epoch := max(crypto.Epoch()+1, uint64(time.Now().UnixMicro()))
+	crypto.RemoveMembers(remove)
+	if err := crypto.AddEpochKey(epoch, dsk); err != nil {
+		return 0, nil, err
+	}
+
+	// Co-owners and our other devices learn who was removed from the announcement
+	removed := make([]string, 0, len(remove))
+	for _, name := range remove {
+		removed = append(removed, name.String())
+	}
+	pub := &tlv.Message{
+		DSKRotate: &tlv.DSKRotate{
+			Epoch:   epoch,
+			Removed: removed,
+		},
+	}
+	if _, err := s.publish(pub.Encode()); err != nil {
+		return 0, nil, err
+	}
+
+	log.Info(s, "Rotated DSK", "epoch", epoch, "removed", len(remove))
+	return epoch, dsk, nil
+}
+
+// Subscribe subscribes to all publications in the group.
+func (s *SvsAlo) Subscribe(sub SvsAloSubscriber) {
+	crypto := s.wksp.crypto
+
+	// Delivers waiting publications again, e.g. once a key or role is known
+	var deliver func(pubs []ndn_sync.SvsPub, state enc.Wire)
+	retry := func() {
+		if _, running := s.wksp.alos.Load(s); running {
+			deliver(nil, nil)
+		}
+	}
+
+	// Send a list of publications to the subscriber
+	// Returns the publications that need a key or role we do not have yet
+	sendPub := func(pubs []ndn_sync.SvsPub) (waiting []ndn_sync.SvsPub, roleWaiting []ndn_sync.SvsPub) {
+		yjsDeltas := make([]*tlv.YjsDelta, 0)
+		chats := make([]*tlv.ChatMessage, 0)
+
+		for _, pub := range pubs {
+			pmsg, err := tlv.ParseMessage(enc.NewWireView(pub.Content), true)
+			if err != nil {
+				log.Error(nil, "Failed to parse publication", "err", err)
+				continue
+			}
+
+			// Revoked members are ignored, including their old publications
+			if s.wksp.isRevoked(pub.Publisher) {
+				log.Warn(nil, "Ignoring publication from revoked member", "publisher", pub.Publisher)
+				continue
+			}
+
+			// Only members write to private projects, with the project key
+			// (or with the DSK before the project was made private)
+			if s.acl.private() && !s.acl.allows(pub.Publisher) &&
+				(pmsg.AeadBlock == nil || !pmsg.AeadBlock.Project) {
+				log.Warn(nil, "Ignoring publication from non-member", "publisher", pub.Publisher, "project", s.proj)
+				continue
+			}
+
+			dmsg, err := crypto.decryptPub(pmsg, s.proj)
+			if errors.Is(err, errUnknownEpoch) {
+				waiting = append(waiting, pub)
+				s.wksp.fetchEpochKey(pmsg.AeadBlock.Epoch.GetOr(0))
+				continue
+			} else if errors.Is(err, errNoProjectKey) {
+				waiting = append(waiting, pub)
+				s.wksp.fetchProjectKey(s.proj)
+				continue
+			} else if err != nil {
+				log.Error(nil, "Failed to decrypt publication", "err", err)
+				continue
+			}
+			pmsg = dmsg
+
+			// All possible message type conversions listed here
+			switch {
+			case pmsg.YjsDelta != nil:
+				// Same rules as checkPublish, with the role of the publisher
+				role, ok := s.wksp.memberRole(pub.Publisher, retry)
+				if !ok {
+					roleWaiting = append(roleWaiting, pub)
+					continue
+				}
+				if err := s.checkRole(role, pmsg.YjsDelta.UUID); err != nil {
+					log.Warn(nil, "Ignoring update", "publisher", pub.Publisher, "role", role, "err", err)
+					continue
+				}
+				yjsDeltas = append(yjsDeltas, pmsg.YjsDelta)
+
+			case pmsg.ChatMessage != nil:
+				// Members can only chat as themselves
+				if pmsg.ChatMessage.Author != pub.Publisher.String() {
+					log.Warn(nil, "Ignoring chat message with wrong author", "publisher", pub.Publisher)
+					continue
+				}
+
+				// Same rules as PubChat, with the role of the publisher
+				role, ok := s.wksp.memberRole(pub.Publisher, retry)
+				if !ok {
+					roleWaiting = append(roleWaiting, pub)
+					continue
+				}
+				if err := s.checkRole(role, commentDoc); err != nil {
+					log.Warn(nil, "Ignoring chat message", "publisher", pub.Publisher, "role", role, "err", err)
+					continue
+				}
+				chats = append(chats, pmsg.ChatMessage)
+
+			case pmsg.DSKRequest != nil:
+				if pmsg.DSKRequest.Expiry < uint64(time.Now().Unix()) {
+					continue
+				}
+
+				// Only owners learn who was removed, so after a rotation
+				// the keys are only handed out by the owners.
+				// Keys of private projects are handed out by their members.
+				// Revoked members never get a key.
+				req, requester := pmsg.DSKRequest, pub.Publisher
+				epoch := req.Epoch.GetOr(crypto.Epoch())
+				proj, isProj := req.Project.Get()
+				if crypto.IsRemoved(requester) || s.wksp.isRevoked(requester) ||
+					(isProj && !crypto.HasProjectKey(proj)) ||
+					(!isProj && epoch != 0 && !s.wksp.owner) {
+					continue
+				}
+
+				if req.X25519Pub == nil {
+					log.Warn(nil, "DSK request missing X25519 public key")
+					continue
+				}
+
+				// Randomness for some crude suppression
+				suppress := time.Duration(1+math_rand.IntN(3)) * time.Second
+
+				pubHex := hex.EncodeToString(req.X25519Pub)
+				crypto.addDskRequest(pubHex, suppress, func() {
+					// Only members of the workspace get the key
+					if err := s.wksp.verifyUserCert(requester); err != nil {
+						log.Warn(nil, "Refusing DSK request", "requester", requester, "err", err)
+						return
+					}
+					if isProj && !s.wksp.isProjectMember(proj, requester) {
+						log.Warn(nil, "Refusing project key request", "requester", requester, "project", proj)
+						return
+					}
+
+					group := s.alo.GroupPrefix()
+					dskRes := crypto.processDskRequest(s.client, group, requester, req, epoch)
+					if dskRes == nil {
+						return
+					}
+					if _, err := s.publish(dskRes); err != nil {
+						log.Error(nil, "Failed to publish DSK response", "err", err)
+					}
+				})
+
+			case pmsg.DSKACK != nil:
+				if pmsg.DSKACK.X25519Peer == nil {
+					log.Warn(nil, "DSK ACK missing X25519 public key")
+					continue
+				}
+
+				// Remove the request that matches this ACK
+				peerHex := hex.EncodeToString(pmsg.DSKACK.X25519Peer)
+				crypto.cancelDskRequest(peerHex)
+
+			case pmsg.DSKRotate != nil:
+				// Checking co-owners needs a fetch, so do not block delivery
+				publisher, rotate := pub.Publisher, pmsg.DSKRotate
+				go func() {
+					if !s.wksp.isOwnerName(publisher) {
+						log.Warn(nil, "Ignoring DSK rotation from non-owner", "publisher", publisher)
+						return
+					}
+
+					// Stop answering key requests of the removed members
+					removed := make([]enc.Name, 0, len(rotate.Removed))
+					for _, member := range rotate.Removed {
+						if name, err := enc.NameFromStr(member); err == nil {
+							removed = append(removed, name)
+						}
+					}
+					crypto.RemoveMembers(removed)
+
+					// Get the new key from the owners
+					s.wksp.fetchEpochKey(rotate.Epoch)
+				}()
+
+			default:
+				// This will be logged even for BlobFetch commands, which is fine
+				// (can be fixed but avoid the extra parse that is unused)
+				// log.Warn(a, "Ignoring unknown message", "publisher", pub.Publisher)
+			}
+		}
+
+		if len(yjsDeltas) > 0 && sub.OnYjsDelta != nil {
+			sub.OnYjsDelta(yjsDeltas)
+		}
+		if len(chats) > 0 && sub.OnChat != nil {
+			sub.OnChat(chats)
+		}
+		return waiting, roleWaiting
+	}
+
+	// Deliver new publications after the waiting ones, and persist
+	// the state once nothing is waiting for a key anymore.
+	deliver = func(pubs []ndn_sync.SvsPub, state enc.Wire) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		pubs = append(append(s.pending, s.roleWaiting...), pubs...)
+		s.pending, s.roleWaiting = sendPub(pubs)
+		if state != nil {
+			s.heldState = state
+		}
+		if len(s.pending) == 0 && s.heldState != nil {
+			s.persistState(s.heldState)
+			s.heldState = nil
+		}
+	}
+
+	// Retry waiting publications when we get a new key
+	s.cancelOnKey = crypto.onEpochKey(func(uint64) {
+		deliver(nil, nil)
+	})
+
+	// Subscribe to the SVS instance
+	s.alo.SubscribePublisher(enc.Name{}, func(pub ndn_sync.SvsPub) {
+		if !pub.IsSnapshot {
+			deliver([]ndn_sync.SvsPub{pub}, pub.State)
+		} else {
+			snapshot, err := svs_ps.ParseHistorySnap(enc.NewWireView(pub.Content), true)
+			if err != nil {
+				panic(err) // we encode this, so this never happens
+			}
+
+			pubs := make([]ndn_sync.SvsPub, 0, len(snapshot.Entries))
+			for _, entry := range snapshot.Entries {
+				pubs = append(pubs, ndn_sync.SvsPub{
+					Publisher: pub.Publisher,
+					Content:   entry.Content,
+					BootTime:  pub.BootTime,
+					SeqNum:    entry.SeqNo,
+				})
+			}
+			deliver(pubs, pub.State)
+		}
+	})
+}
+
+// Awareness creates a new Awareness instance for the document uuid.
+func (s *SvsAlo) Awareness(uuid string) *Awareness {
+	// One awareness instance per document
+	suffix := enc.Name{
+		enc.NewKeywordComponent("aware"),
+		enc.NewGenericComponent(uuid),
+	}
+
+	return &Awareness{
+		Group:    s.alo.SyncPrefix().Append(suffix...),
+		Name:     s.alo.DataPrefix().Append(suffix...),
+		Client:   s.client,
+		Validate: s.wksp.validate,
+	}
+}
+
+// NotifyRepo asks the repo to join the SVS group, retrying until the repo
+// acknowledges. The progress is shown by RepoStatus.
+func (w *Workspace) NotifyRepo(group enc.Name, dataPrefix enc.Name) {
+	// There is no repo in LAN-only mode
+	if w.app.lan {
+		return
+	}
+	w.repoc.join(group, dataPrefix)
+}
@@ -1,19 +1,14 @@
-//go:build js && wasm
-
 package app
 
 import (
-	"context"
 	_ "embed"
 	"fmt"
 	"time"
 
 	enc "github.com/named-data/ndnd/std/encoding"
 	"github.com/named-data/ndnd/std/engine"
-	"github.com/named-data/ndnd/std/engine/face"
 	"github.com/named-data/ndnd/std/log"
 	"github.com/named-data/ndnd/std/ndn"
-	"github.com/named-data/ndnd/std/ndn/fch"
 	spec "github.com/named-data/ndnd/std/ndn/spec_2022"
 	"github.com/named-data/ndnd/std/object"
 	"github.com/named-data/ndnd/std/security"
@@ -24,19 +19,25 @@ import (
 //go:embed testbed.root.cert
 var testbedRootCert []byte
 
-// TODO: better way to configure/change this as needed
-var testbedRootName, _ = enc.NameFromStr("/ndn/KEY/%27%C4%B2%2A%9F%7B%81%27/ndn/v=1651246789556")
-var testbedPrefix = enc.Name{enc.NewGenericComponent("ndn")}
-
-// GetTestbedKey returns the testbed key, or nil if not found.
-// Returns the latest valid certificate from the keychain.
+// GetTestbedKey returns the identity key in the network the app connects to,
+// or nil if not found. Returns the latest valid certificate from the keychain.
 func (a *App) GetTestbedKey() (ndn.Signer, time.Time) {
+	return a.identityKey(a.defaultProfile())
+}
+
+// workspaceIdKey returns the identity key in the network of a workspace.
+func (a *App) workspaceIdKey(wkspName enc.Name) (ndn.Signer, time.Time) {
+	return a.identityKey(a.workspaceProfile(wkspName))
+}
+
+// identityKey returns the identity key in the network of the profile.
+func (a *App) identityKey(profile *NetworkProfile) (ndn.Signer, time.Time) {
 	// TODO: move most of this to NDNd
 
 	var bestSigner ndn.Signer
 	var bestExpiry time.Time
 	for _, id := range a.keychain.Identities() {
-		if !testbedPrefix.IsPrefix(id.Name()) {
+		if !profile.IdentityPrefix.IsPrefix(id.Name()) {
 			continue
 		}
 
@@ -47,7 +48,7 @@ func (a *App) GetTestbedKey() (ndn.Signer, time.Time) {
 					continue
 				}
 
-				// Check if the certificate is a testbed certificate
+				// Check if the certificate is issued by NDNCERT
 				if certName.At(-2).String() != "NDNCERT" {
 					continue
 				}
@@ -71,7 +72,7 @@ func (a *App) GetTestbedKey() (ndn.Signer, time.Time) {
 	}
 
 	if bestSigner != nil {
-		log.Info(nil, "Using testbed certificate", "expiry", bestExpiry)
+		log.Info(nil, "Using identity certificate", "network", profile.Name, "expiry", bestExpiry)
 	}
 
 	return bestSigner, bestExpiry
@@ -88,39 +89,54 @@ func (a *App) ConnectTestbed() error {
 	if a.face != nil {
 		return nil
 	}
+	if a.dialer == nil {
+		return fmt.Errorf("no face dialer available on this platform")
+	}
 
-	// set default router to suns
-	endpoint := "wss://suns.cs.ucla.edu/ws/"
-	// if FCH returns a router, connect to that instead
-	res, err := fch.Query(context.Background(), fch.Request{
-		"",
-		"wss",
-		1,
-		"ndn",
-	})
+	// pick the router with the lowest RTT
+	routers, err := a.findRouters()
 	if err != nil {
 		return err
 	}
-	if res.Routers != nil {
-		// TODO: give the option for which testbed node to connect to, for debugging
-		endpoint = res.Routers[0].Connect
+	a.routers.update(routers)
+	endpoint := a.routers.list("")[0].URI
+
+	face, err := a.dialer.Dial(endpoint)
+	if err != nil {
+		return err
 	}
 
-	face := face.NewWasmWsFace(endpoint, false)
-	face.OnUp(func() {
-		_ndnd_conn_change_js.Invoke(true, endpoint)
-	})
-	face.OnDown(func() {
-		_ndnd_conn_change_js.Invoke(false, endpoint)
-	})
+	// the engine keeps the same face when we move to another router
+	rface := newRouterFace(endpoint, face)
+	rface.OnUp(a.onFaceUp)
+	rface.OnDown(a.onFaceDown)
+
+	a.rface = rface
+	a.face = rface
+
+	// reconnect if the router does not come up
+	a.setConnState(ConnConnecting, connOfflineDelay, a.goOffline)
 
-	a.face = face
 	a.engine = engine.NewBasicEngine(a.face)
 	err = a.engine.Start()
 	if err != nil {
 		return err
 	}
 
+	// keep the RTTs fresh
+	if len(routers) > 1 {
+		go a.watchRouters()
+	}
+
+	// peers on the LAN validate with the identity certificates we have
+	if a.lan {
+		for _, p := range a.profiles {
+			if err := a.serveCached(p.IdentityPrefix); err != nil {
+				log.Warn(a, "Failed to serve cached certificates", "prefix", p.IdentityPrefix, "err", err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -1,6 +1,17 @@
 //go:generate gondn_tlv_gen
 package tlv
 
+import "github.com/named-data/ndnd/std/types/optional"
+
+// AEAD algorithms used in AeadBlock
+const (
+	// AeadAesGcm is AES-256-GCM with a deterministic IV.
+	// Only used by legacy publications; this is the default if absent.
+	AeadAesGcm uint64 = 0
+	// AeadXChaCha20Poly1305 is XChaCha20-Poly1305 with a random 192-bit nonce.
+	AeadXChaCha20Poly1305 uint64 = 1
+)
+
 type Message struct {
 	//+field:struct:AeadBlock
 	AeadBlock *AeadBlock `tlv:"0xC6"`
@@ -12,6 +23,10 @@ type Message struct {
 	DSKResponse *DSKResponse `tlv:"0xCC"`
 	//+field:struct:DSKACK
 	DSKACK *DSKACK `tlv:"0xCE"`
+	//+field:struct:DSKRotate
+	DSKRotate *DSKRotate `tlv:"0xD0"`
+	//+field:struct:ChatMessage
+	ChatMessage *ChatMessage `tlv:"0xD2"`
 }
 
 type AeadBlock struct {
@@ -19,6 +34,12 @@ type AeadBlock struct {
 	IV []byte `tlv:"0xC8"`
 	//+field:binary
 	Ciphertext []byte `tlv:"0xCA"`
+	//+field:natural:optional
+	Algorithm optional.Optional[uint64] `tlv:"0xCC"`
+	//+field:natural:optional
+	Epoch optional.Optional[uint64] `tlv:"0xCE"`
+	//+field:bool
+	Project bool `tlv:"0xD0"`
 }
 
 type YjsDelta struct {
@@ -33,6 +54,10 @@ type DSKRequest struct {
 	X25519Pub []byte `tlv:"0x578"`
 	//+field:natural
 	Expiry uint64 `tlv:"0x57A"`
+	//+field:natural:optional
+	Epoch optional.Optional[uint64] `tlv:"0x57E"`
+	//+field:string:optional
+	Project optional.Optional[string] `tlv:"0x584"`
 }
 
 type DSKResponse struct {
@@ -40,9 +65,107 @@ type DSKResponse struct {
 	X25519Peer []byte `tlv:"0x57A"`
 	//+field:binary
 	Ciphertext []byte `tlv:"0x57C"`
+	//+field:natural:optional
+	Epoch optional.Optional[uint64] `tlv:"0x57E"`
+	//+field:binary
+	Nonce []byte `tlv:"0x580"`
+	//+field:natural
+	Expiry uint64 `tlv:"0x582"`
 }
 
 type DSKACK struct {
 	//+field:binary
 	X25519Peer []byte `tlv:"0x57A"`
 }
+
+type DSKRotate struct {
+	//+field:natural
+	Epoch uint64 `tlv:"0x57E"`
+	// Members removed with this rotation
+	//+field:sequence:string:string
+	Removed []string `tlv:"0x580"`
+}
+
+type ChatMessage struct {
+	//+field:string
+	Channel string `tlv:"0x5F0"`
+	//+field:string
+	ID string `tlv:"0x5F2"`
+	//+field:string
+	Author string `tlv:"0x5F4"`
+	//+field:natural
+	Timestamp uint64 `tlv:"0x5F6"`
+	//+field:string
+	Body string `tlv:"0x5F8"`
+	//+field:string:optional
+	ReplyTo optional.Optional[string] `tlv:"0x5FA"`
+	//+field:sequence:*ChatAttachment:struct:ChatAttachment
+	Attachments []*ChatAttachment `tlv:"0x5FC"`
+}
+
+type ChatAttachment struct {
+	//+field:string
+	Name string `tlv:"0x602"`
+	//+field:string
+	Filename string `tlv:"0x604"`
+	//+field:natural
+	Size uint64 `tlv:"0x606"`
+}
+
+// AccessRequestList is the local queue of access requests to a workspace.
+type AccessRequestList struct {
+	//+field:sequence:*AccessRequest:struct:AccessRequest
+	Requests []*AccessRequest `tlv:"0x5A0"`
+}
+
+type AccessRequest struct {
+	//+field:string
+	Requester string `tlv:"0x5A2"`
+	//+field:natural
+	Time uint64 `tlv:"0x5A4"`
+	//+field:bool
+	Denied bool `tlv:"0x5A6"`
+}
+
+// WorkspaceMeta is the content of the signed workspace metadata.
+type WorkspaceMeta struct {
+	//+field:string
+	Label string `tlv:"0x5B2"`
+	//+field:string
+	Owner string `tlv:"0x5B4"`
+	//+field:natural
+	Created uint64 `tlv:"0x5B6"`
+	//+field:natural
+	SchemaVersion uint64 `tlv:"0x5B8"`
+	//+field:string
+	Repo string `tlv:"0x5BA"`
+	//+field:natural
+	Algorithm uint64 `tlv:"0x5BC"`
+	//+field:sequence:string:string
+	Repos []string `tlv:"0x5BE"`
+}
+
+// ProjectAcl is the content of the signed member list of a private project.
+type ProjectAcl struct {
+	//+field:sequence:string:string
+	Members []string `tlv:"0x5C2"`
+}
+
+// RevocationList is the content of the signed list of revoked members.
+type RevocationList struct {
+	//+field:sequence:string:string
+	Members []string `tlv:"0x5D2"`
+}
+
+// BlobIndex is the local list of blobs of a project in the store.
+type BlobIndex struct {
+	//+field:sequence:*BlobIndexEntry:struct:BlobIndexEntry
+	Blobs []*BlobIndexEntry `tlv:"0x5E0"`
+}
+
+type BlobIndexEntry struct {
+	//+field:string
+	Name string `tlv:"0x5E2"`
+	//+field:natural:optional
+	Unreferenced optional.Optional[uint64] `tlv:"0x5E4"`
+}
@@ -17,6 +17,8 @@ type MessageEncoder struct {
 	DSKRequest_encoder  DSKRequestEncoder
 	DSKResponse_encoder DSKResponseEncoder
 	DSKACK_encoder      DSKACKEncoder
+	DSKRotate_encoder   DSKRotateEncoder
+	ChatMessage_encoder ChatMessageEncoder
 }
 
 type MessageParsingContext struct {
@@ -25,6 +27,8 @@ type MessageParsingContext struct {
 	DSKRequest_context  DSKRequestParsingContext
 	DSKResponse_context DSKResponseParsingContext
 	DSKACK_context      DSKACKParsingContext
+	DSKRotate_context   DSKRotateParsingContext
+	ChatMessage_context ChatMessageParsingContext
 }
 
 func (encoder *MessageEncoder) Init(value *Message) {
@@ -43,6 +47,12 @@ func (encoder *MessageEncoder) Init(value *Message) {
 	if value.DSKACK != nil {
 		encoder.DSKACK_encoder.Init(value.DSKACK)
 	}
+	if value.DSKRotate != nil {
+		encoder.DSKRotate_encoder.Init(value.DSKRotate)
+	}
+	if value.ChatMessage != nil {
+		encoder.ChatMessage_encoder.Init(value.ChatMessage)
+	}
 
 	l := uint(0)
 	if value.AeadBlock != nil {
@@ -70,6 +80,16 @@ func (encoder *MessageEncoder) Init(value *Message) {
 		l += uint(enc.TLNum(encoder.DSKACK_encoder.Length).EncodingLength())
 		l += encoder.DSKACK_encoder.Length
 	}
+	if value.DSKRotate != nil {
+		l += 1
+		l += uint(enc.TLNum(encoder.DSKRotate_encoder.Length).EncodingLength())
+		l += encoder.DSKRotate_encoder.Length
+	}
+	if value.ChatMessage != nil {
+		l += 1
+		l += uint(enc.TLNum(encoder.ChatMessage_encoder.Length).EncodingLength())
+		l += encoder.ChatMessage_encoder.Length
+	}
 	encoder.Length = l
 
 }
@@ -80,6 +100,8 @@ func (context *MessageParsingContext) Init() {
 	context.DSKRequest_context.Init()
 	context.DSKResponse_context.Init()
 	context.DSKACK_context.Init()
+	context.DSKRotate_context.Init()
+	context.ChatMessage_context.Init()
 }
 
 func (encoder *MessageEncoder) EncodeInto(value *Message, buf []byte) {
@@ -131,6 +153,24 @@ func (encoder *MessageEncoder) EncodeInto(value *Message, buf []byte) {
 			pos += encoder.DSKACK_encoder.Length
 		}
 	}
+	if value.DSKRotate != nil {
+		buf[pos] = byte(208)
+		pos += 1
+		pos += uint(enc.TLNum(encoder.DSKRotate_encoder.Length).EncodeInto(buf[pos:]))
+		if encoder.DSKRotate_encoder.Length > 0 {
+			encoder.DSKRotate_encoder.EncodeInto(value.DSKRotate, buf[pos:])
+			pos += encoder.DSKRotate_encoder.Length
+		}
+	}
+	if value.ChatMessage != nil {
+		buf[pos] = byte(210)
+		pos += 1
+		pos += uint(enc.TLNum(encoder.ChatMessage_encoder.Length).EncodeInto(buf[pos:]))
+		if encoder.ChatMessage_encoder.Length > 0 {
+			encoder.ChatMessage_encoder.EncodeInto(value.ChatMessage, buf[pos:])
+			pos += encoder.ChatMessage_encoder.Length
+		}
+	}
 }
 
 func (encoder *MessageEncoder) Encode(value *Message) enc.Wire {
@@ -150,6 +190,8 @@ func (context *MessageParsingContext) Parse(reader enc.WireView, ignoreCritical
 	var handled_DSKRequest bool = false
 	var handled_DSKResponse bool = false
 	var handled_DSKACK bool = false
+	var handled_DSKRotate bool = false
+	var handled_ChatMessage bool = false
 
 	progress := -1
 	_ = progress
@@ -206,6 +248,18 @@ func (context *MessageParsingContext) Parse(reader enc.WireView, ignoreCritical
 					handled_DSKACK = true
 					value.DSKACK, err = context.DSKACK_context.Parse(reader.Delegate(int(l)), ignoreCritical)
 				}
+			case 208:
+				if true {
+					handled = true
+					handled_DSKRotate = true
+					value.DSKRotate, err = context.DSKRotate_context.Parse(reader.Delegate(int(l)), ignoreCritical)
+				}
+			case 210:
+				if true {
+					handled = true
+					handled_ChatMessage = true
+					value.ChatMessage, err = context.ChatMessage_context.Parse(reader.Delegate(int(l)), ignoreCritical)
+				}
 			default:
 				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
 					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
@@ -239,6 +293,12 @@ func (context *MessageParsingContext) Parse(reader enc.WireView, ignoreCritical
 	if !handled_DSKACK && err == nil {
 		value.DSKACK = nil
 	}
+	if !handled_DSKRotate && err == nil {
+		value.DSKRotate = nil
+	}
+	if !handled_ChatMessage && err == nil {
+		value.ChatMessage = nil
+	}
 
 	if err != nil {
 		return nil, err
@@ -283,6 +343,18 @@ func (encoder *AeadBlockEncoder) Init(value *AeadBlock) {
 		l += uint(enc.TLNum(len(value.Ciphertext)).EncodingLength())
 		l += uint(len(value.Ciphertext))
 	}
+	if optval, ok := value.Algorithm.Get(); ok {
+		l += 1
+		l += uint(1 + enc.Nat(optval).EncodingLength())
+	}
+	if optval, ok := value.Epoch.Get(); ok {
+		l += 1
+		l += uint(1 + enc.Nat(optval).EncodingLength())
+	}
+	if value.Project {
+		l += 1
+		l += 1
+	}
 	encoder.Length = l
 
 }
@@ -309,6 +381,28 @@ func (encoder *AeadBlockEncoder) EncodeInto(value *AeadBlock, buf []byte) {
 		copy(buf[pos:], value.Ciphertext)
 		pos += uint(len(value.Ciphertext))
 	}
+	if optval, ok := value.Algorithm.Get(); ok {
+		buf[pos] = byte(204)
+		pos += 1
+
+		buf[pos] = byte(enc.Nat(optval).EncodeInto(buf[pos+1:]))
+		pos += uint(1 + buf[pos])
+
+	}
+	if optval, ok := value.Epoch.Get(); ok {
+		buf[pos] = byte(206)
+		pos += 1
+
+		buf[pos] = byte(enc.Nat(optval).EncodeInto(buf[pos+1:]))
+		pos += uint(1 + buf[pos])
+
+	}
+	if value.Project {
+		buf[pos] = byte(208)
+		pos += 1
+		buf[pos] = byte(0)
+		pos += 1
+	}
 }
 
 func (encoder *AeadBlockEncoder) Encode(value *AeadBlock) enc.Wire {
@@ -325,6 +419,9 @@ func (context *AeadBlockParsingContext) Parse(reader enc.WireView, ignoreCritica
 
 	var handled_IV bool = false
 	var handled_Ciphertext bool = false
+	var handled_Algorithm bool = false
+	var handled_Epoch bool = false
+	var handled_Project bool = false
 
 	progress := -1
 	_ = progress
@@ -365,6 +462,59 @@ func (context *AeadBlockParsingContext) Parse(reader enc.WireView, ignoreCritica
 					value.Ciphertext = make([]byte, l)
 					_, err = reader.ReadFull(value.Ciphertext)
 				}
+			case 204:
+				if true {
+					handled = true
+					handled_Algorithm = true
+					{
+						optval := uint64(0)
+						optval = uint64(0)
+						{
+							for i := 0; i < int(l); i++ {
+								x := byte(0)
+								x, err = reader.ReadByte()
+								if err != nil {
+									if err == io.EOF {
+										err = io.ErrUnexpectedEOF
+									}
+									break
+								}
+								optval = uint64(optval<<8) | uint64(x)
+							}
+						}
+						value.Algorithm.Set(optval)
+					}
+				}
+			case 206:
+				if true {
+					handled = true
+					handled_Epoch = true
+					{
+						optval := uint64(0)
+						optval = uint64(0)
+						{
+							for i := 0; i < int(l); i++ {
+								x := byte(0)
+								x, err = reader.ReadByte()
+								if err != nil {
+									if err == io.EOF {
+										err = io.ErrUnexpectedEOF
+									}
+									break
+								}
+								optval = uint64(optval<<8) | uint64(x)
+							}
+						}
+						value.Epoch.Set(optval)
+					}
+				}
+			case 208:
+				if true {
+					handled = true
+					handled_Project = true
+					value.Project = true
+					err = reader.Skip(int(l))
+				}
 			default:
 				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
 					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
@@ -389,6 +539,15 @@ func (context *AeadBlockParsingContext) Parse(reader enc.WireView, ignoreCritica
 	if !handled_Ciphertext && err == nil {
 		value.Ciphertext = nil
 	}
+	if !handled_Algorithm && err == nil {
+		value.Algorithm.Unset()
+	}
+	if !handled_Epoch && err == nil {
+		value.Epoch.Unset()
+	}
+	if !handled_Project && err == nil {
+		value.Project = false
+	}
 
 	if err != nil {
 		return nil, err
@@ -583,6 +742,15 @@ func (encoder *DSKRequestEncoder) Init(value *DSKRequest) {
 	}
 	l += 3
 	l += uint(1 + enc.Nat(value.Expiry).EncodingLength())
+	if optval, ok := value.Epoch.Get(); ok {
+		l += 3
+		l += uint(1 + enc.Nat(optval).EncodingLength())
+	}
+	if optval, ok := value.Project.Get(); ok {
+		l += 3
+		l += uint(enc.TLNum(len(optval)).EncodingLength())
+		l += uint(len(optval))
+	}
 	encoder.Length = l
 
 }
@@ -609,6 +777,23 @@ func (encoder *DSKRequestEncoder) EncodeInto(value *DSKRequest, buf []byte) {
 
 	buf[pos] = byte(enc.Nat(value.Expiry).EncodeInto(buf[pos+1:]))
 	pos += uint(1 + buf[pos])
+	if optval, ok := value.Epoch.Get(); ok {
+		buf[pos] = 253
+		binary.BigEndian.PutUint16(buf[pos+1:], uint16(1406))
+		pos += 3
+
+		buf[pos] = byte(enc.Nat(optval).EncodeInto(buf[pos+1:]))
+		pos += uint(1 + buf[pos])
+
+	}
+	if optval, ok := value.Project.Get(); ok {
+		buf[pos] = 253
+		binary.BigEndian.PutUint16(buf[pos+1:], uint16(1412))
+		pos += 3
+		pos += uint(enc.TLNum(len(optval)).EncodeInto(buf[pos:]))
+		copy(buf[pos:], optval)
+		pos += uint(len(optval))
+	}
 }
 
 func (encoder *DSKRequestEncoder) Encode(value *DSKRequest) enc.Wire {
@@ -625,6 +810,8 @@ func (context *DSKRequestParsingContext) Parse(reader enc.WireView, ignoreCritic
 
 	var handled_X25519Pub bool = false
 	var handled_Expiry bool = false
+	var handled_Epoch bool = false
+	var handled_Project bool = false
 
 	progress := -1
 	_ = progress
@@ -677,6 +864,41 @@ func (context *DSKRequestParsingContext) Parse(reader enc.WireView, ignoreCritic
 						}
 					}
 				}
+			case 1406:
+				if true {
+					handled = true
+					handled_Epoch = true
+					{
+						optval := uint64(0)
+						optval = uint64(0)
+						{
+							for i := 0; i < int(l); i++ {
+								x := byte(0)
+								x, err = reader.ReadByte()
+								if err != nil {
+									if err == io.EOF {
+										err = io.ErrUnexpectedEOF
+									}
+									break
+								}
+								optval = uint64(optval<<8) | uint64(x)
+							}
+						}
+						value.Epoch.Set(optval)
+					}
+				}
+			case 1412:
+				if true {
+					handled = true
+					handled_Project = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Project.Set(builder.String())
+						}
+					}
+				}
 			default:
 				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
 					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
@@ -701,6 +923,12 @@ func (context *DSKRequestParsingContext) Parse(reader enc.WireView, ignoreCritic
 	if !handled_Expiry && err == nil {
 		err = enc.ErrSkipRequired{Name: "Expiry", TypeNum: 1402}
 	}
+	if !handled_Epoch && err == nil {
+		value.Epoch.Unset()
+	}
+	if !handled_Project && err == nil {
+		value.Project.Unset()
+	}
 
 	if err != nil {
 		return nil, err
@@ -745,6 +973,17 @@ func (encoder *DSKResponseEncoder) Init(value *DSKResponse) {
 		l += uint(enc.TLNum(len(value.Ciphertext)).EncodingLength())
 		l += uint(len(value.Ciphertext))
 	}
+	if optval, ok := value.Epoch.Get(); ok {
+		l += 3
+		l += uint(1 + enc.Nat(optval).EncodingLength())
+	}
+	if value.Nonce != nil {
+		l += 3
+		l += uint(enc.TLNum(len(value.Nonce)).EncodingLength())
+		l += uint(len(value.Nonce))
+	}
+	l += 3
+	l += uint(1 + enc.Nat(value.Expiry).EncodingLength())
 	encoder.Length = l
 
 }
@@ -773,6 +1012,29 @@ func (encoder *DSKResponseEncoder) EncodeInto(value *DSKResponse, buf []byte) {
 		copy(buf[pos:], value.Ciphertext)
 		pos += uint(len(value.Ciphertext))
 	}
+	if optval, ok := value.Epoch.Get(); ok {
+		buf[pos] = 253
+		binary.BigEndian.PutUint16(buf[pos+1:], uint16(1406))
+		pos += 3
+
+		buf[pos] = byte(enc.Nat(optval).EncodeInto(buf[pos+1:]))
+		pos += uint(1 + buf[pos])
+
+	}
+	if value.Nonce != nil {
+		buf[pos] = 253
+		binary.BigEndian.PutUint16(buf[pos+1:], uint16(1408))
+		pos += 3
+		pos += uint(enc.TLNum(len(value.Nonce)).EncodeInto(buf[pos:]))
+		copy(buf[pos:], value.Nonce)
+		pos += uint(len(value.Nonce))
+	}
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1410))
+	pos += 3
+
+	buf[pos] = byte(enc.Nat(value.Expiry).EncodeInto(buf[pos+1:]))
+	pos += uint(1 + buf[pos])
 }
 
 func (encoder *DSKResponseEncoder) Encode(value *DSKResponse) enc.Wire {
@@ -789,6 +1051,9 @@ func (context *DSKResponseParsingContext) Parse(reader enc.WireView, ignoreCriti
 
 	var handled_X25519Peer bool = false
 	var handled_Ciphertext bool = false
+	var handled_Epoch bool = false
+	var handled_Nonce bool = false
+	var handled_Expiry bool = false
 
 	progress := -1
 	_ = progress
@@ -829,6 +1094,55 @@ func (context *DSKResponseParsingContext) Parse(reader enc.WireView, ignoreCriti
 					value.Ciphertext = make([]byte, l)
 					_, err = reader.ReadFull(value.Ciphertext)
 				}
+			case 1406:
+				if true {
+					handled = true
+					handled_Epoch = true
+					{
+						optval := uint64(0)
+						optval = uint64(0)
+						{
+							for i := 0; i < int(l); i++ {
+								x := byte(0)
+								x, err = reader.ReadByte()
+								if err != nil {
+									if err == io.EOF {
+										err = io.ErrUnexpectedEOF
+									}
+									break
+								}
+								optval = uint64(optval<<8) | uint64(x)
+							}
+						}
+						value.Epoch.Set(optval)
+					}
+				}
+			case 1408:
+				if true {
+					handled = true
+					handled_Nonce = true
+					value.Nonce = make([]byte, l)
+					_, err = reader.ReadFull(value.Nonce)
+				}
+			case 1410:
+				if true {
+					handled = true
+					handled_Expiry = true
+					value.Expiry = uint64(0)
+					{
+						for i := 0; i < int(l); i++ {
+							x := byte(0)
+							x, err = reader.ReadByte()
+							if err != nil {
+								if err == io.EOF {
+									err = io.ErrUnexpectedEOF
+								}
+								break
+							}
+							value.Expiry = uint64(value.Expiry<<8) | uint64(x)
+						}
+					}
+				}
 			default:
 				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
 					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
@@ -853,6 +1167,15 @@ func (context *DSKResponseParsingContext) Parse(reader enc.WireView, ignoreCriti
 	if !handled_Ciphertext && err == nil {
 		value.Ciphertext = nil
 	}
+	if !handled_Epoch && err == nil {
+		value.Epoch.Unset()
+	}
+	if !handled_Nonce && err == nil {
+		value.Nonce = nil
+	}
+	if !handled_Expiry && err == nil {
+		err = enc.ErrSkipRequired{Name: "Expiry", TypeNum: 1410}
+	}
 
 	if err != nil {
 		return nil, err
@@ -1004,3 +1327,2295 @@ func ParseDSKACK(reader enc.WireView, ignoreCritical bool) (*DSKACK, error) {
 	context.Init()
 	return context.Parse(reader, ignoreCritical)
 }
+
+type DSKRotateEncoder struct {
+	Length uint
+
+	Removed_subencoder []struct {
+	}
+}
+
+type DSKRotateParsingContext struct {
+}
+
+func (encoder *DSKRotateEncoder) Init(value *DSKRotate) {
+	{
+		Removed_l := len(value.Removed)
+		encoder.Removed_subencoder = make([]struct {
+		}, Removed_l)
+		for i := 0; i < Removed_l; i++ {
+			pseudoEncoder := &encoder.Removed_subencoder[i]
+			pseudoValue := struct {
+				Removed string
+			}{
+				Removed: value.Removed[i],
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+
+	l := uint(0)
+	l += 3
+	l += uint(1 + enc.Nat(value.Epoch).EncodingLength())
+	if value.Removed != nil {
+		for seq_i, seq_v := range value.Removed {
+			pseudoEncoder := &encoder.Removed_subencoder[seq_i]
+			pseudoValue := struct {
+				Removed string
+			}{
+				Removed: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				l += 3
+				l += uint(enc.TLNum(len(value.Removed)).EncodingLength())
+				l += uint(len(value.Removed))
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+	encoder.Length = l
+
+}
+
+func (context *DSKRotateParsingContext) Init() {
+
+}
+
+func (encoder *DSKRotateEncoder) EncodeInto(value *DSKRotate, buf []byte) {
+
+	pos := uint(0)
+
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1406))
+	pos += 3
+
+	buf[pos] = byte(enc.Nat(value.Epoch).EncodeInto(buf[pos+1:]))
+	pos += uint(1 + buf[pos])
+	if value.Removed != nil {
+		for seq_i, seq_v := range value.Removed {
+			pseudoEncoder := &encoder.Removed_subencoder[seq_i]
+			pseudoValue := struct {
+				Removed string
+			}{
+				Removed: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				buf[pos] = 253
+				binary.BigEndian.PutUint16(buf[pos+1:], uint16(1408))
+				pos += 3
+				pos += uint(enc.TLNum(len(value.Removed)).EncodeInto(buf[pos:]))
+				copy(buf[pos:], value.Removed)
+				pos += uint(len(value.Removed))
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+}
+
+func (encoder *DSKRotateEncoder) Encode(value *DSKRotate) enc.Wire {
+
+	wire := make(enc.Wire, 1)
+	wire[0] = make([]byte, encoder.Length)
+	buf := wire[0]
+	encoder.EncodeInto(value, buf)
+
+	return wire
+}
+
+func (context *DSKRotateParsingContext) Parse(reader enc.WireView, ignoreCritical bool) (*DSKRotate, error) {
+
+	var handled_Epoch bool = false
+	var handled_Removed bool = false
+
+	progress := -1
+	_ = progress
+
+	value := &DSKRotate{}
+	var err error
+	var startPos int
+	for {
+		startPos = reader.Pos()
+		if startPos >= reader.Length() {
+			break
+		}
+		typ := enc.TLNum(0)
+		l := enc.TLNum(0)
+		typ, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+		l, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+
+		err = nil
+		if handled := false; true {
+			switch typ {
+			case 1406:
+				if true {
+					handled = true
+					handled_Epoch = true
+					value.Epoch = uint64(0)
+					{
+						for i := 0; i < int(l); i++ {
+							x := byte(0)
+							x, err = reader.ReadByte()
+							if err != nil {
+								if err == io.EOF {
+									err = io.ErrUnexpectedEOF
+								}
+								break
+							}
+							value.Epoch = uint64(value.Epoch<<8) | uint64(x)
+						}
+					}
+				}
+			case 1408:
+				if true {
+					handled = true
+					handled_Removed = true
+					if value.Removed == nil {
+						value.Removed = make([]string, 0)
+					}
+					{
+						pseudoValue := struct {
+							Removed string
+						}{}
+						{
+							value := &pseudoValue
+							{
+								var builder strings.Builder
+								_, err = reader.CopyN(&builder, int(l))
+								if err == nil {
+									value.Removed = builder.String()
+								}
+							}
+							_ = value
+						}
+						value.Removed = append(value.Removed, pseudoValue.Removed)
+					}
+					progress--
+				}
+			default:
+				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
+					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
+				}
+				handled = true
+				err = reader.Skip(int(l))
+			}
+			if err == nil && !handled {
+			}
+			if err != nil {
+				return nil, enc.ErrFailToParse{TypeNum: typ, Err: err}
+			}
+		}
+	}
+
+	startPos = reader.Pos()
+	err = nil
+
+	if !handled_Epoch && err == nil {
+		err = enc.ErrSkipRequired{Name: "Epoch", TypeNum: 1406}
+	}
+	if !handled_Removed && err == nil {
+		// sequence - skip
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (value *DSKRotate) Encode() enc.Wire {
+	encoder := DSKRotateEncoder{}
+	encoder.Init(value)
+	return encoder.Encode(value)
+}
+
+func (value *DSKRotate) Bytes() []byte {
+	return value.Encode().Join()
+}
+
+func ParseDSKRotate(reader enc.WireView, ignoreCritical bool) (*DSKRotate, error) {
+	context := DSKRotateParsingContext{}
+	context.Init()
+	return context.Parse(reader, ignoreCritical)
+}
+
+type ChatMessageEncoder struct {
+	Length uint
+
+	Attachments_subencoder []struct {
+		Attachments_encoder ChatAttachmentEncoder
+	}
+}
+
+type ChatMessageParsingContext struct {
+	Attachments_context ChatAttachmentParsingContext
+}
+
+func (encoder *ChatMessageEncoder) Init(value *ChatMessage) {
+	{
+		Attachments_l := len(value.Attachments)
+		encoder.Attachments_subencoder = make([]struct {
+			Attachments_encoder ChatAttachmentEncoder
+		}, Attachments_l)
+		for i := 0; i < Attachments_l; i++ {
+			pseudoEncoder := &encoder.Attachments_subencoder[i]
+			pseudoValue := struct {
+				Attachments *ChatAttachment
+			}{
+				Attachments: value.Attachments[i],
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				if value.Attachments != nil {
+					encoder.Attachments_encoder.Init(value.Attachments)
+				}
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+
+	l := uint(0)
+	l += 3
+	l += uint(enc.TLNum(len(value.Channel)).EncodingLength())
+	l += uint(len(value.Channel))
+	l += 3
+	l += uint(enc.TLNum(len(value.ID)).EncodingLength())
+	l += uint(len(value.ID))
+	l += 3
+	l += uint(enc.TLNum(len(value.Author)).EncodingLength())
+	l += uint(len(value.Author))
+	l += 3
+	l += uint(1 + enc.Nat(value.Timestamp).EncodingLength())
+	l += 3
+	l += uint(enc.TLNum(len(value.Body)).EncodingLength())
+	l += uint(len(value.Body))
+	if optval, ok := value.ReplyTo.Get(); ok {
+		l += 3
+		l += uint(enc.TLNum(len(optval)).EncodingLength())
+		l += uint(len(optval))
+	}
+	if value.Attachments != nil {
+		for seq_i, seq_v := range value.Attachments {
+			pseudoEncoder := &encoder.Attachments_subencoder[seq_i]
+			pseudoValue := struct {
+				Attachments *ChatAttachment
+			}{
+				Attachments: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				if value.Attachments != nil {
+					l += 3
+					l += uint(enc.TLNum(encoder.Attachments_encoder.Length).EncodingLength())
+					l += encoder.Attachments_encoder.Length
+				}
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+	encoder.Length = l
+
+}
+
+func (context *ChatMessageParsingContext) Init() {
+	context.Attachments_context.Init()
+}
+
+func (encoder *ChatMessageEncoder) EncodeInto(value *ChatMessage, buf []byte) {
+
+	pos := uint(0)
+
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1520))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.Channel)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.Channel)
+	pos += uint(len(value.Channel))
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1522))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.ID)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.ID)
+	pos += uint(len(value.ID))
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1524))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.Author)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.Author)
+	pos += uint(len(value.Author))
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1526))
+	pos += 3
+
+	buf[pos] = byte(enc.Nat(value.Timestamp).EncodeInto(buf[pos+1:]))
+	pos += uint(1 + buf[pos])
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1528))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.Body)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.Body)
+	pos += uint(len(value.Body))
+	if optval, ok := value.ReplyTo.Get(); ok {
+		buf[pos] = 253
+		binary.BigEndian.PutUint16(buf[pos+1:], uint16(1530))
+		pos += 3
+		pos += uint(enc.TLNum(len(optval)).EncodeInto(buf[pos:]))
+		copy(buf[pos:], optval)
+		pos += uint(len(optval))
+	}
+	if value.Attachments != nil {
+		for seq_i, seq_v := range value.Attachments {
+			pseudoEncoder := &encoder.Attachments_subencoder[seq_i]
+			pseudoValue := struct {
+				Attachments *ChatAttachment
+			}{
+				Attachments: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				if value.Attachments != nil {
+					buf[pos] = 253
+					binary.BigEndian.PutUint16(buf[pos+1:], uint16(1532))
+					pos += 3
+					pos += uint(enc.TLNum(encoder.Attachments_encoder.Length).EncodeInto(buf[pos:]))
+					if encoder.Attachments_encoder.Length > 0 {
+						encoder.Attachments_encoder.EncodeInto(value.Attachments, buf[pos:])
+						pos += encoder.Attachments_encoder.Length
+					}
+				}
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+}
+
+func (encoder *ChatMessageEncoder) Encode(value *ChatMessage) enc.Wire {
+
+	wire := make(enc.Wire, 1)
+	wire[0] = make([]byte, encoder.Length)
+	buf := wire[0]
+	encoder.EncodeInto(value, buf)
+
+	return wire
+}
+
+func (context *ChatMessageParsingContext) Parse(reader enc.WireView, ignoreCritical bool) (*ChatMessage, error) {
+
+	var handled_Channel bool = false
+	var handled_ID bool = false
+	var handled_Author bool = false
+	var handled_Timestamp bool = false
+	var handled_Body bool = false
+	var handled_ReplyTo bool = false
+	var handled_Attachments bool = false
+
+	progress := -1
+	_ = progress
+
+	value := &ChatMessage{}
+	var err error
+	var startPos int
+	for {
+		startPos = reader.Pos()
+		if startPos >= reader.Length() {
+			break
+		}
+		typ := enc.TLNum(0)
+		l := enc.TLNum(0)
+		typ, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+		l, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+
+		err = nil
+		if handled := false; true {
+			switch typ {
+			case 1520:
+				if true {
+					handled = true
+					handled_Channel = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Channel = builder.String()
+						}
+					}
+				}
+			case 1522:
+				if true {
+					handled = true
+					handled_ID = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.ID = builder.String()
+						}
+					}
+				}
+			case 1524:
+				if true {
+					handled = true
+					handled_Author = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Author = builder.String()
+						}
+					}
+				}
+			case 1526:
+				if true {
+					handled = true
+					handled_Timestamp = true
+					value.Timestamp = uint64(0)
+					{
+						for i := 0; i < int(l); i++ {
+							x := byte(0)
+							x, err = reader.ReadByte()
+							if err != nil {
+								if err == io.EOF {
+									err = io.ErrUnexpectedEOF
+								}
+								break
+							}
+							value.Timestamp = uint64(value.Timestamp<<8) | uint64(x)
+						}
+					}
+				}
+			case 1528:
+				if true {
+					handled = true
+					handled_Body = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Body = builder.String()
+						}
+					}
+				}
+			case 1530:
+				if true {
+					handled = true
+					handled_ReplyTo = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.ReplyTo.Set(builder.String())
+						}
+					}
+				}
+			case 1532:
+				if true {
+					handled = true
+					handled_Attachments = true
+					if value.Attachments == nil {
+						value.Attachments = make([]*ChatAttachment, 0)
+					}
+					{
+						pseudoValue := struct {
+							Attachments *ChatAttachment
+						}{}
+						{
+							value := &pseudoValue
+							value.Attachments, err = context.Attachments_context.Parse(reader.Delegate(int(l)), ignoreCritical)
+							_ = value
+						}
+						value.Attachments = append(value.Attachments, pseudoValue.Attachments)
+					}
+					progress--
+				}
+			default:
+				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
+					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
+				}
+				handled = true
+				err = reader.Skip(int(l))
+			}
+			if err == nil && !handled {
+			}
+			if err != nil {
+				return nil, enc.ErrFailToParse{TypeNum: typ, Err: err}
+			}
+		}
+	}
+
+	startPos = reader.Pos()
+	err = nil
+
+	if !handled_Channel && err == nil {
+		err = enc.ErrSkipRequired{Name: "Channel", TypeNum: 1520}
+	}
+	if !handled_ID && err == nil {
+		err = enc.ErrSkipRequired{Name: "ID", TypeNum: 1522}
+	}
+	if !handled_Author && err == nil {
+		err = enc.ErrSkipRequired{Name: "Author", TypeNum: 1524}
+	}
+	if !handled_Timestamp && err == nil {
+		err = enc.ErrSkipRequired{Name: "Timestamp", TypeNum: 1526}
+	}
+	if !handled_Body && err == nil {
+		err = enc.ErrSkipRequired{Name: "Body", TypeNum: 1528}
+	}
+	if !handled_ReplyTo && err == nil {
+		value.ReplyTo.Unset()
+	}
+	if !handled_Attachments && err == nil {
+		// sequence - skip
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (value *ChatMessage) Encode() enc.Wire {
+	encoder := ChatMessageEncoder{}
+	encoder.Init(value)
+	return encoder.Encode(value)
+}
+
+func (value *ChatMessage) Bytes() []byte {
+	return value.Encode().Join()
+}
+
+func ParseChatMessage(reader enc.WireView, ignoreCritical bool) (*ChatMessage, error) {
+	context := ChatMessageParsingContext{}
+	context.Init()
+	return context.Parse(reader, ignoreCritical)
+}
+
+type ChatAttachmentEncoder struct {
+	Length uint
+}
+
+type ChatAttachmentParsingContext struct {
+}
+
+func (encoder *ChatAttachmentEncoder) Init(value *ChatAttachment) {
+
+	l := uint(0)
+	l += 3
+	l += uint(enc.TLNum(len(value.Name)).EncodingLength())
+	l += uint(len(value.Name))
+	l += 3
+	l += uint(enc.TLNum(len(value.Filename)).EncodingLength())
+	l += uint(len(value.Filename))
+	l += 3
+	l += uint(1 + enc.Nat(value.Size).EncodingLength())
+	encoder.Length = l
+
+}
+
+func (context *ChatAttachmentParsingContext) Init() {
+
+}
+
+func (encoder *ChatAttachmentEncoder) EncodeInto(value *ChatAttachment, buf []byte) {
+
+	pos := uint(0)
+
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1538))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.Name)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.Name)
+	pos += uint(len(value.Name))
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1540))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.Filename)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.Filename)
+	pos += uint(len(value.Filename))
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1542))
+	pos += 3
+
+	buf[pos] = byte(enc.Nat(value.Size).EncodeInto(buf[pos+1:]))
+	pos += uint(1 + buf[pos])
+}
+
+func (encoder *ChatAttachmentEncoder) Encode(value *ChatAttachment) enc.Wire {
+
+	wire := make(enc.Wire, 1)
+	wire[0] = make([]byte, encoder.Length)
+	buf := wire[0]
+	encoder.EncodeInto(value, buf)
+
+	return wire
+}
+
+func (context *ChatAttachmentParsingContext) Parse(reader enc.WireView, ignoreCritical bool) (*ChatAttachment, error) {
+
+	var handled_Name bool = false
+	var handled_Filename bool = false
+	var handled_Size bool = false
+
+	progress := -1
+	_ = progress
+
+	value := &ChatAttachment{}
+	var err error
+	var startPos int
+	for {
+		startPos = reader.Pos()
+		if startPos >= reader.Length() {
+			break
+		}
+		typ := enc.TLNum(0)
+		l := enc.TLNum(0)
+		typ, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+		l, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+
+		err = nil
+		if handled := false; true {
+			switch typ {
+			case 1538:
+				if true {
+					handled = true
+					handled_Name = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Name = builder.String()
+						}
+					}
+				}
+			case 1540:
+				if true {
+					handled = true
+					handled_Filename = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Filename = builder.String()
+						}
+					}
+				}
+			case 1542:
+				if true {
+					handled = true
+					handled_Size = true
+					value.Size = uint64(0)
+					{
+						for i := 0; i < int(l); i++ {
+							x := byte(0)
+							x, err = reader.ReadByte()
+							if err != nil {
+								if err == io.EOF {
+									err = io.ErrUnexpectedEOF
+								}
+								break
+							}
+							value.Size = uint64(value.Size<<8) | uint64(x)
+						}
+					}
+				}
+			default:
+				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
+					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
+				}
+				handled = true
+				err = reader.Skip(int(l))
+			}
+			if err == nil && !handled {
+			}
+			if err != nil {
+				return nil, enc.ErrFailToParse{TypeNum: typ, Err: err}
+			}
+		}
+	}
+
+	startPos = reader.Pos()
+	err = nil
+
+	if !handled_Name && err == nil {
+		err = enc.ErrSkipRequired{Name: "Name", TypeNum: 1538}
+	}
+	if !handled_Filename && err == nil {
+		err = enc.ErrSkipRequired{Name: "Filename", TypeNum: 1540}
+	}
+	if !handled_Size && err == nil {
+		err = enc.ErrSkipRequired{Name: "Size", TypeNum: 1542}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (value *ChatAttachment) Encode() enc.Wire {
+	encoder := ChatAttachmentEncoder{}
+	encoder.Init(value)
+	return encoder.Encode(value)
+}
+
+func (value *ChatAttachment) Bytes() []byte {
+	return value.Encode().Join()
+}
+
+func ParseChatAttachment(reader enc.WireView, ignoreCritical bool) (*ChatAttachment, error) {
+	context := ChatAttachmentParsingContext{}
+	context.Init()
+	return context.Parse(reader, ignoreCritical)
+}
+
+type AccessRequestListEncoder struct {
+	Length uint
+
+	Requests_subencoder []struct {
+		Requests_encoder AccessRequestEncoder
+	}
+}
+
+type AccessRequestListParsingContext struct {
+	Requests_context AccessRequestParsingContext
+}
+
+func (encoder *AccessRequestListEncoder) Init(value *AccessRequestList) {
+	{
+		Requests_l := len(value.Requests)
+		encoder.Requests_subencoder = make([]struct {
+			Requests_encoder AccessRequestEncoder
+		}, Requests_l)
+		for i := 0; i < Requests_l; i++ {
+			pseudoEncoder := &encoder.Requests_subencoder[i]
+			pseudoValue := struct {
+				Requests *AccessRequest
+			}{
+				Requests: value.Requests[i],
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				if value.Requests != nil {
+					encoder.Requests_encoder.Init(value.Requests)
+				}
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+
+	l := uint(0)
+	if value.Requests != nil {
+		for seq_i, seq_v := range value.Requests {
+			pseudoEncoder := &encoder.Requests_subencoder[seq_i]
+			pseudoValue := struct {
+				Requests *AccessRequest
+			}{
+				Requests: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				if value.Requests != nil {
+					l += 3
+					l += uint(enc.TLNum(encoder.Requests_encoder.Length).EncodingLength())
+					l += encoder.Requests_encoder.Length
+				}
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+	encoder.Length = l
+
+}
+
+func (context *AccessRequestListParsingContext) Init() {
+	context.Requests_context.Init()
+}
+
+func (encoder *AccessRequestListEncoder) EncodeInto(value *AccessRequestList, buf []byte) {
+
+	pos := uint(0)
+
+	if value.Requests != nil {
+		for seq_i, seq_v := range value.Requests {
+			pseudoEncoder := &encoder.Requests_subencoder[seq_i]
+			pseudoValue := struct {
+				Requests *AccessRequest
+			}{
+				Requests: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				if value.Requests != nil {
+					buf[pos] = 253
+					binary.BigEndian.PutUint16(buf[pos+1:], uint16(1440))
+					pos += 3
+					pos += uint(enc.TLNum(encoder.Requests_encoder.Length).EncodeInto(buf[pos:]))
+					if encoder.Requests_encoder.Length > 0 {
+						encoder.Requests_encoder.EncodeInto(value.Requests, buf[pos:])
+						pos += encoder.Requests_encoder.Length
+					}
+				}
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+}
+
+func (encoder *AccessRequestListEncoder) Encode(value *AccessRequestList) enc.Wire {
+
+	wire := make(enc.Wire, 1)
+	wire[0] = make([]byte, encoder.Length)
+	buf := wire[0]
+	encoder.EncodeInto(value, buf)
+
+	return wire
+}
+
+func (context *AccessRequestListParsingContext) Parse(reader enc.WireView, ignoreCritical bool) (*AccessRequestList, error) {
+
+	var handled_Requests bool = false
+
+	progress := -1
+	_ = progress
+
+	value := &AccessRequestList{}
+	var err error
+	var startPos int
+	for {
+		startPos = reader.Pos()
+		if startPos >= reader.Length() {
+			break
+		}
+		typ := enc.TLNum(0)
+		l := enc.TLNum(0)
+		typ, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+		l, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+
+		err = nil
+		if handled := false; true {
+			switch typ {
+			case 1440:
+				if true {
+					handled = true
+					handled_Requests = true
+					if value.Requests == nil {
+						value.Requests = make([]*AccessRequest, 0)
+					}
+					{
+						pseudoValue := struct {
+							Requests *AccessRequest
+						}{}
+						{
+							value := &pseudoValue
+							value.Requests, err = context.Requests_context.Parse(reader.Delegate(int(l)), ignoreCritical)
+							_ = value
+						}
+						value.Requests = append(value.Requests, pseudoValue.Requests)
+					}
+					progress--
+				}
+			default:
+				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
+					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
+				}
+				handled = true
+				err = reader.Skip(int(l))
+			}
+			if err == nil && !handled {
+			}
+			if err != nil {
+				return nil, enc.ErrFailToParse{TypeNum: typ, Err: err}
+			}
+		}
+	}
+
+	startPos = reader.Pos()
+	err = nil
+
+	if !handled_Requests && err == nil {
+		// sequence - skip
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (value *AccessRequestList) Encode() enc.Wire {
+	encoder := AccessRequestListEncoder{}
+	encoder.Init(value)
+	return encoder.Encode(value)
+}
+
+func (value *AccessRequestList) Bytes() []byte {
+	return value.Encode().Join()
+}
+
+func ParseAccessRequestList(reader enc.WireView, ignoreCritical bool) (*AccessRequestList, error) {
+	context := AccessRequestListParsingContext{}
+	context.Init()
+	return context.Parse(reader, ignoreCritical)
+}
+
+type AccessRequestEncoder struct {
+	Length uint
+}
+
+type AccessRequestParsingContext struct {
+}
+
+func (encoder *AccessRequestEncoder) Init(value *AccessRequest) {
+
+	l := uint(0)
+	l += 3
+	l += uint(enc.TLNum(len(value.Requester)).EncodingLength())
+	l += uint(len(value.Requester))
+	l += 3
+	l += uint(1 + enc.Nat(value.Time).EncodingLength())
+	if value.Denied {
+		l += 3
+		l += 1
+	}
+	encoder.Length = l
+
+}
+
+func (context *AccessRequestParsingContext) Init() {
+
+}
+
+func (encoder *AccessRequestEncoder) EncodeInto(value *AccessRequest, buf []byte) {
+
+	pos := uint(0)
+
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1442))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.Requester)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.Requester)
+	pos += uint(len(value.Requester))
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1444))
+	pos += 3
+
+	buf[pos] = byte(enc.Nat(value.Time).EncodeInto(buf[pos+1:]))
+	pos += uint(1 + buf[pos])
+	if value.Denied {
+		buf[pos] = 253
+		binary.BigEndian.PutUint16(buf[pos+1:], uint16(1446))
+		pos += 3
+		buf[pos] = byte(0)
+		pos += 1
+	}
+}
+
+func (encoder *AccessRequestEncoder) Encode(value *AccessRequest) enc.Wire {
+
+	wire := make(enc.Wire, 1)
+	wire[0] = make([]byte, encoder.Length)
+	buf := wire[0]
+	encoder.EncodeInto(value, buf)
+
+	return wire
+}
+
+func (context *AccessRequestParsingContext) Parse(reader enc.WireView, ignoreCritical bool) (*AccessRequest, error) {
+
+	var handled_Requester bool = false
+	var handled_Time bool = false
+	var handled_Denied bool = false
+
+	progress := -1
+	_ = progress
+
+	value := &AccessRequest{}
+	var err error
+	var startPos int
+	for {
+		startPos = reader.Pos()
+		if startPos >= reader.Length() {
+			break
+		}
+		typ := enc.TLNum(0)
+		l := enc.TLNum(0)
+		typ, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+		l, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+
+		err = nil
+		if handled := false; true {
+			switch typ {
+			case 1442:
+				if true {
+					handled = true
+					handled_Requester = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Requester = builder.String()
+						}
+					}
+				}
+			case 1444:
+				if true {
+					handled = true
+					handled_Time = true
+					value.Time = uint64(0)
+					{
+						for i := 0; i < int(l); i++ {
+							x := byte(0)
+							x, err = reader.ReadByte()
+							if err != nil {
+								if err == io.EOF {
+									err = io.ErrUnexpectedEOF
+								}
+								break
+							}
+							value.Time = uint64(value.Time<<8) | uint64(x)
+						}
+					}
+				}
+			case 1446:
+				if true {
+					handled = true
+					handled_Denied = true
+					value.Denied = true
+					err = reader.Skip(int(l))
+				}
+			default:
+				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
+					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
+				}
+				handled = true
+				err = reader.Skip(int(l))
+			}
+			if err == nil && !handled {
+			}
+			if err != nil {
+				return nil, enc.ErrFailToParse{TypeNum: typ, Err: err}
+			}
+		}
+	}
+
+	startPos = reader.Pos()
+	err = nil
+
+	if !handled_Requester && err == nil {
+		err = enc.ErrSkipRequired{Name: "Requester", TypeNum: 1442}
+	}
+	if !handled_Time && err == nil {
+		err = enc.ErrSkipRequired{Name: "Time", TypeNum: 1444}
+	}
+	if !handled_Denied && err == nil {
+		value.Denied = false
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (value *AccessRequest) Encode() enc.Wire {
+	encoder := AccessRequestEncoder{}
+	encoder.Init(value)
+	return encoder.Encode(value)
+}
+
+func (value *AccessRequest) Bytes() []byte {
+	return value.Encode().Join()
+}
+
+func ParseAccessRequest(reader enc.WireView, ignoreCritical bool) (*AccessRequest, error) {
+	context := AccessRequestParsingContext{}
+	context.Init()
+	return context.Parse(reader, ignoreCritical)
+}
+
+type WorkspaceMetaEncoder struct {
+	Length uint
+
+	Repos_subencoder []struct {
+	}
+}
+
+type WorkspaceMetaParsingContext struct {
+}
+
+func (encoder *WorkspaceMetaEncoder) Init(value *WorkspaceMeta) {
+	{
+		Repos_l := len(value.Repos)
+		encoder.Repos_subencoder = make([]struct {
+		}, Repos_l)
+		for i := 0; i < Repos_l; i++ {
+			pseudoEncoder := &encoder.Repos_subencoder[i]
+			pseudoValue := struct {
+				Repos string
+			}{
+				Repos: value.Repos[i],
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+
+	l := uint(0)
+	l += 3
+	l += uint(enc.TLNum(len(value.Label)).EncodingLength())
+	l += uint(len(value.Label))
+	l += 3
+	l += uint(enc.TLNum(len(value.Owner)).EncodingLength())
+	l += uint(len(value.Owner))
+	l += 3
+	l += uint(1 + enc.Nat(value.Created).EncodingLength())
+	l += 3
+	l += uint(1 + enc.Nat(value.SchemaVersion).EncodingLength())
+	l += 3
+	l += uint(enc.TLNum(len(value.Repo)).EncodingLength())
+	l += uint(len(value.Repo))
+	l += 3
+	l += uint(1 + enc.Nat(value.Algorithm).EncodingLength())
+	if value.Repos != nil {
+		for seq_i, seq_v := range value.Repos {
+			pseudoEncoder := &encoder.Repos_subencoder[seq_i]
+			pseudoValue := struct {
+				Repos string
+			}{
+				Repos: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				l += 3
+				l += uint(enc.TLNum(len(value.Repos)).EncodingLength())
+				l += uint(len(value.Repos))
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+	encoder.Length = l
+
+}
+
+func (context *WorkspaceMetaParsingContext) Init() {
+
+}
+
+func (encoder *WorkspaceMetaEncoder) EncodeInto(value *WorkspaceMeta, buf []byte) {
+
+	pos := uint(0)
+
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1458))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.Label)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.Label)
+	pos += uint(len(value.Label))
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1460))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.Owner)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.Owner)
+	pos += uint(len(value.Owner))
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1462))
+	pos += 3
+
+	buf[pos] = byte(enc.Nat(value.Created).EncodeInto(buf[pos+1:]))
+	pos += uint(1 + buf[pos])
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1464))
+	pos += 3
+
+	buf[pos] = byte(enc.Nat(value.SchemaVersion).EncodeInto(buf[pos+1:]))
+	pos += uint(1 + buf[pos])
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1466))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.Repo)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.Repo)
+	pos += uint(len(value.Repo))
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1468))
+	pos += 3
+
+	buf[pos] = byte(enc.Nat(value.Algorithm).EncodeInto(buf[pos+1:]))
+	pos += uint(1 + buf[pos])
+	if value.Repos != nil {
+		for seq_i, seq_v := range value.Repos {
+			pseudoEncoder := &encoder.Repos_subencoder[seq_i]
+			pseudoValue := struct {
+				Repos string
+			}{
+				Repos: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				buf[pos] = 253
+				binary.BigEndian.PutUint16(buf[pos+1:], uint16(1470))
+				pos += 3
+				pos += uint(enc.TLNum(len(value.Repos)).EncodeInto(buf[pos:]))
+				copy(buf[pos:], value.Repos)
+				pos += uint(len(value.Repos))
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+}
+
+func (encoder *WorkspaceMetaEncoder) Encode(value *WorkspaceMeta) enc.Wire {
+
+	wire := make(enc.Wire, 1)
+	wire[0] = make([]byte, encoder.Length)
+	buf := wire[0]
+	encoder.EncodeInto(value, buf)
+
+	return wire
+}
+
+func (context *WorkspaceMetaParsingContext) Parse(reader enc.WireView, ignoreCritical bool) (*WorkspaceMeta, error) {
+
+	var handled_Label bool = false
+	var handled_Owner bool = false
+	var handled_Created bool = false
+	var handled_SchemaVersion bool = false
+	var handled_Repo bool = false
+	var handled_Algorithm bool = false
+	var handled_Repos bool = false
+
+	progress := -1
+	_ = progress
+
+	value := &WorkspaceMeta{}
+	var err error
+	var startPos int
+	for {
+		startPos = reader.Pos()
+		if startPos >= reader.Length() {
+			break
+		}
+		typ := enc.TLNum(0)
+		l := enc.TLNum(0)
+		typ, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+		l, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+
+		err = nil
+		if handled := false; true {
+			switch typ {
+			case 1458:
+				if true {
+					handled = true
+					handled_Label = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Label = builder.String()
+						}
+					}
+				}
+			case 1460:
+				if true {
+					handled = true
+					handled_Owner = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Owner = builder.String()
+						}
+					}
+				}
+			case 1462:
+				if true {
+					handled = true
+					handled_Created = true
+					value.Created = uint64(0)
+					{
+						for i := 0; i < int(l); i++ {
+							x := byte(0)
+							x, err = reader.ReadByte()
+							if err != nil {
+								if err == io.EOF {
+									err = io.ErrUnexpectedEOF
+								}
+								break
+							}
+							value.Created = uint64(value.Created<<8) | uint64(x)
+						}
+					}
+				}
+			case 1464:
+				if true {
+					handled = true
+					handled_SchemaVersion = true
+					value.SchemaVersion = uint64(0)
+					{
+						for i := 0; i < int(l); i++ {
+							x := byte(0)
+							x, err = reader.ReadByte()
+							if err != nil {
+								if err == io.EOF {
+									err = io.ErrUnexpectedEOF
+								}
+								break
+							}
+							value.SchemaVersion = uint64(value.SchemaVersion<<8) | uint64(x)
+						}
+					}
+				}
+			case 1466:
+				if true {
+					handled = true
+					handled_Repo = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Repo = builder.String()
+						}
+					}
+				}
+			case 1468:
+				if true {
+					handled = true
+					handled_Algorithm = true
+					value.Algorithm = uint64(0)
+					{
+						for i := 0; i < int(l); i++ {
+							x := byte(0)
+							x, err = reader.ReadByte()
+							if err != nil {
+								if err == io.EOF {
+									err = io.ErrUnexpectedEOF
+								}
+								break
+							}
+							value.Algorithm = uint64(value.Algorithm<<8) | uint64(x)
+						}
+					}
+				}
+			case 1470:
+				if true {
+					handled = true
+					handled_Repos = true
+					if value.Repos == nil {
+						value.Repos = make([]string, 0)
+					}
+					{
+						pseudoValue := struct {
+							Repos string
+						}{}
+						{
+							value := &pseudoValue
+							{
+								var builder strings.Builder
+								_, err = reader.CopyN(&builder, int(l))
+								if err == nil {
+									value.Repos = builder.String()
+								}
+							}
+							_ = value
+						}
+						value.Repos = append(value.Repos, pseudoValue.Repos)
+					}
+					progress--
+				}
+			default:
+				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
+					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
+				}
+				handled = true
+				err = reader.Skip(int(l))
+			}
+			if err == nil && !handled {
+			}
+			if err != nil {
+				return nil, enc.ErrFailToParse{TypeNum: typ, Err: err}
+			}
+		}
+	}
+
+	startPos = reader.Pos()
+	err = nil
+
+	if !handled_Label && err == nil {
+		err = enc.ErrSkipRequired{Name: "Label", TypeNum: 1458}
+	}
+	if !handled_Owner && err == nil {
+		err = enc.ErrSkipRequired{Name: "Owner", TypeNum: 1460}
+	}
+	if !handled_Created && err == nil {
+		err = enc.ErrSkipRequired{Name: "Created", TypeNum: 1462}
+	}
+	if !handled_SchemaVersion && err == nil {
+		err = enc.ErrSkipRequired{Name: "SchemaVersion", TypeNum: 1464}
+	}
+	if !handled_Repo && err == nil {
+		err = enc.ErrSkipRequired{Name: "Repo", TypeNum: 1466}
+	}
+	if !handled_Algorithm && err == nil {
+		err = enc.ErrSkipRequired{Name: "Algorithm", TypeNum: 1468}
+	}
+	if !handled_Repos && err == nil {
+		// sequence - skip
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (value *WorkspaceMeta) Encode() enc.Wire {
+	encoder := WorkspaceMetaEncoder{}
+	encoder.Init(value)
+	return encoder.Encode(value)
+}
+
+func (value *WorkspaceMeta) Bytes() []byte {
+	return value.Encode().Join()
+}
+
+func ParseWorkspaceMeta(reader enc.WireView, ignoreCritical bool) (*WorkspaceMeta, error) {
+	context := WorkspaceMetaParsingContext{}
+	context.Init()
+	return context.Parse(reader, ignoreCritical)
+}
+
+type ProjectAclEncoder struct {
+	Length uint
+
+	Members_subencoder []struct {
+	}
+}
+
+type ProjectAclParsingContext struct {
+}
+
+func (encoder *ProjectAclEncoder) Init(value *ProjectAcl) {
+	{
+		Members_l := len(value.Members)
+		encoder.Members_subencoder = make([]struct {
+		}, Members_l)
+		for i := 0; i < Members_l; i++ {
+			pseudoEncoder := &encoder.Members_subencoder[i]
+			pseudoValue := struct {
+				Members string
+			}{
+				Members: value.Members[i],
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+
+	l := uint(0)
+	if value.Members != nil {
+		for seq_i, seq_v := range value.Members {
+			pseudoEncoder := &encoder.Members_subencoder[seq_i]
+			pseudoValue := struct {
+				Members string
+			}{
+				Members: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				l += 3
+				l += uint(enc.TLNum(len(value.Members)).EncodingLength())
+				l += uint(len(value.Members))
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+	encoder.Length = l
+
+}
+
+func (context *ProjectAclParsingContext) Init() {
+
+}
+
+func (encoder *ProjectAclEncoder) EncodeInto(value *ProjectAcl, buf []byte) {
+
+	pos := uint(0)
+
+	if value.Members != nil {
+		for seq_i, seq_v := range value.Members {
+			pseudoEncoder := &encoder.Members_subencoder[seq_i]
+			pseudoValue := struct {
+				Members string
+			}{
+				Members: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				buf[pos] = 253
+				binary.BigEndian.PutUint16(buf[pos+1:], uint16(1474))
+				pos += 3
+				pos += uint(enc.TLNum(len(value.Members)).EncodeInto(buf[pos:]))
+				copy(buf[pos:], value.Members)
+				pos += uint(len(value.Members))
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+}
+
+func (encoder *ProjectAclEncoder) Encode(value *ProjectAcl) enc.Wire {
+
+	wire := make(enc.Wire, 1)
+	wire[0] = make([]byte, encoder.Length)
+	buf := wire[0]
+	encoder.EncodeInto(value, buf)
+
+	return wire
+}
+
+func (context *ProjectAclParsingContext) Parse(reader enc.WireView, ignoreCritical bool) (*ProjectAcl, error) {
+
+	var handled_Members bool = false
+
+	progress := -1
+	_ = progress
+
+	value := &ProjectAcl{}
+	var err error
+	var startPos int
+	for {
+		startPos = reader.Pos()
+		if startPos >= reader.Length() {
+			break
+		}
+		typ := enc.TLNum(0)
+		l := enc.TLNum(0)
+		typ, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+		l, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+
+		err = nil
+		if handled := false; true {
+			switch typ {
+			case 1474:
+				if true {
+					handled = true
+					handled_Members = true
+					if value.Members == nil {
+						value.Members = make([]string, 0)
+					}
+					{
+						pseudoValue := struct {
+							Members string
+						}{}
+						{
+							value := &pseudoValue
+							{
+								var builder strings.Builder
+								_, err = reader.CopyN(&builder, int(l))
+								if err == nil {
+									value.Members = builder.String()
+								}
+							}
+							_ = value
+						}
+						value.Members = append(value.Members, pseudoValue.Members)
+					}
+					progress--
+				}
+			default:
+				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
+					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
+				}
+				handled = true
+				err = reader.Skip(int(l))
+			}
+			if err == nil && !handled {
+			}
+			if err != nil {
+				return nil, enc.ErrFailToParse{TypeNum: typ, Err: err}
+			}
+		}
+	}
+
+	startPos = reader.Pos()
+	err = nil
+
+	if !handled_Members && err == nil {
+		// sequence - skip
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (value *ProjectAcl) Encode() enc.Wire {
+	encoder := ProjectAclEncoder{}
+	encoder.Init(value)
+	return encoder.Encode(value)
+}
+
+func (value *ProjectAcl) Bytes() []byte {
+	return value.Encode().Join()
+}
+
+func ParseProjectAcl(reader enc.WireView, ignoreCritical bool) (*ProjectAcl, error) {
+	context := ProjectAclParsingContext{}
+	context.Init()
+	return context.Parse(reader, ignoreCritical)
+}
+
+type RevocationListEncoder struct {
+	Length uint
+
+	Members_subencoder []struct {
+	}
+}
+
+type RevocationListParsingContext struct {
+}
+
+func (encoder *RevocationListEncoder) Init(value *RevocationList) {
+	{
+		Members_l := len(value.Members)
+		encoder.Members_subencoder = make([]struct {
+		}, Members_l)
+		for i := 0; i < Members_l; i++ {
+			pseudoEncoder := &encoder.Members_subencoder[i]
+			pseudoValue := struct {
+				Members string
+			}{
+				Members: value.Members[i],
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+
+	l := uint(0)
+	if value.Members != nil {
+		for seq_i, seq_v := range value.Members {
+			pseudoEncoder := &encoder.Members_subencoder[seq_i]
+			pseudoValue := struct {
+				Members string
+			}{
+				Members: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				l += 3
+				l += uint(enc.TLNum(len(value.Members)).EncodingLength())
+				l += uint(len(value.Members))
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+	encoder.Length = l
+
+}
+
+func (context *RevocationListParsingContext) Init() {
+
+}
+
+func (encoder *RevocationListEncoder) EncodeInto(value *RevocationList, buf []byte) {
+
+	pos := uint(0)
+
+	if value.Members != nil {
+		for seq_i, seq_v := range value.Members {
+			pseudoEncoder := &encoder.Members_subencoder[seq_i]
+			pseudoValue := struct {
+				Members string
+			}{
+				Members: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				buf[pos] = 253
+				binary.BigEndian.PutUint16(buf[pos+1:], uint16(1490))
+				pos += 3
+				pos += uint(enc.TLNum(len(value.Members)).EncodeInto(buf[pos:]))
+				copy(buf[pos:], value.Members)
+				pos += uint(len(value.Members))
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+}
+
+func (encoder *RevocationListEncoder) Encode(value *RevocationList) enc.Wire {
+
+	wire := make(enc.Wire, 1)
+	wire[0] = make([]byte, encoder.Length)
+	buf := wire[0]
+	encoder.EncodeInto(value, buf)
+
+	return wire
+}
+
+func (context *RevocationListParsingContext) Parse(reader enc.WireView, ignoreCritical bool) (*RevocationList, error) {
+
+	var handled_Members bool = false
+
+	progress := -1
+	_ = progress
+
+	value := &RevocationList{}
+	var err error
+	var startPos int
+	for {
+		startPos = reader.Pos()
+		if startPos >= reader.Length() {
+			break
+		}
+		typ := enc.TLNum(0)
+		l := enc.TLNum(0)
+		typ, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+		l, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+
+		err = nil
+		if handled := false; true {
+			switch typ {
+			case 1490:
+				if true {
+					handled = true
+					handled_Members = true
+					if value.Members == nil {
+						value.Members = make([]string, 0)
+					}
+					{
+						pseudoValue := struct {
+							Members string
+						}{}
+						{
+							value := &pseudoValue
+							{
+								var builder strings.Builder
+								_, err = reader.CopyN(&builder, int(l))
+								if err == nil {
+									value.Members = builder.String()
+								}
+							}
+							_ = value
+						}
+						value.Members = append(value.Members, pseudoValue.Members)
+					}
+					progress--
+				}
+			default:
+				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
+					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
+				}
+				handled = true
+				err = reader.Skip(int(l))
+			}
+			if err == nil && !handled {
+			}
+			if err != nil {
+				return nil, enc.ErrFailToParse{TypeNum: typ, Err: err}
+			}
+		}
+	}
+
+	startPos = reader.Pos()
+	err = nil
+
+	if !handled_Members && err == nil {
+		// sequence - skip
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (value *RevocationList) Encode() enc.Wire {
+	encoder := RevocationListEncoder{}
+	encoder.Init(value)
+	return encoder.Encode(value)
+}
+
+func (value *RevocationList) Bytes() []byte {
+	return value.Encode().Join()
+}
+
+func ParseRevocationList(reader enc.WireView, ignoreCritical bool) (*RevocationList, error) {
+	context := RevocationListParsingContext{}
+	context.Init()
+	return context.Parse(reader, ignoreCritical)
+}
+
+type BlobIndexEncoder struct {
+	Length uint
+
+	Blobs_subencoder []struct {
+		Blobs_encoder BlobIndexEntryEncoder
+	}
+}
+
+type BlobIndexParsingContext struct {
+	Blobs_context BlobIndexEntryParsingContext
+}
+
+func (encoder *BlobIndexEncoder) Init(value *BlobIndex) {
+	{
+		Blobs_l := len(value.Blobs)
+		encoder.Blobs_subencoder = make([]struct {
+			Blobs_encoder BlobIndexEntryEncoder
+		}, Blobs_l)
+		for i := 0; i < Blobs_l; i++ {
+			pseudoEncoder := &encoder.Blobs_subencoder[i]
+			pseudoValue := struct {
+				Blobs *BlobIndexEntry
+			}{
+				Blobs: value.Blobs[i],
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				if value.Blobs != nil {
+					encoder.Blobs_encoder.Init(value.Blobs)
+				}
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+
+	l := uint(0)
+	if value.Blobs != nil {
+		for seq_i, seq_v := range value.Blobs {
+			pseudoEncoder := &encoder.Blobs_subencoder[seq_i]
+			pseudoValue := struct {
+				Blobs *BlobIndexEntry
+			}{
+				Blobs: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				if value.Blobs != nil {
+					l += 3
+					l += uint(enc.TLNum(encoder.Blobs_encoder.Length).EncodingLength())
+					l += encoder.Blobs_encoder.Length
+				}
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+	encoder.Length = l
+
+}
+
+func (context *BlobIndexParsingContext) Init() {
+	context.Blobs_context.Init()
+}
+
+func (encoder *BlobIndexEncoder) EncodeInto(value *BlobIndex, buf []byte) {
+
+	pos := uint(0)
+
+	if value.Blobs != nil {
+		for seq_i, seq_v := range value.Blobs {
+			pseudoEncoder := &encoder.Blobs_subencoder[seq_i]
+			pseudoValue := struct {
+				Blobs *BlobIndexEntry
+			}{
+				Blobs: seq_v,
+			}
+			{
+				encoder := pseudoEncoder
+				value := &pseudoValue
+				if value.Blobs != nil {
+					buf[pos] = 253
+					binary.BigEndian.PutUint16(buf[pos+1:], uint16(1504))
+					pos += 3
+					pos += uint(enc.TLNum(encoder.Blobs_encoder.Length).EncodeInto(buf[pos:]))
+					if encoder.Blobs_encoder.Length > 0 {
+						encoder.Blobs_encoder.EncodeInto(value.Blobs, buf[pos:])
+						pos += encoder.Blobs_encoder.Length
+					}
+				}
+				_ = encoder
+				_ = value
+			}
+		}
+	}
+}
+
+func (encoder *BlobIndexEncoder) Encode(value *BlobIndex) enc.Wire {
+
+	wire := make(enc.Wire, 1)
+	wire[0] = make([]byte, encoder.Length)
+	buf := wire[0]
+	encoder.EncodeInto(value, buf)
+
+	return wire
+}
+
+func (context *BlobIndexParsingContext) Parse(reader enc.WireView, ignoreCritical bool) (*BlobIndex, error) {
+
+	var handled_Blobs bool = false
+
+	progress := -1
+	_ = progress
+
+	value := &BlobIndex{}
+	var err error
+	var startPos int
+	for {
+		startPos = reader.Pos()
+		if startPos >= reader.Length() {
+			break
+		}
+		typ := enc.TLNum(0)
+		l := enc.TLNum(0)
+		typ, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+		l, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+
+		err = nil
+		if handled := false; true {
+			switch typ {
+			case 1504:
+				if true {
+					handled = true
+					handled_Blobs = true
+					if value.Blobs == nil {
+						value.Blobs = make([]*BlobIndexEntry, 0)
+					}
+					{
+						pseudoValue := struct {
+							Blobs *BlobIndexEntry
+						}{}
+						{
+							value := &pseudoValue
+							value.Blobs, err = context.Blobs_context.Parse(reader.Delegate(int(l)), ignoreCritical)
+							_ = value
+						}
+						value.Blobs = append(value.Blobs, pseudoValue.Blobs)
+					}
+					progress--
+				}
+			default:
+				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
+					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
+				}
+				handled = true
+				err = reader.Skip(int(l))
+			}
+			if err == nil && !handled {
+			}
+			if err != nil {
+				return nil, enc.ErrFailToParse{TypeNum: typ, Err: err}
+			}
+		}
+	}
+
+	startPos = reader.Pos()
+	err = nil
+
+	if !handled_Blobs && err == nil {
+		// sequence - skip
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (value *BlobIndex) Encode() enc.Wire {
+	encoder := BlobIndexEncoder{}
+	encoder.Init(value)
+	return encoder.Encode(value)
+}
+
+func (value *BlobIndex) Bytes() []byte {
+	return value.Encode().Join()
+}
+
+func ParseBlobIndex(reader enc.WireView, ignoreCritical bool) (*BlobIndex, error) {
+	context := BlobIndexParsingContext{}
+	context.Init()
+	return context.Parse(reader, ignoreCritical)
+}
+
+type BlobIndexEntryEncoder struct {
+	Length uint
+}
+
+type BlobIndexEntryParsingContext struct {
+}
+
+func (encoder *BlobIndexEntryEncoder) Init(value *BlobIndexEntry) {
+
+	l := uint(0)
+	l += 3
+	l += uint(enc.TLNum(len(value.Name)).EncodingLength())
+	l += uint(len(value.Name))
+	if optval, ok := value.Unreferenced.Get(); ok {
+		l += 3
+		l += uint(1 + enc.Nat(optval).EncodingLength())
+	}
+	encoder.Length = l
+
+}
+
+func (context *BlobIndexEntryParsingContext) Init() {
+
+}
+
+func (encoder *BlobIndexEntryEncoder) EncodeInto(value *BlobIndexEntry, buf []byte) {
+
+	pos := uint(0)
+
+	buf[pos] = 253
+	binary.BigEndian.PutUint16(buf[pos+1:], uint16(1506))
+	pos += 3
+	pos += uint(enc.TLNum(len(value.Name)).EncodeInto(buf[pos:]))
+	copy(buf[pos:], value.Name)
+	pos += uint(len(value.Name))
+	if optval, ok := value.Unreferenced.Get(); ok {
+		buf[pos] = 253
+		binary.BigEndian.PutUint16(buf[pos+1:], uint16(1508))
+		pos += 3
+
+		buf[pos] = byte(enc.Nat(optval).EncodeInto(buf[pos+1:]))
+		pos += uint(1 + buf[pos])
+
+	}
+}
+
+func (encoder *BlobIndexEntryEncoder) Encode(value *BlobIndexEntry) enc.Wire {
+
+	wire := make(enc.Wire, 1)
+	wire[0] = make([]byte, encoder.Length)
+	buf := wire[0]
+	encoder.EncodeInto(value, buf)
+
+	return wire
+}
+
+func (context *BlobIndexEntryParsingContext) Parse(reader enc.WireView, ignoreCritical bool) (*BlobIndexEntry, error) {
+
+	var handled_Name bool = false
+	var handled_Unreferenced bool = false
+
+	progress := -1
+	_ = progress
+
+	value := &BlobIndexEntry{}
+	var err error
+	var startPos int
+	for {
+		startPos = reader.Pos()
+		if startPos >= reader.Length() {
+			break
+		}
+		typ := enc.TLNum(0)
+		l := enc.TLNum(0)
+		typ, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+		l, err = reader.ReadTLNum()
+		if err != nil {
+			return nil, enc.ErrFailToParse{TypeNum: 0, Err: err}
+		}
+
+		err = nil
+		if handled := false; true {
+			switch typ {
+			case 1506:
+				if true {
+					handled = true
+					handled_Name = true
+					{
+						var builder strings.Builder
+						_, err = reader.CopyN(&builder, int(l))
+						if err == nil {
+							value.Name = builder.String()
+						}
+					}
+				}
+			case 1508:
+				if true {
+					handled = true
+					handled_Unreferenced = true
+					{
+						optval := uint64(0)
+						optval = uint64(0)
+						{
+							for i := 0; i < int(l); i++ {
+								x := byte(0)
+								x, err = reader.ReadByte()
+								if err != nil {
+									if err == io.EOF {
+										err = io.ErrUnexpectedEOF
+									}
+									break
+								}
+								optval = uint64(optval<<8) | uint64(x)
+							}
+						}
+						value.Unreferenced.Set(optval)
+					}
+				}
+			default:
+				if !ignoreCritical && ((typ <= 31) || ((typ & 1) == 1)) {
+					return nil, enc.ErrUnrecognizedField{TypeNum: typ}
+				}
+				handled = true
+				err = reader.Skip(int(l))
+			}
+			if err == nil && !handled {
+			}
+			if err != nil {
+				return nil, enc.ErrFailToParse{TypeNum: typ, Err: err}
+			}
+		}
+	}
+
+	startPos = reader.Pos()
+	err = nil
+
+	if !handled_Name && err == nil {
+		err = enc.ErrSkipRequired{Name: "Name", TypeNum: 1506}
+	}
+	if !handled_Unreferenced && err == nil {
+		value.Unreferenced.Unset()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (value *BlobIndexEntry) Encode() enc.Wire {
+	encoder := BlobIndexEntryEncoder{}
+	encoder.Init(value)
+	return encoder.Encode(value)
+}
+
+func (value *BlobIndexEntry) Bytes() []byte {
+	return value.Encode().Join()
+}
+
+func ParseBlobIndexEntry(reader enc.WireView, ignoreCritical bool) (*BlobIndexEntry, error) {
+	context := BlobIndexEntryParsingContext{}
+	context.Init()
+	return context.Parse(reader, ignoreCritical)
+}
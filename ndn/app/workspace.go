@@ -1,20 +1,14 @@
-//go:build js && wasm
-
 package app
 
 import (
-	"crypto/aes"
-	"crypto/ecdh"
 	"crypto/elliptic"
-	"crypto/rand"
 	_ "embed"
-	"encoding/hex"
+	"errors"
 	"fmt"
-	math_rand "math/rand/v2"
-	"syscall/js"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	spec_repo "github.com/named-data/ndnd/repo/tlv"
 	enc "github.com/named-data/ndnd/std/encoding"
 	"github.com/named-data/ndnd/std/log"
 	"github.com/named-data/ndnd/std/ndn"
@@ -26,25 +20,19 @@ import (
 	"github.com/named-data/ndnd/std/security/trust_schema"
 	ndn_sync "github.com/named-data/ndnd/std/sync"
 	"github.com/named-data/ndnd/std/types/optional"
-	jsutil "github.com/named-data/ndnd/std/utils/js"
 	"github.com/pulsejet/ownly/ndn/app/tlv"
 )
 
 // TODO: find optimal value
 const SnapshotThreshold = 100
 
-// TODO: change this
-var repoName, _ = enc.NameFromStr("/ndnd/ucla/repo")
-
-// TODO: this is testbed configuration
-var multicastPrefix, _ = enc.NameFromStr("/ndn/multicast")
-
 //go:embed schema.tlv
 var SchemaBytes []byte
 
 // JoinWorkspace joins the workspace with the given name.
-// If the workspace does not exist, it will be created if create is true.
-func (a *App) JoinWorkspace(wkspStr_ string, create bool) (wkspStr string, err error) {
+// If the workspace does not exist, it will be created if create is true,
+// with label as the display name in the workspace metadata.
+func (a *App) JoinWorkspace(wkspStr_ string, create bool, label string) (wkspStr string, err error) {
 	wkspName, err := enc.NameFromStr(wkspStr_)
 	if err != nil {
 		return
@@ -56,11 +44,28 @@ func (a *App) JoinWorkspace(wkspStr_ string, create bool) (wkspStr string, err e
 		return
 	}
 
-	// TODO: fetch workspace "metadata" and check for existence
-	// If not existing, check the create flag and proceed
+	// Check that the workspace exists before asking for an invitation.
+	// Workspaces created before the metadata was introduced have none until
+	// their owner opens them again, so the owners are still asked for an
+	// invitation, but only briefly since the name may be mistyped.
+	// Metadata that is invalid or too new cannot be joined.
+	retries := inviteRetries
+	if !create {
+		info, metaErr := a.fetchWorkspaceMeta(wkspName)
+		switch {
+		case errors.Is(metaErr, errNoMeta):
+			log.Warn(a, "No workspace metadata, fetching the invitation", "name", wkspStr, "err", metaErr)
+			retries = inviteRetriesNoMeta
+		case metaErr != nil:
+			err = metaErr
+			return
+		default:
+			log.Info(a, "Found workspace", "name", wkspStr, "label", info.Label, "owner", info.Owner)
+		}
+	}
 
 	// Get a valid identity key to sign the certificate
-	idSigner, _ := a.GetTestbedKey()
+	idSigner, _ := a.workspaceIdKey(wkspName)
 	if idSigner == nil {
 		err = fmt.Errorf("no identity key found")
 		return
@@ -78,61 +83,9 @@ func (a *App) JoinWorkspace(wkspStr_ string, create bool) (wkspStr string, err e
 		}
 
 		// Other namespace - check for invitation
-		inviteName := wkspName.
-			Append(enc.NewGenericComponent("root")).
-			Append(enc.NewKeywordComponent("INVITE")).
-			Append(idName...)
-
-		// accessRequestPrefix, _ := enc.NameFromStr("/ndn/multicast" + wkspStr) // Uncomment if you want to use multicast
-		accessRequestPrefix, _ := enc.NameFromStr(wkspStr)
-
-		// Name to request access from workspace initiator
-		accessRequestName := accessRequestPrefix.
-			Append(enc.NewGenericComponent("root")).
-			Append(enc.NewKeywordComponent("INVITE")).
-			Append(idName...)
-
-		// Fetch the invitation from the repo
-		log.Info(a, "Fetching workspace invite from repo", "name", inviteName)
-		ch := make(chan ndn.ExpressCallbackArgs)
-		object.ExpressR(a.engine, ndn.ExpressRArgs{
-			Name: inviteName,
-			Config: &ndn.InterestConfig{
-				MustBeFresh:    true,
-				CanBePrefix:    true,
-				ForwardingHint: []enc.Name{repoName},
-			},
-			Retries:  1,
-			Callback: func(args ndn.ExpressCallbackArgs) { ch <- args },
-		})
-		args := <-ch
-		if args.Result != ndn.InterestResultData {
-			// If the invite is not found, request access from the workspace initiator
-			log.Info(a, "Fetching workspace invite from initiator", "name", inviteName)
-			ch2 := make(chan ndn.ExpressCallbackArgs)
-			object.ExpressR(a.engine, ndn.ExpressRArgs{
-				Name: accessRequestName,
-				Config: &ndn.InterestConfig{
-					MustBeFresh: true,
-					CanBePrefix: true,
-				},
-				Retries:  20,
-				Callback: func(args ndn.ExpressCallbackArgs) { ch2 <- args },
-			})
-			args = <-ch2
-
-			if args.Result != ndn.InterestResultData {
-				// Failed if both attempts do not return data
-				err = fmt.Errorf("failed to get invitation, make sure %s is invited to %s (%s)",
-					idName, wkspName, args.Result)
-				return
-			}
+		if invitation, err = a.fetchInvitation(wkspName, idName, time.Time{}, retries); err != nil {
+			return
 		}
-
-		// TODO: validate the invitation itself
-		invitation = args.RawData
-
-		log.Info(a, "Got workspace invitation", "name", wkspStr, "invite", args.Data.Name())
 	} else {
 		log.Info(a, "Joining workspace in own namespace", "name", wkspStr)
 	}
@@ -140,6 +93,14 @@ func (a *App) JoinWorkspace(wkspStr_ string, create bool) (wkspStr string, err e
 	err = a.SignWorkspaceCert(wkspName, idName, idSigner, invitation)
 	if err != nil {
 		log.Error(a, "Failed to sign workspace certificate")
+		return
+	}
+
+	// Publish the metadata of a new workspace (signed with the new certificate)
+	if create {
+		if wire, _ := a.store.Get(metaPrefix(wkspName), true); wire == nil {
+			err = a.publishWorkspaceMeta(wkspName, idName, label)
+		}
 	}
 	return
 }
@@ -151,80 +112,87 @@ func (a *App) IsWorkspaceOwner(wkspStr string) (bool, error) {
 		return false, err
 	}
 
-	idKey, _ := a.GetTestbedKey()
+	idKey, _ := a.workspaceIdKey(wkspName)
 	if idKey == nil {
-		return false, fmt.Errorf("no testbed key")
+		return false, fmt.Errorf("no identity key found")
 	}
 
-	// Currently this only checks if the workspace is in the identity namespace, but in the
-	// future it should check for actual delegation (valid signer)
-	// We don't support any owner-level delegation yet.
+	// The workspace creator is the root of all delegations
 	idName := idKey.KeyName().Prefix(-2)
-	return idName.IsPrefix(wkspName), nil
-}
-
-// onAccessRequest handles incoming access requests if the user is owner of the workspace.
-func (a *App) onAccessRequest(args ndn.InterestHandlerArgs) {
-	interest := args.Interest
-
-	// Get list of access requests, add the new one if not a duplicate
-	access_requests := js.Global().Get("_access_requests")
-	name := interest.Name()
-	requester := ""
-	wksp := ""
-	for c := 0; c < name.EncodingLength(); c++ {
-		if name[c].Equal(enc.NewKeywordComponent("INVITE")) {
-			requester = name[c+1:].String()
-			wksp = name[:c-1].String()
-			break
-		}
-	}
-
-	r := 0
-	duplicate := false
-
-	for r < access_requests.Length() {
-		if access_requests.Index(r).Index(0).Equal(js.ValueOf(wksp)) &&
-			access_requests.Index(r).Index(1).Equal(js.ValueOf(requester)) {
-
-			duplicate = true
-		}
-		r++
+	if idName.IsPrefix(wkspName) {
+		return true, nil
 	}
 
-	log.Info(nil, "Received access request", "requester", requester, "duplicate", duplicate)
-
-	if !duplicate {
-		wksp_data := append(make([]interface{}, 0), wksp, requester, false) // Creates an array of the wksp name and requester to pass to JS
-		access_requests.Call("push", js.ValueOf(wksp_data))
-
-		js.Global().Set("_access_requests", access_requests)
-
-		log.Info(nil, "Access requests:", "requests", access_requests)
-	}
+	// Co-owners need a key signed with a valid grant
+	return a.hasCoOwnerCert(wkspName, idName), nil
+}
 
+// Workspace is a handle to a joined workspace.
+type Workspace struct {
+	app    *App
+	group  enc.Name
+	idName enc.Name
+
+	userKey ndn.Signer
+	trust   *security.TrustConfig
+	client  ndn.Client
+	crypto  *WorkspaceCrypto
+	owner   bool
+	role    Role
+	// Commands sent to the repos of the workspace metadata
+	repoc *repoClient
+	// Network of the workspace
+	profile *NetworkProfile
+
+	// Running SVS instance of the root project, used for key requests
+	root atomic.Pointer[SvsAlo]
+	// Running SVS instances, which are replayed after reconnecting
+	alos sync.Map
+	// Cancels the connection state watcher
+	cancelConn func()
+	// Callback to persist fetched epoch keys
+	onEpochKey func(epoch uint64, dsk []byte)
+	// Callback to persist keys of private projects
+	onProjectKey func(proj string, key []byte)
+	// Member lists of projects, see projectAcl
+	acls sync.Map
+	// Pending access requests (owners only)
+	access *accessQueue
+	// Verified co-owners to the expiry of their grant
+	coOwners sync.Map
+	// Roles of other members, see memberRole
+	roles sync.Map
+	// Latest revocation list signed by the owners
+	revoked revocationList
+	// Serializes updates of the reference counts and indexes of blobs
+	blobMutex sync.Mutex
+	// Closed when the workspace is stopped
+	stop chan struct{}
+
+	ignoreValidity bool
 }
 
-// GetWorkspace returns a JS object representing the workspace with the given name.
-func (a *App) GetWorkspace(groupStr string, ignoreValidity bool) (api js.Value, err error) {
+// GetWorkspace returns a handle to the workspace with the given name.
+func (a *App) GetWorkspace(groupStr string, ignoreValidity bool) (wksp *Workspace, err error) {
 	group, err := enc.NameFromStr(groupStr)
 	if err != nil {
 		return
 	}
 
-	// Create trust configuration
-	trust, err := getTrustConfig(a.keychain)
+	// Create trust configuration with the anchor of the network
+	profile := a.workspaceProfile(group)
+	trust, err := getTrustConfig(a.keychain, []enc.Name{profile.anchorName})
 	if err != nil {
 		return
 	}
 
-	// Get identity key to use (same as testbed key)
-	idKey, _ := a.GetTestbedKey()
+	// Get identity key to use in the network of the workspace
+	idKey, _ := a.identityKey(profile)
 	if idKey == nil {
-		err = fmt.Errorf("no valid testbed key found")
+		err = fmt.Errorf("no valid identity key found for network %s", profile.Name)
 		return
 	}
-	// Use testbed key to sign NFD management commands
+	// Use identity key to sign NFD management commands
 	a.SetCmdKey(idKey)
 	idName := idKey.KeyName().Prefix(-2) // pop KeyId and KEY
 
@@ -239,11 +207,22 @@ func (a *App) GetWorkspace(groupStr string, ignoreValidity bool) (api js.Value,
 	}
 
 	// If [idKey ==> userKey] does not exist, resign
+	role := RoleEditor
 	certWire, _ := a.keychain.Store().Get(userKey.KeyName(), true)
 	if certWire != nil {
 		certData, _, err := spec.Spec{}.ReadData(enc.NewWireView(enc.Wire{certWire}))
+
+		// Our role is given by the invitation in the certificate
+		if err == nil && certData.CrossSchema() != nil {
+			var roleErr error
+			if role, roleErr = inviteRole(group, idName, certData.CrossSchema()); roleErr != nil {
+				log.Warn(a, "Failed to read role from invitation", "err", roleErr)
+			}
+		}
+
 		if err == nil && !idKey.KeyName().IsPrefix(certData.Signature().KeyName()) {
-			if err := a.SignWorkspaceCert(group, idName, idKey, nil); err != nil {
+			// Keep the invitation of the old certificate
+			if err := a.SignWorkspaceCert(group, idName, idKey, certData.CrossSchema()); err != nil {
 				log.Error(a, "Failed to resign workspace cert", "err", err)
 			}
 		}
@@ -254,218 +233,455 @@ func (a *App) GetWorkspace(groupStr string, ignoreValidity bool) (api js.Value,
 	// Create client object for this workspace
 	client := object.NewClient(a.engine, a.store, trust)
 
-	// Reset encryption keys
-	a.psk = nil
-	a.dsk = nil
-	a.aes = nil
-
-	// If owner, watch for access request interests
 	isOwner, err := a.IsWorkspaceOwner(groupStr)
 	if err != nil {
 		return
 	}
-	if isOwner {
-		// prefix, _ := enc.NameFromStr("/ndn/multicast" + groupStr) // Uncomment if you want to use multicast
-		prefix, _ := enc.NameFromStr(groupStr)
-		accessRequestPrefix := prefix.
-			Append(enc.NewGenericComponent("root")).
-			Append(enc.NewKeywordComponent("INVITE"))
-		a.engine.AttachHandler(accessRequestPrefix, a.onAccessRequest)
-		client.AnnouncePrefix(ndn.Announcement{
-			Name:    accessRequestPrefix,
+
+	wksp = &Workspace{
+		app:            a,
+		group:          group,
+		idName:         idName,
+		userKey:        userKey,
+		trust:          trust,
+		client:         client,
+		crypto:         newWorkspaceCrypto(),
+		owner:          isOwner,
+		role:           role,
+		profile:        profile,
+		access:         newAccessQueue(a.store, group),
+		ignoreValidity: ignoreValidity,
+	}
+	wksp.repoc = newRepoClient(wksp, a.workspaceRepos(group))
+	wksp.loadRevocations()
+	return wksp, nil
+}
+
+func (w *Workspace) String() string {
+	return "workspace"
+}
+
+// Name is the name of this user / node.
+func (w *Workspace) Name() enc.Name {
+	return w.idName
+}
+
+// Group is the overall prefix of the workspace.
+func (w *Workspace) Group() enc.Name {
+	return w.group
+}
+
+// Client is the object client used by this workspace.
+func (w *Workspace) Client() ndn.Client {
+	return w.client
+}
+
+// Crypto is the encryption context of this workspace.
+func (w *Workspace) Crypto() *WorkspaceCrypto {
+	return w.crypto
+}
+
+// SetEncryptKeys sets the pre-shared key and data-sharing key of the workspace.
+// epoch is the key epoch of the DSK, which is 0 if the key was never rotated.
+func (w *Workspace) SetEncryptKeys(psk []byte, dsk []byte, epoch uint64) error {
+	return w.crypto.SetKeys(psk, dsk, epoch)
+}
+
+// AddEpochKey adds a persisted data-sharing key of another epoch.
+func (w *Workspace) AddEpochKey(epoch uint64, dsk []byte) error {
+	return w.crypto.AddEpochKey(epoch, dsk)
+}
+
+// SetRemovedMembers sets the members that do not get keys of new epochs.
+func (w *Workspace) SetRemovedMembers(names []enc.Name) {
+	w.crypto.RemoveMembers(names)
+}
+
+// SetOnEpochKey sets the callback for keys of new epochs fetched from the owners.
+// The application should persist these keys along with the workspace keys.
+func (w *Workspace) SetOnEpochKey(callback func(epoch uint64, dsk []byte)) {
+	w.onEpochKey = callback
+}
+
+// SetProjectKey sets a persisted key of a private project.
+func (w *Workspace) SetProjectKey(proj string, key []byte) error {
+	return w.crypto.SetProjectKey(proj, key)
+}
+
+// SetOnProjectKey sets the callback for keys of private projects, which are
+// either made by us or fetched from other members of the project.
+// The application should persist these keys along with the workspace keys.
+func (w *Workspace) SetOnProjectKey(callback func(proj string, key []byte)) {
+	w.onProjectKey = callback
+}
+
+// Start starts the workspace client.
+// If we are owner, this also starts watching for access requests
+// and serves the co-owner grants and the workspace metadata.
+func (w *Workspace) Start() error {
+	if err := w.client.Start(); err != nil {
+		return err
+	}
+
+	// Renew the certificate in the background before it expires,
+	// and keep the revocation list up to date
+	w.stop = make(chan struct{})
+	go w.renewCert(w.stop)
+	go w.watchRevocations(w.stop)
+
+	if w.owner {
+		prefix := w.accessPrefix()
+		if err := w.client.Engine().AttachHandler(prefix, w.onAccessRequest); err != nil {
+			return err
+		}
+		w.client.AnnouncePrefix(ndn.Announcement{
+			Name:    prefix,
 			Expose:  true,
 			OnError: nil, // TODO
 		})
-		log.Info(nil, "Watching for access requests")
+		log.Info(w, "Watching for access requests")
+
+		// Requests may have arrived before the last restart
+		w.emitAccessRequests()
+
+		// Serve co-owner grants and keys, and the metadata if we are the creator
+		w.announceOwner()
+		if w.isCreator() {
+			go w.ensureMeta()
+		}
 	}
 
-	var workspaceJs map[string]any
-	workspaceJs = map[string]any{
-		// name: string;
-		"name": js.ValueOf(idName.String()), // wrong
+	// Peers on the LAN fetch blobs and certificates from each other
+	if w.app.lan {
+		if err := w.app.serveCached(w.group); err != nil {
+			log.Warn(w, "Failed to serve cached workspace data", "err", err)
+		}
+	}
 
-		// group: string;
-		"group": js.ValueOf(group.String()),
+	// Replay what the router may have lost when the app reconnects
+	w.cancelConn = w.app.WatchConnState(func(state ConnState, prev ConnState) {
+		if state == ConnUp && (prev == ConnDegraded || prev == ConnOffline) {
+			w.replay()
+		}
+	})
+	return nil
+}
 
-		// set_encrypt_keys(psk: Uint8Array, dsk: Uint8Array): Promise<void>;
-		"set_encrypt_keys": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			a.psk = jsutil.JsArrayToSlice(p[0])
-			a.dsk = jsutil.JsArrayToSlice(p[1])
-			if len(a.psk) == 0 || len(a.dsk) == 0 {
-				return nil, fmt.Errorf("invalid keys")
-			}
+// announceOwner announces the prefixes served by an owner.
+func (w *Workspace) announceOwner() {
+	for _, prefix := range w.ownerPrefixes() {
+		w.client.AnnouncePrefix(ndn.Announcement{
+			Name:    prefix,
+			Expose:  true,
+			OnError: nil, // TODO
+		})
+	}
+}
 
-			symKey, err := hkdfSha256(append(a.psk, a.dsk...))
-			if err != nil {
-				return nil, err
-			}
-			a.aes, err = aes.NewCipher(symKey)
-			if err != nil {
-				return nil, err
-			}
-			a.ivb = userKey.KeyName().Hash()
+// replay is called when the app is connected again after an outage.
+// A new face to the router starts without our routes, so after reconnecting:
+//   - the access request prefix and owner prefixes are announced (owners only)
+//   - the SVS prefixes of every running project are announced
+//   - the repo is sent SyncJoin for every running project
+//   - publications queued while offline are published in order,
+//     which also announces our latest SVS state to the group
+//
+// Sync Interests and fetches that failed during the outage are not replayed;
+// SVS recovers these with the next sync Interest of any member.
+func (w *Workspace) replay() {
+	log.Info(w, "Reconnected, replaying announcements")
+	if w.owner {
+		w.client.AnnouncePrefix(ndn.Announcement{
+			Name:    w.accessPrefix(),
+			Expose:  true,
+			OnError: nil, // TODO
+		})
+		w.announceOwner()
+	}
+	w.alos.Range(func(key, _ any) bool {
+		go key.(*SvsAlo).replay()
+		return true
+	})
+}
 
-			return nil, nil
-		}),
+// Stop stops the workspace client.
+func (w *Workspace) Stop() error {
+	if w.cancelConn != nil {
+		w.cancelConn()
+		w.cancelConn = nil
+	}
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+	if w.app.lan {
+		w.client.Engine().DetachHandler(w.group)
+	}
+	if w.owner {
+		prefix := w.accessPrefix()
+		w.client.WithdrawPrefix(prefix, nil)
+		w.client.Engine().DetachHandler(prefix)
+		for _, prefix := range w.ownerPrefixes() {
+			w.client.WithdrawPrefix(prefix, nil)
+		}
+	}
+	return w.client.Stop()
+}
 
-		// start(): Promise<void>;
-		"start": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			if err := client.Start(); err != nil {
-				return nil, err
-			}
+// Produce produces an NDN object under the given name.
+func (w *Workspace) Produce(name enc.Name, content enc.Wire) error {
+	_, err := w.client.Produce(ndn.ProduceArgs{
+		Name:    name,
+		Content: content,
+	})
+	return err
+}
 
-			return nil, nil
-		}),
+// Consume fetches an NDN object with the given name.
+// Returns the full name and content of the object.
+func (w *Workspace) Consume(name enc.Name) (enc.Name, enc.Wire, error) {
+	// Fetch the content from the network
+	ch := make(chan ndn.ConsumeState)
+	w.client.ConsumeExt(ndn.ConsumeExtArgs{
+		Name:           name,
+		TryStore:       true,
+		IgnoreValidity: optional.Some(w.ignoreValidity),
+		Callback:       func(state ndn.ConsumeState) { ch <- state },
+	})
+	state := <-ch
+	if err := state.Error(); err != nil {
+		return nil, nil, err
+	}
 
-		// stop(): Promise<void>;
-		"stop": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			if err := client.Stop(); err != nil {
-				return nil, err
-			}
+	return state.Name(), state.Content(), nil
+}
 
-			jsutil.ReleaseMap(workspaceJs)
-			return nil, nil
-		}),
+// SvsAlo creates a new SVS ALO instance for the given group.
+// persistState is called whenever the SVS state should be persisted.
+// Fails if the group is a private project we are not a member of.
+func (w *Workspace) SvsAlo(group enc.Name, state enc.Wire, persistState func(enc.Wire)) (*SvsAlo, error) {
+	proj := w.projectOf(group)
+	acl, err := w.projectAcl(proj)
+	if err != nil {
+		return nil, err
+	}
+	if !acl.allows(w.idName) && !w.owner {
+		return nil, fmt.Errorf("%s is not a member of the private project %s", w.idName, proj)
+	}
 
-		// produce(name: string, data: Uint8Array): Promise<void>;
-		"produce": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			name, err := enc.NameFromStr(p[0].String())
-			if err != nil {
-				return nil, err
-			}
+	// Create new SVS ALO instance
+	svsAlo, err := ndn_sync.NewSvsALO(ndn_sync.SvsAloOpts{
+		Name:         w.idName,
+		InitialState: state,
 
-			_, err = client.Produce(ndn.ProduceArgs{
-				Name:    name,
-				Content: enc.Wire{jsutil.JsArrayToSlice(p[1])},
-			})
+		Svs: ndn_sync.SvSyncOpts{
+			Client:         w.client,
+			GroupPrefix:    group,
+			IgnoreValidity: optional.Some(w.ignoreValidity),
+		},
 
-			return nil, err
-		}),
+		Snapshot: &ndn_sync.SnapshotNodeHistory{
+			Client:    w.client,
+			Threshold: SnapshotThreshold,
+			Compress: func(hs *svs_ps.HistorySnap) {
+				w.CompressSnapshotYjs(proj, hs)
+				// Snapshots only have our own publications
+				w.compressSnapshotChat(proj, w.idName, hs)
+			},
+			IgnoreValidity: optional.Some(w.ignoreValidity),
+		},
 
-		// consume(name: string): Promise<{ data: Uint8Array; name: string; }>;
-		"consume": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			name, err := enc.NameFromStr(p[0].String())
-			if err != nil {
-				return nil, err
-			}
+		MulticastPrefix: w.profile.MulticastPrefix,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			// Fetch the content from the network
-			ch := make(chan ndn.ConsumeState)
-			client.ConsumeExt(ndn.ConsumeExtArgs{
-				Name:           name,
-				TryStore:       true,
-				IgnoreValidity: optional.Some(ignoreValidity),
-				Callback:       func(state ndn.ConsumeState) { ch <- state },
-			})
-			state := <-ch
-			if err := state.Error(); err != nil {
-				return nil, err
-			}
+	return newSvsAlo(w, svsAlo, proj, acl, persistState), nil
+}
 
-			return js.ValueOf(map[string]any{
-				"data": jsutil.SliceToJsArray(state.Content().Join()),
-				"name": js.ValueOf(state.Name().String()),
-			}), nil
-		}),
-
-		// svs_alo(group: string, state: Uint8Array | undefined, persist_state: (state: Uint8Array) => Promise<void>): Promise<SvsAloApi>;
-		"svs_alo": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			svsAloGroup, err := enc.NameFromStr(p[0].String())
-			if err != nil {
-				return nil, err
-			}
+// SignInvitation signs an invitation to the workspace for the given invitee.
+// The workspace certificate of the invitee is limited to the invitation validity,
+// and the invitee gets the role in the options.
+func (w *Workspace) SignInvitation(invitee enc.Name, opts InviteOpts) (enc.Wire, error) {
+	notBefore, notAfter, err := opts.validity()
+	if err != nil {
+		return nil, err
+	}
 
-			// Parse initial state
-			var stateWire enc.Wire = nil
-			if !p[1].IsUndefined() {
-				stateWire = enc.Wire{jsutil.JsArrayToSlice(p[1])}
-			}
+	// Make the invitation name
+	// There is always a "root" project that manages the workspace,
+	// so we reuse that naming convention for the invitation.
+	// /<wksp>/root/32=INVITE/<invitee>/v=<time>
+	inviteName := invitePrefix(w.group, invitee).WithVersion(enc.VersionUnixMicro)
 
-			// Create new SVS ALO instance
-			svsAlo, err := ndn_sync.NewSvsALO(ndn_sync.SvsAloOpts{
-				Name:         idName,
-				InitialState: stateWire,
-
-				Svs: ndn_sync.SvSyncOpts{
-					Client:         client,
-					GroupPrefix:    svsAloGroup,
-					IgnoreValidity: optional.Some(ignoreValidity),
-				},
-
-				Snapshot: &ndn_sync.SnapshotNodeHistory{
-					Client:         client,
-					Threshold:      SnapshotThreshold,
-					Compress:       a.CompressSnapshotYjs,
-					IgnoreValidity: optional.Some(ignoreValidity),
-				},
-
-				MulticastPrefix: multicastPrefix,
-			})
-			if err != nil {
-				return nil, err
-			}
+	// Make sure we can make this invitation
+	signer := w.client.SuggestSigner(inviteName)
+	if signer == nil {
+		return nil, fmt.Errorf("no valid signing key")
+	}
 
-			// Create JS API for SVS ALO
-			return a.SvsAloJs(client, svsAlo, p[2])
-		}),
+	return trust_schema.SignCrossSchema(trust_schema.SignCrossSchemaArgs{
+		Name:   inviteName,
+		Signer: signer,
+		Content: trust_schema.CrossSchemaContent{
+			SimpleSchemaRules: inviteRules(w.group, invitee, opts.Role),
+		},
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+		Store:     w.client.Store(), // auto-store
+	})
+}
 
-		// sign_invitation(invitee: string): Promise<Uint8Array>;
-		"sign_invitation": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			invitee, err := enc.NameFromStr(p[0].String())
-			if err != nil {
-				return nil, err
-			}
+// WaitForDsk waits for a DSK response to the request made with the given X25519 key.
+// Returns the epoch and the data-sharing key.
+func (w *Workspace) WaitForDsk(priv []byte) (uint64, []byte, error) {
+	return w.fetchDsk("", priv)
+}
 
-			// Make the invitation name
-			// There is always a "root" project that manages the workspace,
-			// so we reuse that naming convention for the invitation.
-			// /<wksp>/root/32=INVITE/<invitee>/v=<time>
-			inviteName := group.
-				Append(enc.NewGenericComponent("root")).
-				Append(enc.NewKeywordComponent("INVITE")).
-				Append(invitee...).
-				WithVersion(enc.VersionUnixMicro)
-
-			// Make sure we can make this invitation
-			signer := client.SuggestSigner(inviteName)
-			if signer == nil {
-				return nil, fmt.Errorf("no valid signing key")
-			}
+// verifyUserCert checks that the member holds a valid certificate in the workspace.
+// The certificate must be valid now and match #user_cert of the trust schema,
+// and the member must not be revoked.
+func (w *Workspace) verifyUserCert(member enc.Name) error {
+	keyPrefix := w.group.Append(member...).Append(enc.NewGenericComponent("KEY"))
+
+	ch := make(chan ndn.ExpressCallbackArgs, 1)
+	w.client.ExpressR(ndn.ExpressRArgs{
+		Name: keyPrefix,
+		Config: &ndn.InterestConfig{
+			CanBePrefix: true,
+			Lifetime:    optional.Some(2 * time.Second),
+		},
+		Retries:  2,
+		TryStore: w.client.Store(),
+		Callback: func(args ndn.ExpressCallbackArgs) { ch <- args },
+	})
+	args := <-ch
+	if args.Error != nil {
+		return args.Error
+	}
+	if args.Result != ndn.InterestResultData {
+		return fmt.Errorf("no certificate found: %s", args.Result)
+	}
 
-			wire, err := trust_schema.SignCrossSchema(trust_schema.SignCrossSchemaArgs{
-				Name:   inviteName,
-				Signer: signer,
-				Content: trust_schema.CrossSchemaContent{
-					SimpleSchemaRules: []*trust_schema.SimpleSchemaRule{{
-						// Authorize invitee's identity key to sign data
-						NamePrefix: group.Append(invitee...),
-						KeyLocator: &spec.KeyLocator{
-							Name: invitee.Append(enc.NewGenericComponent("KEY")),
-						},
-					}},
-				},
-				NotBefore: time.Now().Add(-time.Hour),
-				NotAfter:  time.Now().AddDate(50, 0, 0), // for now
-				Store:     client.Store(),               // auto-store
-			})
-			if err != nil {
-				return nil, err
-			}
+	cert := args.Data
+	if !keyPrefix.IsPrefix(cert.Name()) {
+		return fmt.Errorf("unexpected certificate name: %s", cert.Name())
+	}
 
-			return jsutil.SliceToJsArray(wire.Join()), nil
-		}),
+	if !w.ignoreValidity {
+		now := time.Now()
+		notBefore, notAfter := cert.Signature().Validity()
+		if val, ok := notBefore.Get(); !ok || now.Before(val) {
+			return fmt.Errorf("certificate is not yet valid")
+		}
+		if val, ok := notAfter.Get(); !ok || now.After(val) {
+			return fmt.Errorf("certificate has expired")
+		}
+	}
 
-		// wait_for_dsk(key: Uint8Array): Promise<Uint8Array>;
-		"wait_for_dsk": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			dsk, err := a.fetchDsk(client, group, jsutil.JsArrayToSlice(p[0]))
-			if err != nil {
-				return nil, err
-			}
-			return jsutil.SliceToJsArray(dsk), nil
-		}),
+	return w.validate(cert, args.SigCovered)
+}
+
+// isCreator returns true if we created the workspace.
+func (w *Workspace) isCreator() bool {
+	return w.idName.IsPrefix(w.group)
+}
+
+// ownerPrefixes are the prefixes served by an owner.
+func (w *Workspace) ownerPrefixes() []enc.Name {
+	prefixes := []enc.Name{
+		coOwnerPrefix(w.group, nil),
+		projectAclPrefix(w.group, ""),
+		revokePrefix(w.group),
+	}
+	if w.isCreator() {
+		prefixes = append(prefixes, metaPrefix(w.group))
+	}
+	return prefixes
+}
+
+// isOwnerName returns true if the member with the given name is an owner.
+// Co-owners are checked by fetching their grant, so this may block.
+func (w *Workspace) isOwnerName(name enc.Name) bool {
+	if name.IsPrefix(w.group) {
+		return true
+	}
+	if w.isRevoked(name) {
+		return false
+	}
+
+	key := name.String()
+	if expiry, ok := w.coOwners.Load(key); ok && time.Now().Before(expiry.(time.Time)) {
+		return true
+	}
+	notAfter, err := w.app.fetchCoOwnerGrant(w.group, name)
+	if err != nil {
+		log.Debug(w, "Not a co-owner", "name", name, "err", err)
+		return false
+	}
+	w.coOwners.Store(key, notAfter)
+	return true
+}
+
+// fetchEpochKey requests the key of an epoch from the owners in the background.
+// This needs the root project to be running, since requests are made there.
+func (w *Workspace) fetchEpochKey(epoch uint64) {
+	root := w.root.Load()
+	if root == nil || !w.crypto.startEpochReq(epoch) {
+		return
 	}
 
-	return js.ValueOf(workspaceJs), nil
+	go func() {
+		defer w.crypto.endEpochReq(epoch)
+
+		resEpoch, dsk, priv, err := w.requestKey(root, &tlv.DSKRequest{
+			Epoch: optional.Some(epoch),
+		})
+		if err != nil {
+			log.Error(w, "Failed to get epoch key", "epoch", epoch, "err", err)
+			return
+		}
+		if resEpoch != epoch {
+			log.Warn(w, "Got key of wrong epoch", "epoch", epoch, "got", resEpoch)
+			return
+		}
+
+		if err := w.crypto.AddEpochKey(epoch, dsk); err != nil {
+			log.Error(w, "Failed to add epoch key", "epoch", epoch, "err", err)
+			return
+		}
+		if w.onEpochKey != nil {
+			w.onEpochKey(epoch, dsk)
+		}
+		log.Info(w, "Got key of new epoch", "epoch", epoch)
+
+		if err := root.PubDskAck(priv); err != nil {
+			log.Warn(w, "Failed to acknowledge epoch key", "err", err)
+		}
+	}()
+}
+
+// requestKey publishes a key request in the root project and waits for the answer.
+// Returns the epoch and key in the answer, and the X25519 key of the request.
+func (w *Workspace) requestKey(root *SvsAlo, req *tlv.DSKRequest) (uint64, []byte, []byte, error) {
+	priv, err := root.pubDskRequest(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	// Owners may take a while to answer (or be offline)
+	for i := 0; i < 3; i++ {
+		var epoch uint64
+		var dsk []byte
+		epoch, dsk, err = w.fetchDsk(req.Project.GetOr(""), priv)
+		if err == nil {
+			return epoch, dsk, priv, nil
+		}
+		log.Warn(w, "Failed to fetch key", "err", err)
+		time.Sleep(5 * time.Second)
+	}
+	return 0, nil, nil, err
 }
 
 func (a *App) SignWorkspaceCert(
@@ -474,8 +690,14 @@ func (a *App) SignWorkspaceCert(
 	idSigner ndn.Signer,
 	invitation enc.Wire,
 ) error {
+	return a.signDelegatedCert(wkspName.Append(idName...), idSigner, invitation)
+}
+
+// signDelegatedCert generates a key for appIdName and signs its certificate
+// with the identity key. The CrossSchema authorizes the identity key, and
+// limits the validity of the certificate.
+func (a *App) signDelegatedCert(appIdName enc.Name, idSigner ndn.Signer, crossSchema enc.Wire) error {
 	// Generate key and certificate for this workspace
-	appIdName := wkspName.Append(idName...)
 	appIdKeyName := security.MakeKeyName(appIdName)
 	appIdSigner, err := sig.KeygenEcc(appIdKeyName, elliptic.P256())
 	if err != nil {
@@ -488,15 +710,34 @@ func (a *App) SignWorkspaceCert(
 		return err
 	}
 
+	// Certificate cannot be valid outside the invitation or grant
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := time.Now().AddDate(10, 0, 0) // for now
+	if crossSchema != nil {
+		csData, _, err := spec.Spec{}.ReadData(enc.NewWireView(crossSchema))
+		if err != nil {
+			return fmt.Errorf("malformed cross schema: %w", err)
+		}
+		csNotBefore, csNotAfter, err := dataValidity(csData)
+		if err != nil {
+			return err
+		}
+		if csNotBefore.After(notBefore) {
+			notBefore = csNotBefore
+		}
+		if csNotAfter.Before(notAfter) {
+			notAfter = csNotAfter
+		}
+	}
+
 	// Create certificate for this workspace
-	// TODO: limit validity to same as invite validity
 	appIdCert, err := security.SignCert(security.SignCertArgs{
 		Data:        appIdSecret,
 		Signer:      idSigner,
 		IssuerId:    enc.NewGenericComponent("self"),
-		NotBefore:   time.Now().Add(-time.Hour),
-		NotAfter:    time.Now().AddDate(10, 0, 0), // for now
-		CrossSchema: invitation,
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		CrossSchema: crossSchema,
 	})
 	if err != nil {
 		return err
@@ -511,374 +752,3 @@ func (a *App) SignWorkspaceCert(
 	}
 	return nil
 }
-
-func (a *App) SvsAloJs(
-	client ndn.Client,
-	alo *ndn_sync.SvsALO,
-	persistState js.Value,
-) (api js.Value, err error) {
-	// List of SVS routes to announce
-	routes := []enc.Name{
-		alo.SyncPrefix(),
-		alo.DataPrefix(),
-	}
-
-	// Wrap the SVS ALO instance in a JS API
-	var svsAloJs map[string]any
-	svsAloJs = map[string]any{
-		"sync_prefix": js.ValueOf(alo.SyncPrefix().String()),
-		"data_prefix": js.ValueOf(alo.DataPrefix().String()),
-
-		// start(): Promise<void>;
-		"start": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			// Announce prefixes to the network
-			for _, route := range routes {
-				client.AnnouncePrefix(ndn.Announcement{
-					Name:    route,
-					Expose:  true,
-					OnError: nil, // TODO
-				})
-				log.Info(nil, "Announcing prefix", "name", "prefix", route)
-			}
-
-			// Notify repo to start
-			a.ExecWithConnectivity(func() {
-				a.NotifyRepo(client, alo.GroupPrefix(), alo.DataPrefix())
-			})
-
-			if err := alo.Start(); err != nil {
-				return nil, err
-			}
-
-			return nil, nil
-		}),
-
-		// stop(): Promise<void>;
-		"stop": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			if err := alo.Stop(); err != nil {
-				return nil, err
-			}
-
-			for _, route := range routes {
-				client.WithdrawPrefix(route, nil)
-			}
-
-			jsutil.ReleaseMap(svsAloJs)
-			return nil, nil
-		}),
-
-		// set_on_error(): void;
-		"set_on_error": js.FuncOf(func(this js.Value, p []js.Value) any {
-			alo.SetOnError(func(err error) {
-				p[0].Invoke(js.ValueOf(err.Error()))
-			})
-			return nil
-		}),
-
-		// names(): Promise<string[]>;
-		"names": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			names := alo.SVS().GetNames()
-
-			arr := js.Global().Get("Array").New()
-			for _, name := range names {
-				arr.Call("push", js.ValueOf(name.String()))
-			}
-
-			return arr, nil
-		}),
-
-		// pub_yjs_delta(binary: Uint8Array): Promise<void>;
-		"pub_yjs_delta": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			pub := &tlv.Message{
-				YjsDelta: &tlv.YjsDelta{
-					UUID:   p[0].String(),
-					Binary: jsutil.JsArrayToSlice(p[1]),
-				},
-			}
-
-			// Encrypt the publication
-			epub, err := a.encryptPub(pub, alo.SeqNo())
-			if err != nil {
-				return nil, err
-			}
-
-			name, state, err := alo.Publish(epub.Encode())
-			if err != nil {
-				return nil, err
-			}
-
-			// Persist state
-			jsutil.Await(persistState.Invoke(jsutil.SliceToJsArray(state.Join())))
-
-			return js.ValueOf(name.String()), nil
-		}),
-
-		// pub_blob_fetch(name: string, encapsulate: Uint8Array | undefined): Promise<string>;
-		"pub_blob_fetch": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			// This message is special, in the sense that it is purely intended for repo.
-			// So subscribers will never see this message.
-			cmd := spec_repo.RepoCmd{
-				BlobFetch: &spec_repo.BlobFetch{},
-			}
-			if !p[1].IsUndefined() { // encapsulate
-				// For now this only supports a single encapsulated Data
-				cmd.BlobFetch.Data = [][]byte{
-					jsutil.JsArrayToSlice(p[1]),
-				}
-			} else { // pointer only
-				blobName, err := enc.NameFromStr(p[0].String())
-				if err != nil {
-					return nil, err
-				}
-				cmd.BlobFetch.Name = &spec.NameContainer{Name: blobName}
-			}
-
-			blobName, state, err := alo.Publish(cmd.Encode())
-			if err != nil {
-				return nil, err
-			}
-
-			// Persist state
-			jsutil.Await(persistState.Invoke(jsutil.SliceToJsArray(state.Join())))
-
-			return js.ValueOf(blobName.String()), nil
-		}),
-
-		// pub_dsk_request(): Promise<Uint8Array>;
-		"pub_dsk_request": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			sk, err := ecdh.X25519().GenerateKey(rand.Reader)
-			if err != nil {
-				return nil, err
-			}
-			pub := &tlv.Message{
-				DSKRequest: &tlv.DSKRequest{
-					X25519Pub: sk.PublicKey().Bytes(),
-					Expiry:    uint64(time.Now().Add(24 * time.Hour).Unix()),
-				},
-			}
-			_, state, err := alo.Publish(pub.Encode())
-			if err != nil {
-				return nil, err
-			}
-
-			// Persist state
-			jsutil.Await(persistState.Invoke(jsutil.SliceToJsArray(state.Join())))
-
-			return jsutil.SliceToJsArray(sk.Bytes()), nil
-		}),
-
-		// pub_dsk_ack(key: Uint8Array): Promise<void>;
-		"pub_dsk_ack": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			sk, err := ecdh.X25519().NewPrivateKey(jsutil.JsArrayToSlice(p[0]))
-			if err != nil {
-				return nil, err
-			}
-			pub := &tlv.Message{
-				DSKACK: &tlv.DSKACK{
-					X25519Peer: sk.PublicKey().Bytes(),
-				},
-			}
-			_, state, err := alo.Publish(pub.Encode())
-			if err != nil {
-				return nil, err
-			}
-
-			// Persist state
-			jsutil.Await(persistState.Invoke(jsutil.SliceToJsArray(state.Join())))
-
-			return nil, nil
-		}),
-
-		// subscribe(name: string, { on_yjs_delta }): Promise<void>;
-		"subscribe": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			// Send a list of publications to the JS callback
-			sendPub := func(pubs []ndn_sync.SvsPub) {
-				yjsDeltas := js.Global().Get("Array").New()
-
-				for _, pub := range pubs {
-					pmsg, err := tlv.ParseMessage(enc.NewWireView(pub.Content), true)
-					if err != nil {
-						log.Error(nil, "Failed to parse publication", "err", err)
-						continue
-					}
-
-					pmsg, err = a.decryptPub(pmsg)
-					if err != nil {
-						log.Error(nil, "Failed to decrypt publication", "err", err)
-						continue
-					}
-
-					// All possible message type conversions listed here
-					switch {
-					case pmsg.YjsDelta != nil:
-						yjsDeltas.Call("push", js.ValueOf(map[string]any{
-							"uuid":   pmsg.YjsDelta.UUID,
-							"binary": jsutil.SliceToJsArray(pmsg.YjsDelta.Binary),
-						}))
-
-					case pmsg.DSKRequest != nil:
-						if pmsg.DSKRequest.Expiry < uint64(time.Now().Unix()) {
-							continue
-						}
-
-						pub := pmsg.DSKRequest.X25519Pub
-						if pub == nil {
-							log.Warn(nil, "DSK request missing X25519 public key")
-							continue
-						}
-
-						// Randomness for some crude suppression
-						suppress := time.Duration(1+math_rand.IntN(3)) * time.Second
-
-						pubHex := hex.EncodeToString(pub)
-						a.dskReqs[pubHex] = time.AfterFunc(suppress, func() {
-							delete(a.dskReqs, pubHex)
-
-							group := alo.GroupPrefix()
-							dskRes := a.processDskRequest(client, group, pub)
-							if dskRes == nil {
-								return
-							}
-							_, state, err := alo.Publish(dskRes)
-							if err != nil {
-								log.Error(nil, "Failed to publish DSK response", "err", err)
-							}
-							jsutil.Await(persistState.Invoke(jsutil.SliceToJsArray(state.Join())))
-						})
-
-					case pmsg.DSKACK != nil:
-						if pmsg.DSKACK.X25519Peer == nil {
-							log.Warn(nil, "DSK ACK missing X25519 public key")
-							continue
-						}
-
-						// Remove the request that matches this ACK
-						peerHex := hex.EncodeToString(pmsg.DSKACK.X25519Peer)
-						if timer, ok := a.dskReqs[peerHex]; ok {
-							timer.Stop()
-							delete(a.dskReqs, peerHex)
-						}
-
-					default:
-						// This will be logged even for BlobFetch commands, which is fine
-						// (can be fixed but avoid the extra parse that is unused)
-						// log.Warn(a, "Ignoring unknown message", "publisher", pub.Publisher)
-					}
-				}
-
-				if yjsDeltas.Get("length").Int() > 0 {
-					jsutil.Await(p[0].Get("on_yjs_delta").Invoke(yjsDeltas))
-				}
-			}
-
-			// Subscribe to the SVS instance
-			alo.SubscribePublisher(enc.Name{}, func(pub ndn_sync.SvsPub) {
-				if !pub.IsSnapshot {
-					sendPub([]ndn_sync.SvsPub{pub})
-				} else {
-					snapshot, err := svs_ps.ParseHistorySnap(enc.NewWireView(pub.Content), true)
-					if err != nil {
-						panic(err) // we encode this, so this never happens
-					}
-
-					pubs := make([]ndn_sync.SvsPub, 0, len(snapshot.Entries))
-					for _, entry := range snapshot.Entries {
-						pubs = append(pubs, ndn_sync.SvsPub{
-							Publisher: pub.Publisher,
-							Content:   entry.Content,
-							BootTime:  pub.BootTime,
-							SeqNum:    entry.SeqNo,
-						})
-					}
-					sendPub(pubs)
-				}
-
-				// Persist state
-				jsutil.Await(persistState.Invoke(jsutil.SliceToJsArray(pub.State.Join())))
-
-				return
-			})
-			return nil, nil
-		}),
-
-		// awareness(uuid: string): Promise<AwarenessApi>;
-		"awareness": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			// One awareness instance per document
-			suffix := enc.Name{
-				enc.NewKeywordComponent("aware"),
-				enc.NewGenericComponent(p[0].String()),
-			}
-
-			// Create new Awareness instance
-			return a.AwarenessJs(&Awareness{
-				Group:  alo.SyncPrefix().Append(suffix...),
-				Name:   alo.DataPrefix().Append(suffix...),
-				Client: client,
-			}), nil
-		}),
-	}
-	return js.ValueOf(svsAloJs), nil
-}
-
-func (a *App) AwarenessJs(awareness *Awareness) (api js.Value) {
-	// Create JS API for Awareness
-	var awarenessJs map[string]any
-	awarenessJs = map[string]any{
-		// start(): Promise<void>;
-		"start": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			err := awareness.Start()
-			return nil, err
-		}),
-
-		// stop(): Promise<void>;
-		"stop": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			if err := awareness.Stop(); err != nil {
-				return nil, err
-			}
-			jsutil.ReleaseMap(awarenessJs)
-			return nil, nil
-		}),
-
-		// publish(data: Uint8Array): Promise<void>;
-		"publish": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			return nil, awareness.Publish(enc.Wire{jsutil.JsArrayToSlice(p[0])})
-		}),
-
-		// subscribe(cb: (pub: Uint8Array) => void): Promise<void>;
-		"subscribe": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
-			awareness.OnData = func(wire enc.Wire) {
-				p[0].Invoke(jsutil.SliceToJsArray(wire.Join()))
-			}
-			return nil, nil
-		}),
-	}
-	return js.ValueOf(awarenessJs)
-}
-
-func (a *App) NotifyRepo(client ndn.Client, group enc.Name, dataPrefix enc.Name) {
-	// Wait for 1s so that routes get registered
-	time.Sleep(time.Second)
-
-	// Notify repo to join SVS group
-	repoCmd := spec_repo.RepoCmd{
-		SyncJoin: &spec_repo.SyncJoin{
-			Protocol: &spec.NameContainer{Name: spec_repo.SyncProtocolSvsV3},
-			Group:    &spec.NameContainer{Name: group},
-			HistorySnapshot: &spec_repo.HistorySnapshotConfig{
-				Threshold: SnapshotThreshold,
-			},
-			MulticastPrefix: &spec.NameContainer{Name: multicastPrefix},
-		},
-	}
-	client.ExpressCommand(
-		repoName,
-		dataPrefix.Append(enc.NewKeywordComponent("repo-cmd")),
-		repoCmd.Encode(),
-		func(w enc.Wire, err error) {
-			if err != nil {
-				log.Warn(nil, "Repo sync join command failed", "group", group, "err", err)
-			} else {
-				log.Info(nil, "Repo joined SVS group", "group", group)
-			}
-		})
-}
@@ -0,0 +1,779 @@
+//go:build js && wasm
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"syscall/js"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/types/optional"
+	jsutil "github.com/named-data/ndnd/std/utils/js"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+// JsApi returns a JS object representing the workspace.
+func (w *Workspace) JsApi() js.Value {
+	var workspaceJs map[string]any
+	workspaceJs = map[string]any{
+		// name: string;
+		"name": js.ValueOf(w.idName.String()), // wrong
+
+		// group: string;
+		"group": js.ValueOf(w.group.String()),
+
+		// role: 'editor' | 'commenter' | 'viewer';
+		"role": js.ValueOf(w.Role().String()),
+
+		// set_encrypt_keys(psk: Uint8Array, dsk: Uint8Array, epoch: number): Promise<void>;
+		"set_encrypt_keys": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			if len(p) < 3 || !jsIsBytes(p[0]) || !jsIsBytes(p[1]) || p[2].Type() != js.TypeNumber {
+				return nil, fmt.Errorf("set_encrypt_keys expects (psk: Uint8Array, dsk: Uint8Array, epoch: number)")
+			}
+			return nil, w.SetEncryptKeys(jsutil.JsArrayToSlice(p[0]), jsutil.JsArrayToSlice(p[1]), uint64(p[2].Int()))
+		}),
+
+		// add_epoch_key(epoch: number, dsk: Uint8Array): Promise<void>;
+		"add_epoch_key": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			if len(p) < 2 || p[0].Type() != js.TypeNumber || !jsIsBytes(p[1]) {
+				return nil, fmt.Errorf("add_epoch_key expects (epoch: number, dsk: Uint8Array)")
+			}
+			return nil, w.AddEpochKey(uint64(p[0].Int()), jsutil.JsArrayToSlice(p[1]))
+		}),
+
+		// set_removed_members(names: string[]): Promise<void>;
+		"set_removed_members": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			names, err := jsNameList(p[0])
+			if err != nil {
+				return nil, err
+			}
+			w.SetRemovedMembers(names)
+			return nil, nil
+		}),
+
+		// set_on_epoch_key(cb: (epoch: number, dsk: Uint8Array) => Promise<void>): void;
+		// The previous callback is dropped, and so is this one when the workspace stops.
+		"set_on_epoch_key": js.FuncOf(func(this js.Value, p []js.Value) any {
+			if len(p) == 0 || p[0].Type() != js.TypeFunction {
+				w.SetOnEpochKey(nil)
+				return nil
+			}
+			callback := p[0]
+			w.SetOnEpochKey(func(epoch uint64, dsk []byte) {
+				jsutil.Await(callback.Invoke(js.ValueOf(epoch), jsutil.SliceToJsArray(dsk)))
+			})
+			return nil
+		}),
+
+		// set_project_key(proj: string, key: Uint8Array): Promise<void>;
+		"set_project_key": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, w.SetProjectKey(p[0].String(), jsutil.JsArrayToSlice(p[1]))
+		}),
+
+		// set_on_project_key(cb: (proj: string, key: Uint8Array) => Promise<void>): void;
+		"set_on_project_key": js.FuncOf(func(this js.Value, p []js.Value) any {
+			if len(p) == 0 || p[0].Type() != js.TypeFunction {
+				w.SetOnProjectKey(nil)
+				return nil
+			}
+			callback := p[0]
+			w.SetOnProjectKey(func(proj string, key []byte) {
+				jsutil.Await(callback.Invoke(js.ValueOf(proj), jsutil.SliceToJsArray(key)))
+			})
+			return nil
+		}),
+
+		// set_project_members(proj: string, members: string[]): Promise<void>;
+		"set_project_members": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			members, err := jsNameList(p[1])
+			if err != nil {
+				return nil, err
+			}
+			return nil, w.SetProjectMembers(p[0].String(), members)
+		}),
+
+		// project_members(proj: string): Promise<string[] | null>;
+		"project_members": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			members, err := w.ProjectMembers(p[0].String())
+			if err != nil || members == nil {
+				return nil, err
+			}
+
+			arr := js.Global().Get("Array").New()
+			for _, member := range members {
+				arr.Call("push", js.ValueOf(member.String()))
+			}
+			return arr, nil
+		}),
+
+		// is_project_member(proj: string): Promise<boolean>;
+		"is_project_member": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return js.ValueOf(w.IsProjectMember(p[0].String())), nil
+		}),
+
+		// revoke_members(names: string[]): Promise<void>;
+		"revoke_members": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			members, err := jsNameList(p[0])
+			if err != nil {
+				return nil, err
+			}
+			return nil, w.RevokeMembers(members)
+		}),
+
+		// revoked_members(): Promise<string[]>;
+		"revoked_members": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			arr := js.Global().Get("Array").New()
+			for _, member := range w.RevokedMembers() {
+				arr.Call("push", js.ValueOf(member.String()))
+			}
+			return arr, nil
+		}),
+
+		// repos(): Promise<string[]>;
+		"repos": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return jsNames(w.Repos()), nil
+		}),
+
+		// set_repos(repos: string[]): Promise<void>;
+		"set_repos": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			repos, err := jsNameList(p[0])
+			if err != nil {
+				return nil, err
+			}
+			return nil, w.SetRepos(repos)
+		}),
+
+		// repo_status(proj: string): Promise<IRepoStatus[]>;
+		"repo_status": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			list, err := w.RepoStatus(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+			arr := js.Global().Get("Array").New()
+			for _, status := range list {
+				arr.Call("push", jsRepoStatus(status))
+			}
+			return arr, nil
+		}),
+
+		// start(): Promise<void>;
+		"start": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, w.Start()
+		}),
+
+		// stop(): Promise<void>;
+		"stop": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			if err := w.Stop(); err != nil {
+				return nil, err
+			}
+
+			// Do not call into JS after the functions are released
+			w.SetOnEpochKey(nil)
+			w.SetOnProjectKey(nil)
+			jsutil.ReleaseMap(workspaceJs)
+			return nil, nil
+		}),
+
+		// produce(name: string, data: Uint8Array): Promise<void>;
+		"produce": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			name, err := enc.NameFromStr(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+
+			return nil, w.Produce(name, enc.Wire{jsutil.JsArrayToSlice(p[1])})
+		}),
+
+		// consume(name: string): Promise<{ data: Uint8Array; name: string; }>;
+		"consume": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			name, err := enc.NameFromStr(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+
+			name, content, err := w.Consume(name)
+			if err != nil {
+				return nil, err
+			}
+
+			return js.ValueOf(map[string]any{
+				"data": jsutil.SliceToJsArray(content.Join()),
+				"name": js.ValueOf(name.String()),
+			}), nil
+		}),
+
+		// svs_alo(group: string, state: Uint8Array | undefined, persist_state: (state: Uint8Array) => Promise<void>): Promise<SvsAloApi>;
+		"svs_alo": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			svsAloGroup, err := enc.NameFromStr(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+
+			// Parse initial state
+			var stateWire enc.Wire = nil
+			if !p[1].IsUndefined() {
+				stateWire = enc.Wire{jsutil.JsArrayToSlice(p[1])}
+			}
+
+			persistState := p[2]
+			svsAlo, err := w.SvsAlo(svsAloGroup, stateWire, func(state enc.Wire) {
+				jsutil.Await(persistState.Invoke(jsutil.SliceToJsArray(state.Join())))
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			// Create JS API for SVS ALO
+			return svsAlo.JsApi(), nil
+		}),
+
+		// sign_invitation(invitee: string, opts?: InviteOpts): Promise<Uint8Array>;
+		"sign_invitation": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			invitee, err := enc.NameFromStr(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+
+			opts, err := jsInviteOpts(p, 1)
+			if err != nil {
+				return nil, err
+			}
+			wire, err := w.SignInvitation(invitee, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			return jsutil.SliceToJsArray(wire.Join()), nil
+		}),
+
+		// sign_coowner_grant(coowner: string, opts?: InviteOpts): Promise<Uint8Array>;
+		"sign_coowner_grant": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			coowner, err := enc.NameFromStr(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+
+			opts, err := jsInviteOpts(p, 1)
+			if err != nil {
+				return nil, err
+			}
+			wire, err := w.SignCoOwnerGrant(coowner, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			return jsutil.SliceToJsArray(wire.Join()), nil
+		}),
+
+		// list_access_requests(): Promise<{ requester: string, time: number }[]>;
+		"list_access_requests": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return jsAccessRequests(w.ListAccessRequests()), nil
+		}),
+
+		// approve_access_request(requester: string, opts?: InviteOpts): Promise<Uint8Array>;
+		"approve_access_request": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			requester, err := enc.NameFromStr(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+
+			opts, err := jsInviteOpts(p, 1)
+			if err != nil {
+				return nil, err
+			}
+			wire, err := w.ApproveAccessRequest(requester, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			return jsutil.SliceToJsArray(wire.Join()), nil
+		}),
+
+		// deny_access_request(requester: string): Promise<void>;
+		"deny_access_request": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			requester, err := enc.NameFromStr(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+			return nil, w.DenyAccessRequest(requester)
+		}),
+
+		// wait_for_dsk(key: Uint8Array): Promise<{ epoch: number; dsk: Uint8Array }>;
+		"wait_for_dsk": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			epoch, dsk, err := w.WaitForDsk(jsutil.JsArrayToSlice(p[0]))
+			if err != nil {
+				return nil, err
+			}
+			return js.ValueOf(map[string]any{
+				"epoch": js.ValueOf(epoch),
+				"dsk":   jsutil.SliceToJsArray(dsk),
+			}), nil
+		}),
+	}
+
+	return js.ValueOf(workspaceJs)
+}
+
+// JsApi returns a JS object wrapping the SVS ALO instance.
+func (s *SvsAlo) JsApi() js.Value {
+	var svsAloJs map[string]any
+	svsAloJs = map[string]any{
+		"sync_prefix": js.ValueOf(s.SyncPrefix().String()),
+		"data_prefix": js.ValueOf(s.DataPrefix().String()),
+
+		// start(): Promise<void>;
+		"start": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, s.Start()
+		}),
+
+		// stop(): Promise<void>;
+		"stop": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			if err := s.Stop(); err != nil {
+				return nil, err
+			}
+
+			jsutil.ReleaseMap(svsAloJs)
+			return nil, nil
+		}),
+
+		// set_on_error(): void;
+		"set_on_error": js.FuncOf(func(this js.Value, p []js.Value) any {
+			s.SetOnError(func(err error) {
+				p[0].Invoke(js.ValueOf(err.Error()))
+			})
+			return nil
+		}),
+
+		// names(): Promise<string[]>;
+		"names": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			arr := js.Global().Get("Array").New()
+			for _, name := range s.Names() {
+				arr.Call("push", js.ValueOf(name.String()))
+			}
+
+			return arr, nil
+		}),
+
+		// pub_yjs_delta(binary: Uint8Array): Promise<void>;
+		"pub_yjs_delta": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			name, err := s.PubYjsDelta(p[0].String(), jsutil.JsArrayToSlice(p[1]))
+			if err != nil {
+				return nil, err
+			}
+			if name == nil { // queued while offline
+				return nil, nil
+			}
+
+			return js.ValueOf(name.String()), nil
+		}),
+
+		// pub_chat(msg: IChatPub): Promise<string | undefined>;
+		"pub_chat": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			name, err := s.PubChat(jsChatMessage(p[0]))
+			if err != nil {
+				return nil, err
+			}
+			if name == nil { // queued while offline
+				return nil, nil
+			}
+
+			return js.ValueOf(name.String()), nil
+		}),
+
+		// pub_blob_fetch(name: string, encapsulate: Uint8Array | undefined): Promise<string>;
+		"pub_blob_fetch": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			var blobName enc.Name
+			var encapsulate []byte
+			if !p[1].IsUndefined() { // encapsulate
+				encapsulate = jsutil.JsArrayToSlice(p[1])
+			} else { // pointer only
+				var err error
+				if blobName, err = enc.NameFromStr(p[0].String()); err != nil {
+					return nil, err
+				}
+			}
+
+			name, err := s.PubBlobFetch(blobName, encapsulate)
+			if err != nil {
+				return nil, err
+			}
+			if name == nil { // queued while offline
+				return nil, nil
+			}
+
+			return js.ValueOf(name.String()), nil
+		}),
+
+		// publish_blob(uuid: string, size: number, read: (offset: number, length: number) => Promise<Uint8Array>, opts?: BlobPublishOpts): Promise<string>;
+		"publish_blob": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			r := &jsReaderAt{read: p[2]}
+			name, err := s.PublishBlob(p[0].String(), r, int64(p[1].Int()), jsBlobPublishOpts(p, 3))
+			if err != nil {
+				return nil, err
+			}
+			return js.ValueOf(name.String()), nil
+		}),
+
+		// release_blob(name: string): Promise<number>;
+		"release_blob": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			name, err := enc.NameFromStr(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+			return js.ValueOf(s.ReleaseBlob(name)), nil
+		}),
+
+		// gc_blobs(reachable: string[], opts?: BlobGcOpts): Promise<BlobGcResult>;
+		"gc_blobs": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			reachable, err := jsNameList(p[0])
+			if err != nil {
+				return nil, err
+			}
+			var opts BlobGcOpts
+			if len(p) > 1 && p[1].Type() == js.TypeObject {
+				if v := p[1].Get("grace"); v.Type() == js.TypeNumber {
+					opts.Grace = time.Duration(v.Int()) * time.Millisecond
+				}
+			}
+
+			res := s.GcBlobs(reachable, opts)
+			return js.ValueOf(map[string]any{
+				"kept":     res.Kept,
+				"expiring": res.Expiring,
+				"removed":  jsNames(res.Removed),
+			}), nil
+		}),
+
+		// remove_blob(name: string): Promise<void>;
+		"remove_blob": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			name, err := enc.NameFromStr(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+			return nil, s.RemoveBlob(name)
+		}),
+
+		// fetch_blob(name: string, write: (chunk: Uint8Array) => Promise<void>, opts?: BlobFetchOpts): Promise<void>;
+		"fetch_blob": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			name, err := enc.NameFromStr(p[0].String())
+			if err != nil {
+				return nil, err
+			}
+
+			opts, release := jsBlobFetchOpts(p, 2)
+			defer release()
+			return nil, s.FetchBlob(name, &jsWriter{write: p[1]}, opts)
+		}),
+
+		// pub_dsk_request(): Promise<Uint8Array>;
+		"pub_dsk_request": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			sk, err := s.PubDskRequest()
+			if err != nil {
+				return nil, err
+			}
+			return jsutil.SliceToJsArray(sk), nil
+		}),
+
+		// pub_dsk_ack(key: Uint8Array): Promise<void>;
+		"pub_dsk_ack": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, s.PubDskAck(jsutil.JsArrayToSlice(p[0]))
+		}),
+
+		// pub_dsk_rotate(remove: string[]): Promise<{ epoch: number; dsk: Uint8Array }>;
+		"pub_dsk_rotate": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			remove, err := jsNameList(p[0])
+			if err != nil {
+				return nil, err
+			}
+
+			epoch, dsk, err := s.PubDskRotate(remove)
+			if err != nil {
+				return nil, err
+			}
+			return js.ValueOf(map[string]any{
+				"epoch": js.ValueOf(epoch),
+				"dsk":   jsutil.SliceToJsArray(dsk),
+			}), nil
+		}),
+
+		// subscribe(name: string, { on_yjs_delta }): Promise<void>;
+		"subscribe": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			callbacks := p[0]
+			s.Subscribe(SvsAloSubscriber{
+				OnYjsDelta: func(deltas []*tlv.YjsDelta) {
+					yjsDeltas := js.Global().Get("Array").New()
+					for _, delta := range deltas {
+						yjsDeltas.Call("push", js.ValueOf(map[string]any{
+							"uuid":   delta.UUID,
+							"binary": jsutil.SliceToJsArray(delta.Binary),
+						}))
+					}
+					jsutil.Await(callbacks.Get("on_yjs_delta").Invoke(yjsDeltas))
+				},
+				OnChat: func(msgs []*tlv.ChatMessage) {
+					onChat := callbacks.Get("on_chat")
+					if onChat.Type() != js.TypeFunction {
+						return
+					}
+					chats := js.Global().Get("Array").New()
+					for _, msg := range msgs {
+						chats.Call("push", jsChatPub(msg))
+					}
+					jsutil.Await(onChat.Invoke(chats))
+				},
+			})
+			return nil, nil
+		}),
+
+		// awareness(uuid: string): Promise<AwarenessApi>;
+		"awareness": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			// Create new Awareness instance
+			return s.Awareness(p[0].String()).JsApi(), nil
+		}),
+	}
+	return js.ValueOf(svsAloJs)
+}
+
+// JsApi returns a JS object wrapping the Awareness instance.
+func (aw *Awareness) JsApi() js.Value {
+	var awarenessJs map[string]any
+	awarenessJs = map[string]any{
+		// start(): Promise<void>;
+		"start": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			err := aw.Start()
+			return nil, err
+		}),
+
+		// stop(): Promise<void>;
+		"stop": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			if err := aw.Stop(); err != nil {
+				return nil, err
+			}
+			jsutil.ReleaseMap(awarenessJs)
+			return nil, nil
+		}),
+
+		// publish(data: Uint8Array): Promise<void>;
+		"publish": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			return nil, aw.Publish(enc.Wire{jsutil.JsArrayToSlice(p[0])})
+		}),
+
+		// subscribe(cb: (pub: Uint8Array) => void): Promise<void>;
+		"subscribe": jsutil.AsyncFunc(func(this js.Value, p []js.Value) (any, error) {
+			aw.OnData = func(wire enc.Wire) {
+				p[0].Invoke(jsutil.SliceToJsArray(wire.Join()))
+			}
+			return nil, nil
+		}),
+	}
+	return js.ValueOf(awarenessJs)
+}
+
+// jsIsBytes returns true if the value is a Uint8Array.
+func jsIsBytes(v js.Value) bool {
+	return v.InstanceOf(js.Global().Get("Uint8Array"))
+}
+
+// jsNameList converts a JS array of strings to a list of names.
+func jsNameList(arr js.Value) ([]enc.Name, error) {
+	names := make([]enc.Name, 0, arr.Length())
+	for i := 0; i < arr.Length(); i++ {
+		name, err := enc.NameFromStr(arr.Index(i).String())
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// jsNames converts a list of names to a JS array of strings.
+func jsNames(names []enc.Name) js.Value {
+	arr := js.Global().Get("Array").New()
+	for _, name := range names {
+		arr.Call("push", js.ValueOf(name.String()))
+	}
+	return arr
+}
+
+// jsChatMessage reads a chat message from JS.
+// The author is set when publishing, so it is not read.
+func jsChatMessage(v js.Value) *tlv.ChatMessage {
+	msg := &tlv.ChatMessage{
+		Channel: v.Get("channel").String(),
+		ID:      v.Get("id").String(),
+		Body:    v.Get("body").String(),
+	}
+	if ts := v.Get("timestamp"); ts.Type() == js.TypeNumber {
+		msg.Timestamp = uint64(ts.Float())
+	}
+	if replyTo := v.Get("replyTo"); replyTo.Type() == js.TypeString {
+		msg.ReplyTo = optional.Some(replyTo.String())
+	}
+	if atts := v.Get("attachments"); atts.Type() == js.TypeObject {
+		for i := 0; i < atts.Length(); i++ {
+			att := atts.Index(i)
+			msg.Attachments = append(msg.Attachments, &tlv.ChatAttachment{
+				Name:     att.Get("name").String(),
+				Filename: att.Get("filename").String(),
+				Size:     uint64(att.Get("size").Float()),
+			})
+		}
+	}
+	return msg
+}
+
+// jsChatPub converts a received chat message to JS.
+func jsChatPub(msg *tlv.ChatMessage) js.Value {
+	atts := js.Global().Get("Array").New()
+	for _, att := range msg.Attachments {
+		atts.Call("push", js.ValueOf(map[string]any{
+			"name":     att.Name,
+			"filename": att.Filename,
+			"size":     att.Size,
+		}))
+	}
+	obj := map[string]any{
+		"channel":     msg.Channel,
+		"id":          msg.ID,
+		"author":      msg.Author,
+		"timestamp":   msg.Timestamp,
+		"body":        msg.Body,
+		"replyTo":     js.Undefined(),
+		"attachments": atts,
+	}
+	if replyTo, ok := msg.ReplyTo.Get(); ok {
+		obj["replyTo"] = replyTo
+	}
+	return js.ValueOf(obj)
+}
+
+// jsInviteOpts reads the optional invitation options at index i of the arguments.
+// The options are { notBefore?: number, notAfter?: number, role?: string },
+// with times in unix milliseconds.
+func jsInviteOpts(p []js.Value, i int) (opts InviteOpts, err error) {
+	if len(p) <= i || p[i].IsUndefined() || p[i].IsNull() {
+		return
+	}
+	if v := p[i].Get("notBefore"); v.Type() == js.TypeNumber {
+		opts.NotBefore = time.UnixMilli(int64(v.Float()))
+	}
+	if v := p[i].Get("notAfter"); v.Type() == js.TypeNumber {
+		opts.NotAfter = time.UnixMilli(int64(v.Float()))
+	}
+	if v := p[i].Get("role"); v.Type() == js.TypeString {
+		opts.Role, err = ParseRole(v.String())
+	}
+	return
+}
+
+// jsReaderAt reads a blob from a JS callback that returns the bytes at an
+// offset, which may be fewer than asked for at the end of the blob.
+type jsReaderAt struct {
+	read js.Value
+}
+
+func (r *jsReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	chunk, err := jsutil.Await(r.read.Invoke(js.ValueOf(off), js.ValueOf(len(p))))
+	if err != nil {
+		return 0, err
+	}
+	n := copy(p, jsutil.JsArrayToSlice(chunk))
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// jsWriter writes a stream to a JS callback chunk by chunk.
+type jsWriter struct {
+	write js.Value
+}
+
+func (w *jsWriter) Write(p []byte) (int, error) {
+	if _, err := jsutil.Await(w.write.Invoke(jsutil.SliceToJsArray(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// jsBlobProgress converts an optional progress callback.
+// The callback is (done: number, total: number) => void.
+func jsBlobProgress(callback js.Value) BlobProgress {
+	if callback.Type() != js.TypeFunction {
+		return nil
+	}
+	return func(done int64, total int64) {
+		callback.Invoke(js.ValueOf(done), js.ValueOf(total))
+	}
+}
+
+// jsBlobPublishOpts reads the optional blob publish options at index i of the arguments.
+// The options are { dedup?: boolean, progress?: BlobProgress }.
+func jsBlobPublishOpts(p []js.Value, i int) (opts BlobPublishOpts) {
+	if len(p) <= i || p[i].IsUndefined() || p[i].IsNull() {
+		return
+	}
+	opts.Dedup = p[i].Get("dedup").Truthy()
+	opts.Progress = jsBlobProgress(p[i].Get("progress"))
+	return
+}
+
+// jsBlobFetchOpts reads the optional blob fetch options at index i of the arguments.
+// The options are { window?: number, progress?: BlobProgress, signal?: AbortSignal }.
+// The returned function releases the abort listener.
+func jsBlobFetchOpts(p []js.Value, i int) (opts BlobFetchOpts, release func()) {
+	release = func() {}
+	if len(p) <= i || p[i].IsUndefined() || p[i].IsNull() {
+		return
+	}
+	if v := p[i].Get("window"); v.Type() == js.TypeNumber {
+		opts.Window = v.Int()
+	}
+	opts.Progress = jsBlobProgress(p[i].Get("progress"))
+
+	signal := p[i].Get("signal")
+	if signal.Type() != js.TypeObject {
+		return
+	}
+	cancel := make(chan struct{})
+	opts.Cancel = cancel
+	if signal.Get("aborted").Bool() {
+		close(cancel)
+		return
+	}
+	onAbort := js.FuncOf(func(this js.Value, args []js.Value) any {
+		close(cancel)
+		return nil
+	})
+	signal.Call("addEventListener", "abort", onAbort, map[string]any{"once": true})
+	release = func() {
+		signal.Call("removeEventListener", "abort", onAbort)
+		onAbort.Release()
+	}
+	return
+}
+
+// jsRepoStatus converts the status of a repo to a JS object.
+func jsRepoStatus(status RepoStatus) js.Value {
+	obj := map[string]any{
+		"repo":         status.Repo.String(),
+		"join":         status.Join.String(),
+		"joinAttempts": status.JoinAttempts,
+		"joinedAt":     0,
+		"error":        status.Error,
+		"snapshot":     js.Null(),
+		"blobsPending": status.BlobsPending,
+		"blobsStored":  status.BlobsStored,
+		"failedBlobs":  jsNames(status.FailedBlobs),
+	}
+	if !status.JoinedAt.IsZero() {
+		obj["joinedAt"] = status.JoinedAt.UnixMilli()
+	}
+	if status.Snapshot != nil {
+		obj["snapshot"] = status.Snapshot.String()
+	}
+	return js.ValueOf(obj)
+}
@@ -1,26 +1,26 @@
-//go:build js && wasm
-
 package app
 
 import (
-	"syscall/js"
-
 	enc "github.com/named-data/ndnd/std/encoding"
 	"github.com/named-data/ndnd/std/log"
 	"github.com/named-data/ndnd/std/ndn/svs_ps"
-	jsutil "github.com/named-data/ndnd/std/utils/js"
 	"github.com/pulsejet/ownly/ndn/app/tlv"
 )
 
-var _yjs_merge_updates = js.Global().Get("_yjs_merge_updates")
-
 // CompressSnapshotYjs compresses Yjs updates in the history snapshot.
 // This follows the SvsALO rules for snapshot compression.
-func (a *App) CompressSnapshotYjs(hs *svs_ps.HistorySnap) {
+// Entries are re-encrypted with the keys of this workspace and project.
+func (w *Workspace) CompressSnapshotYjs(proj string, hs *svs_ps.HistorySnap) {
+	// Without a Yjs implementation, keep the snapshot as-is
+	yjs := w.app.yjs
+	if yjs == nil {
+		return
+	}
+
 	// Compress Yjs updates in the snapshot.
 	// But we need to compress documents individually.
 	updateMap := make(map[string][][]byte)
-	lastEntry := make(map[string]*svs_ps.HistorySnapEntry) // see rules for snapshot compress
+	entryMap := make(map[string][]*svs_ps.HistorySnapEntry) // see rules for snapshot compress
 
 	for _, entry := range hs.Entries {
 		// Parse entry to check if it is a Yjs update
@@ -31,7 +31,7 @@ func (a *App) CompressSnapshotYjs(hs *svs_ps.HistorySnap) {
 		}
 
 		// Application updates are encrypted, decrypt it again
-		msg, err = a.decryptPub(msg)
+		msg, err = w.crypto.decryptPub(msg, proj)
 		if err != nil {
 			log.Error(nil, "Failed to decrypt snapshot entry", "err", err)
 			continue
@@ -39,18 +39,17 @@ func (a *App) CompressSnapshotYjs(hs *svs_ps.HistorySnap) {
 
 		if msg.YjsDelta != nil {
 			updateMap[msg.YjsDelta.UUID] = append(updateMap[msg.YjsDelta.UUID], msg.YjsDelta.Binary)
-			lastEntry[msg.YjsDelta.UUID] = entry
-			entry.Content = nil // remove this entry later if this is still nil
+			entryMap[msg.YjsDelta.UUID] = append(entryMap[msg.YjsDelta.UUID], entry)
 		}
 	}
 
 	// Compress Yjs updates
 	for uuid, updates := range updateMap {
-		updatesJs := js.Global().Get("Array").New()
-		for _, update := range updates {
-			updatesJs.Call("push", jsutil.SliceToJsArray(update))
+		merged, err := yjs.MergeUpdates(updates)
+		if err != nil {
+			log.Error(nil, "Failed to merge Yjs updates", "uuid", uuid, "err", err)
+			continue
 		}
-		merged := jsutil.JsArrayToSlice(_yjs_merge_updates.Invoke(updatesJs))
 
 		// Create new message
 		msg := &tlv.Message{
@@ -61,13 +60,19 @@ func (a *App) CompressSnapshotYjs(hs *svs_ps.HistorySnap) {
 		}
 
 		// Encrypt the snapshot entry
-		msg, err := a.encryptPub(msg, lastEntry[uuid].SeqNo)
+		entries := entryMap[uuid]
+		lastEntry := entries[len(entries)-1]
+		msg, err = w.crypto.encryptPub(msg, proj)
 		if err != nil {
 			log.Error(nil, "Failed to encrypt snapshot entry", "err", err)
 			continue
 		}
 
-		lastEntry[uuid].Content = msg.Encode()
+		// Keep only the last entry with the merged content
+		for _, entry := range entries {
+			entry.Content = nil // removed below
+		}
+		lastEntry.Content = msg.Encode()
 	}
 
 	// Remove all entries with nil content
@@ -9,12 +9,7 @@ import (
 )
 
 func main() {
-	var me *app.App
-	if js.Global().Get("window").IsUndefined() {
-		me = app.NewNodeApp()
-	} else {
-		me = app.NewApp()
-	}
+	me := app.NewApp()
 	js.Global().Call("set_ndn", me.JsApi())
 	select {}
 }
@@ -0,0 +1,1219 @@
+//go:build !js
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	enc "github.com/named-data/ndnd/std/encoding"
+	"github.com/named-data/ndnd/std/log"
+	"github.com/pulsejet/ownly/ndn/app"
+	"github.com/pulsejet/ownly/ndn/app/tlv"
+)
+
+// cli holds the global options and the lazily created app.
+type cli struct {
+	keychainDir string
+	stateDir    string
+	router      string
+	networks    string
+	lan         bool
+	lanIface    string
+
+	app *app.App
+	// Serializes updates of workspace metadata from callbacks
+	metaMutex sync.Mutex
+}
+
+func (c *cli) String() string {
+	return "ownly"
+}
+
+// connect creates the app and connects to the forwarder.
+func (c *cli) connect() (*app.App, error) {
+	if c.app != nil {
+		return c.app, nil
+	}
+
+	// Network profiles other than the testbed
+	var profiles []*app.NetworkProfile
+	if c.networks != "" {
+		buf, err := os.ReadFile(c.networks)
+		if err != nil {
+			return nil, err
+		}
+		if profiles, err = app.ParseNetworkProfiles(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	a, err := app.NewNativeApp(app.NativeOpts{
+		KeyChainDir:  c.keychainDir,
+		StateDir:     c.stateDir,
+		Router:       c.router,
+		Lan:          c.lan,
+		LanInterface: c.lanIface,
+		UI:           c,
+		Profiles:     profiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.WaitForConnectivity(5 * time.Second); err != nil {
+		return nil, err
+	}
+
+	c.app = a
+	return a, nil
+}
+
+func (c *cli) OnConnChange(state app.ConnState, router app.RouterInfo) {
+	if state == app.ConnUp {
+		log.Info(c, "Connected to forwarder", "router", router.URI)
+	} else {
+		log.Warn(c, "Not connected to forwarder", "router", router.URI, "state", state)
+	}
+}
+
+func (c *cli) OnAccessRequests(wksp string, requests []app.AccessRequest) {
+	for _, req := range requests {
+		fmt.Fprintf(os.Stderr, "Access request to %s from %s (%s)\n",
+			wksp, req.Requester, req.Time.Format(time.RFC3339))
+	}
+}
+
+func (c *cli) cmdIdentity(args []string) error {
+	flags := flag.NewFlagSet("identity", flag.ExitOnError)
+	email := flags.String("email", "", "request a testbed certificate for this email")
+	flags.Parse(args)
+
+	a, err := c.connect()
+	if err != nil {
+		return err
+	}
+
+	if *email != "" {
+		stdin := bufio.NewReader(os.Stdin)
+		err := a.NdncertEmail(*email, func(status string) string {
+			fmt.Fprintf(os.Stderr, "Enter the code sent to %s (%s): ", *email, status)
+			code, _ := stdin.ReadString('\n')
+			return strings.TrimSpace(code)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	key, expiry := a.GetTestbedKey()
+	if key == nil {
+		return fmt.Errorf("no testbed key, use -email to get a certificate")
+	}
+	fmt.Printf("%s (expires %s)\n", key.KeyName().Prefix(-2), expiry.Format(time.RFC3339))
+	return nil
+}
+
+func (c *cli) cmdJoin(args []string) error {
+	flags := flag.NewFlagSet("join", flag.ExitOnError)
+	create := flags.Bool("create", false, "create the workspace")
+	pskHex := flags.String("psk", "", "pre-shared key of the workspace (hex, from the invite)")
+	label := flags.String("label", "", "readable label for the workspace")
+	ignore := flags.Bool("ignore", false, "ignore certificate validity in the workspace")
+	network := flags.String("network", "", "network profile of the workspace (default by name)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected workspace name")
+	}
+	wkspStr := flags.Arg(0)
+
+	if meta, _ := c.getWorkspace(wkspStr); meta != nil {
+		return fmt.Errorf("already joined %s", meta.Name)
+	}
+
+	// Generate or validate PSK
+	psk := make([]byte, 32)
+	if *create {
+		rand.Read(psk)
+	} else if buf, err := hex.DecodeString(*pskHex); err != nil || len(buf) != 32 {
+		return fmt.Errorf("invalid PSK, expected 32 bytes of hex")
+	} else {
+		psk = buf
+	}
+
+	// Generate DSK if creating a new workspace
+	var dsk []byte
+	if *create {
+		dsk = make([]byte, 32)
+		rand.Read(dsk)
+	}
+
+	a, err := c.connect()
+	if err != nil {
+		return err
+	}
+
+	if *network != "" {
+		if err := a.SetWorkspaceProfile(wkspStr, *network); err != nil {
+			return err
+		}
+	}
+
+	// Join workspace - this will check invitation etc.
+	name, err := a.JoinWorkspace(wkspStr, *create, *label)
+	if err != nil {
+		return err
+	}
+
+	// Check if we have the owner permissions
+	isOwner, err := a.IsWorkspaceOwner(name)
+	if err != nil {
+		return err
+	}
+
+	// Use the label of the workspace metadata by default
+	if *label == "" {
+		if info, err := a.GetWorkspaceInfo(name); err == nil {
+			*label = info.Label
+		} else {
+			*label = name
+		}
+	}
+	if err := c.saveWorkspace(&wkspState{
+		Label:  *label,
+		Name:   name,
+		Owner:  isOwner,
+		Ignore: *ignore,
+		Psk:    hex.EncodeToString(psk),
+		Dsk:    hex.EncodeToString(dsk),
+	}); err != nil {
+		return err
+	}
+
+	fmt.Println(name)
+	return nil
+}
+
+func (c *cli) cmdInfo(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected workspace name")
+	}
+
+	a, err := c.connect()
+	if err != nil {
+		return err
+	}
+	info, err := a.GetWorkspaceInfo(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Name:\t%s\n", info.Name)
+	fmt.Printf("Label:\t%s\n", info.Label)
+	fmt.Printf("Owner:\t%s\n", info.Owner)
+	fmt.Printf("Created:\t%s\n", info.Created.Format(time.RFC3339))
+	fmt.Printf("Schema:\t%d\n", info.SchemaVersion)
+	fmt.Printf("Repo:\t%s\n", info.Repo)
+	for _, repo := range info.Repos[1:] {
+		fmt.Printf("\t%s\n", repo)
+	}
+	if network, err := a.WorkspaceProfile(args[0]); err == nil {
+		fmt.Printf("Network:\t%s\n", network)
+	}
+	return nil
+}
+
+func (c *cli) cmdWorkspaces(args []string) error {
+	wksps, err := c.loadWorkspaces()
+	if err != nil {
+		return err
+	}
+	for _, meta := range wksps {
+		role := "member"
+		if meta.Owner {
+			role = "owner"
+		}
+		fmt.Printf("%s\t%s\t%s\n", meta.Name, meta.Label, role)
+	}
+	return nil
+}
+
+func (c *cli) cmdProjects(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected workspace name")
+	}
+	meta, err := c.getWorkspace(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Project names are stored in the Yjs documents of the root project,
+	// so we can only list the project uuids that were synchronized.
+	entries, err := os.ReadDir(c.mirrorDir(meta.Name))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		project, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		m, err := c.openMirror(meta.Name, project)
+		if err != nil {
+			return err
+		}
+		docs, err := m.Docs()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\t%d documents\n", project, len(docs))
+	}
+	return nil
+}
+
+func (c *cli) cmdSync(args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	duration := flags.Duration("duration", 0, "stop after this duration (default: until interrupted)")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		return fmt.Errorf("expected workspace name")
+	}
+
+	meta, err := c.getWorkspace(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	projects := flags.Args()[1:]
+	if len(projects) == 0 {
+		projects = []string{"root"}
+	}
+
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	// Start all project SVS groups
+	for _, project := range projects {
+		svs, m, err := c.projectSvs(wksp, meta, project)
+		if err != nil {
+			return err
+		}
+
+		svs.SetOnError(func(err error) {
+			log.Warn(c, "SVS error", "project", project, "err", err)
+		})
+		svs.Subscribe(app.SvsAloSubscriber{
+			OnYjsDelta: func(deltas []*tlv.YjsDelta) {
+				for _, delta := range deltas {
+					if err := m.AppendUpdate(delta.UUID, delta.Binary); err != nil {
+						log.Error(c, "Failed to store update", "project", project, "err", err)
+					}
+				}
+			},
+		})
+		if err := svs.Start(); err != nil {
+			return err
+		}
+		defer svs.Stop()
+
+		fmt.Fprintf(os.Stderr, "Synchronizing %s\n", svs.DataPrefix())
+	}
+
+	// Wait for interrupt or timeout
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+	<-ctx.Done()
+
+	return nil
+}
+
+func (c *cli) cmdRotateKey(args []string) error {
+	flags := flag.NewFlagSet("rotate-key", flag.ExitOnError)
+	remove := flags.String("remove", "", "comma-separated members that should not get the new key")
+	revoke := flags.Bool("revoke", false, "also reject publications and keys from removed members")
+	duration := flags.Duration("duration", 30*time.Second, "time to stay online answering key requests")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected workspace name")
+	}
+
+	meta, err := c.getWorkspace(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if !meta.Owner {
+		return fmt.Errorf("only owners can rotate the key of %s", meta.Name)
+	}
+	if *revoke && *remove == "" {
+		return fmt.Errorf("expected members to revoke with -remove")
+	}
+
+	removed := make([]enc.Name, 0)
+	for _, nameStr := range strings.Split(*remove, ",") {
+		if nameStr = strings.TrimSpace(nameStr); nameStr == "" {
+			continue
+		}
+		name, err := enc.NameFromStr(nameStr)
+		if err != nil {
+			return fmt.Errorf("invalid member name %s: %w", nameStr, err)
+		}
+		removed = append(removed, name)
+	}
+
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	rootSvs, m, err := c.projectSvs(wksp, meta, "root")
+	if err != nil {
+		return err
+	}
+	rootSvs.Subscribe(app.SvsAloSubscriber{
+		OnYjsDelta: func(deltas []*tlv.YjsDelta) {
+			for _, delta := range deltas {
+				if err := m.AppendUpdate(delta.UUID, delta.Binary); err != nil {
+					log.Error(c, "Failed to store update", "project", "root", "err", err)
+				}
+			}
+		},
+	})
+	if err := rootSvs.Start(); err != nil {
+		return err
+	}
+	defer rootSvs.Stop()
+
+	// Revoke before rotating, so that removed members cannot get the new key
+	if *revoke {
+		if err := wksp.RevokeMembers(removed); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked %d members of %s\n", len(removed), meta.Name)
+	}
+
+	epoch, dsk, err := rootSvs.PubDskRotate(removed)
+	if err != nil {
+		return err
+	}
+
+	// Persist the new key and removed members
+	for _, name := range removed {
+		meta.Removed = append(meta.Removed, name.String())
+	}
+	c.saveEpochKey(meta, epoch, dsk)
+	fmt.Printf("Rotated key of %s to epoch %d\n", meta.Name, epoch)
+
+	// Stay online so that members can fetch the new key
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+	<-ctx.Done()
+
+	return nil
+}
+
+func (c *cli) cmdMembers(args []string) error {
+	flags := flag.NewFlagSet("members", flag.ExitOnError)
+	set := flags.String("set", "", "comma-separated members that can access the project (owner only)")
+	duration := flags.Duration("duration", 30*time.Second, "time to stay online answering key requests")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		return fmt.Errorf("expected workspace and project names")
+	}
+	project := flags.Arg(1)
+
+	meta, err := c.getWorkspace(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	if *set == "" {
+		members, err := wksp.ProjectMembers(project)
+		if err != nil {
+			return err
+		}
+		if members == nil {
+			fmt.Printf("%s is public to all members of %s\n", project, meta.Name)
+		}
+		for _, member := range members {
+			fmt.Println(member)
+		}
+		return nil
+	}
+
+	members := make([]enc.Name, 0)
+	for _, nameStr := range strings.Split(*set, ",") {
+		if nameStr = strings.TrimSpace(nameStr); nameStr == "" {
+			continue
+		}
+		name, err := enc.NameFromStr(nameStr)
+		if err != nil {
+			return fmt.Errorf("invalid member name %s: %w", nameStr, err)
+		}
+		members = append(members, name)
+	}
+
+	// The member list is sent to the repo through the root project
+	rootSvs, _, err := c.projectSvs(wksp, meta, "root")
+	if err != nil {
+		return err
+	}
+	rootSvs.Subscribe(app.SvsAloSubscriber{})
+	if err := rootSvs.Start(); err != nil {
+		return err
+	}
+	defer rootSvs.Stop()
+
+	if err := wksp.SetProjectMembers(project, members); err != nil {
+		return err
+	}
+	fmt.Printf("Made %s private to %d members\n", project, len(members))
+
+	// Stay online so that members can fetch the project key
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+	<-ctx.Done()
+
+	return nil
+}
+
+func (c *cli) cmdRepoStatus(args []string) error {
+	flags := flag.NewFlagSet("repo-status", flag.ExitOnError)
+	duration := flags.Duration("duration", 30*time.Second, "time to wait for the repo to join the project")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		return fmt.Errorf("expected workspace and project names")
+	}
+	project := flags.Arg(1)
+
+	meta, err := c.getWorkspace(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	// Starting the project sends the SyncJoin command
+	svs, _, err := c.projectSvs(wksp, meta, project)
+	if err != nil {
+		return err
+	}
+	if err := svs.Start(); err != nil {
+		return err
+	}
+	defer svs.Stop()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	// Wait until all repos joined
+	var list []app.RepoStatus
+	for {
+		if list, err = wksp.RepoStatus(project); err != nil {
+			return err
+		}
+		joined := true
+		for _, status := range list {
+			joined = joined && status.Join == app.RepoCmdAcked
+		}
+		if joined || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+
+	for i, status := range list {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Repo:\t%s\n", status.Repo)
+		fmt.Printf("Join:\t%s (%d attempts)\n", status.Join, status.JoinAttempts)
+		if status.Error != "" {
+			fmt.Printf("Error:\t%s\n", status.Error)
+		}
+		if status.Snapshot != nil {
+			fmt.Printf("Snapshot:\t%s\n", status.Snapshot)
+		} else {
+			fmt.Printf("Snapshot:\tnone\n")
+		}
+	}
+	return nil
+}
+
+func (c *cli) cmdRepos(args []string) error {
+	flags := flag.NewFlagSet("repos", flag.ExitOnError)
+	set := flags.String("set", "", "comma-separated repos of the workspace, the first is the main repo (creator only)")
+	duration := flags.Duration("duration", 30*time.Second, "time to stay online for the repos to join")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected workspace name")
+	}
+
+	meta, err := c.getWorkspace(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	if *set == "" {
+		for _, repo := range wksp.Repos() {
+			fmt.Println(repo)
+		}
+		return nil
+	}
+
+	repos := make([]enc.Name, 0)
+	for _, nameStr := range strings.Split(*set, ",") {
+		if nameStr = strings.TrimSpace(nameStr); nameStr == "" {
+			continue
+		}
+		name, err := enc.NameFromStr(nameStr)
+		if err != nil {
+			return fmt.Errorf("invalid repo name %s: %w", nameStr, err)
+		}
+		repos = append(repos, name)
+	}
+
+	// The metadata is sent to the repos through the root project
+	rootSvs, _, err := c.projectSvs(wksp, meta, "root")
+	if err != nil {
+		return err
+	}
+	rootSvs.Subscribe(app.SvsAloSubscriber{})
+	if err := rootSvs.Start(); err != nil {
+		return err
+	}
+	defer rootSvs.Stop()
+
+	if err := wksp.SetRepos(repos); err != nil {
+		return err
+	}
+	fmt.Printf("Set %d repos of %s\n", len(repos), meta.Name)
+
+	// Stay online so that the repos join and fetch the metadata
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+	<-ctx.Done()
+
+	return nil
+}
+
+func (c *cli) cmdRequests(args []string) error {
+	flags := flag.NewFlagSet("requests", flag.ExitOnError)
+	duration := flags.Duration("duration", 0, "stay online to receive new requests for this duration")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected workspace name")
+	}
+
+	meta, err := c.getWorkspace(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if !meta.Owner {
+		return fmt.Errorf("only owners receive access requests to %s", meta.Name)
+	}
+
+	// Starting the workspace reports the persisted requests
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	if *duration > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, *duration)
+		defer cancel()
+		<-ctx.Done()
+	}
+
+	return nil
+}
+
+func (c *cli) cmdInvite(args []string) error {
+	return c.signInvitation("invite", "invitation", args, func(wksp *app.Workspace, invitee enc.Name, opts app.InviteOpts) (enc.Wire, error) {
+		return wksp.SignInvitation(invitee, opts)
+	})
+}
+
+func (c *cli) cmdApprove(args []string) error {
+	return c.signInvitation("approve", "invitation", args, func(wksp *app.Workspace, requester enc.Name, opts app.InviteOpts) (enc.Wire, error) {
+		return wksp.ApproveAccessRequest(requester, opts)
+	})
+}
+
+func (c *cli) cmdGrantOwner(args []string) error {
+	return c.signInvitation("grant-owner", "co-owner grant", args, func(wksp *app.Workspace, coowner enc.Name, opts app.InviteOpts) (enc.Wire, error) {
+		return wksp.SignCoOwnerGrant(coowner, opts)
+	})
+}
+
+// signInvitation parses the invitation options, signs an invitation (or grant)
+// with sign and publishes it to the repo through the root project.
+func (c *cli) signInvitation(
+	cmd string, kind string, args []string,
+	sign func(*app.Workspace, enc.Name, app.InviteOpts) (enc.Wire, error),
+) error {
+	flags := flag.NewFlagSet(cmd, flag.ExitOnError)
+	expiry := flags.Duration("expiry", app.DefaultInviteValidity, "validity of the "+kind)
+	notBefore := flags.String("not-before", "", "start of the validity (RFC 3339, default: now)")
+	var role *string
+	if kind == "invitation" {
+		role = flags.String("role", "editor", "role of the invitee (editor, commenter or viewer)")
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		return fmt.Errorf("expected workspace and invitee")
+	}
+
+	meta, err := c.getWorkspace(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	invitee, err := enc.NameFromStr(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	opts := app.InviteOpts{}
+	start := time.Now()
+	if *notBefore != "" {
+		if opts.NotBefore, err = time.Parse(time.RFC3339, *notBefore); err != nil {
+			return fmt.Errorf("invalid -not-before: %w", err)
+		}
+		start = opts.NotBefore
+	}
+	opts.NotAfter = start.Add(*expiry)
+	if role != nil {
+		if opts.Role, err = app.ParseRole(*role); err != nil {
+			return err
+		}
+	}
+
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	invitation, err := sign(wksp, invitee, opts)
+	if err != nil {
+		return err
+	}
+
+	// Let the repo store the invitation in case the request Interest expired
+	rootSvs, _, err := c.projectSvs(wksp, meta, "root")
+	if err != nil {
+		return err
+	}
+	if err := rootSvs.Start(); err != nil {
+		return err
+	}
+	defer rootSvs.Stop()
+	if _, err := rootSvs.PubBlobFetch(nil, invitation.Join()); err != nil {
+		return err
+	}
+
+	fmt.Printf("Signed %s for %s in %s until %s\n", kind, invitee, meta.Name, opts.NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+func (c *cli) cmdAcceptOwner(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected workspace name")
+	}
+	meta, err := c.getWorkspace(args[0])
+	if err != nil {
+		return err
+	}
+
+	a, err := c.connect()
+	if err != nil {
+		return err
+	}
+	if err := a.AcceptCoOwnerGrant(meta.Name); err != nil {
+		return err
+	}
+
+	if meta.Owner, err = a.IsWorkspaceOwner(meta.Name); err != nil {
+		return err
+	}
+	if err := c.saveWorkspace(meta); err != nil {
+		return err
+	}
+
+	fmt.Printf("Accepted ownership of %s\n", meta.Name)
+	return nil
+}
+
+func (c *cli) cmdRenew(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected workspace name")
+	}
+	meta, err := c.getWorkspace(args[0])
+	if err != nil {
+		return err
+	}
+
+	a, err := c.connect()
+	if err != nil {
+		return err
+	}
+	renewed, err := a.RenewWorkspaceCert(meta.Name)
+	if err != nil {
+		return err
+	}
+	if renewed {
+		fmt.Printf("Renewed certificate for %s\n", meta.Name)
+	} else {
+		fmt.Printf("Certificate for %s does not need renewal\n", meta.Name)
+	}
+	return nil
+}
+
+func (c *cli) cmdDeny(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected workspace and requester")
+	}
+	meta, err := c.getWorkspace(args[0])
+	if err != nil {
+		return err
+	}
+	requester, err := enc.NameFromStr(args[1])
+	if err != nil {
+		return err
+	}
+
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	return wksp.DenyAccessRequest(requester)
+}
+
+func (c *cli) cmdExport(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("expected workspace, project and directory")
+	}
+	meta, err := c.getWorkspace(args[0])
+	if err != nil {
+		return err
+	}
+	m, err := c.openMirror(meta.Name, args[1])
+	if err != nil {
+		return err
+	}
+	outDir := args[2]
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+
+	docs, err := m.Docs()
+	if err != nil {
+		return err
+	}
+
+	// Each document is exported as a list of length-prefixed Yjs V2 updates,
+	// which can be applied in order with Y.applyUpdateV2
+	manifest := make(map[string]int)
+	for _, doc := range docs {
+		updates, err := m.Updates(doc)
+		if err != nil {
+			return err
+		}
+
+		out := make([]byte, 0)
+		for _, update := range updates {
+			out = binary.AppendUvarint(out, uint64(len(update)))
+			out = append(out, update...)
+		}
+		file := filepath.Join(outDir, url.PathEscape(doc)+".yjs")
+		if err := os.WriteFile(file, out, 0644); err != nil {
+			return err
+		}
+		manifest[doc] = len(updates)
+	}
+
+	buf, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "manifest.json"), buf, 0644)
+}
+
+func (c *cli) cmdPutBlob(args []string) error {
+	flags := flag.NewFlagSet("put-blob", flag.ExitOnError)
+	uuid := flags.String("uuid", "", "document of the blob (default: random)")
+	dedup := flags.Bool("dedup", false, "name the blob by its content and skip it if already published")
+	duration := flags.Duration("duration", 30*time.Second, "time to stay online for the repo to fetch the blob")
+	flags.Parse(args)
+	if flags.NArg() != 3 {
+		return fmt.Errorf("expected workspace, project and file")
+	}
+
+	file, err := os.Open(flags.Arg(2))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if *uuid == "" {
+		id := make([]byte, 16)
+		rand.Read(id)
+		*uuid = hex.EncodeToString(id)
+	}
+
+	meta, err := c.getWorkspace(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	svs, _, err := c.projectSvs(wksp, meta, flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	svs.Subscribe(app.SvsAloSubscriber{})
+	if err := svs.Start(); err != nil {
+		return err
+	}
+	defer svs.Stop()
+
+	name, err := svs.PublishBlob(*uuid, file, stat.Size(), app.BlobPublishOpts{
+		Dedup:    *dedup,
+		Progress: printProgress,
+	})
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return err
+	}
+	fmt.Println(name)
+
+	// Stay online so that the repo fetches the blob
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+	<-ctx.Done()
+
+	return nil
+}
+
+func (c *cli) cmdGetBlob(args []string) error {
+	flags := flag.NewFlagSet("get-blob", flag.ExitOnError)
+	window := flags.Int("window", 8, "number of segments fetched in parallel")
+	flags.Parse(args)
+	if flags.NArg() != 4 {
+		return fmt.Errorf("expected workspace, project, name and file")
+	}
+	args = flags.Args()
+	name, err := enc.NameFromStr(args[2])
+	if err != nil {
+		return err
+	}
+
+	meta, err := c.getWorkspace(args[0])
+	if err != nil {
+		return err
+	}
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	svs, _, err := c.projectSvs(wksp, meta, args[1])
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(args[3])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Interrupting keeps the fetched segments, so running again resumes
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = svs.FetchBlob(name, file, app.BlobFetchOpts{
+		Window:   *window,
+		Progress: printProgress,
+		Cancel:   ctx.Done(),
+	})
+	fmt.Fprintln(os.Stderr)
+	return err
+}
+
+func (c *cli) cmdRemoveBlob(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("expected workspace, project and name")
+	}
+	name, err := enc.NameFromStr(args[2])
+	if err != nil {
+		return err
+	}
+
+	meta, err := c.getWorkspace(args[0])
+	if err != nil {
+		return err
+	}
+	wksp, err := c.openWorkspace(meta)
+	if err != nil {
+		return err
+	}
+	defer wksp.Stop()
+
+	svs, _, err := c.projectSvs(wksp, meta, args[1])
+	if err != nil {
+		return err
+	}
+	if err := svs.RemoveBlob(name); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Removed %s locally, delete it on the repos to remove their copy\n", name)
+	return nil
+}
+
+// printProgress shows the progress of a blob transfer on stderr.
+func printProgress(done int64, total int64) {
+	fmt.Fprintf(os.Stderr, "\r%d / %d bytes", done, total)
+}
+
+// openWorkspace starts the workspace and makes sure we have the encryption keys.
+func (c *cli) openWorkspace(meta *wkspState) (*app.Workspace, error) {
+	a, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	wksp, err := a.GetWorkspace(meta.Name, meta.Ignore)
+	if err != nil {
+		return nil, err
+	}
+	if err := wksp.Start(); err != nil {
+		return nil, err
+	}
+
+	// Check if we have the encryption keys
+	if meta.Dsk == "" {
+		if err := c.findDsk(wksp, meta); err != nil {
+			wksp.Stop()
+			return nil, err
+		}
+	}
+
+	psk, err := hex.DecodeString(meta.Psk)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PSK: %w", err)
+	}
+	dsk, err := hex.DecodeString(meta.Dsk)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSK: %w", err)
+	}
+	if err := wksp.SetEncryptKeys(psk, dsk, meta.DskEpoch); err != nil {
+		wksp.Stop()
+		return nil, err
+	}
+	for epoch, keyHex := range meta.EpochKeys {
+		key, err := hex.DecodeString(keyHex)
+		if err != nil {
+			wksp.Stop()
+			return nil, fmt.Errorf("invalid DSK of epoch %d: %w", epoch, err)
+		}
+		if err := wksp.AddEpochKey(epoch, key); err != nil {
+			wksp.Stop()
+			return nil, err
+		}
+	}
+
+	for proj, keyHex := range meta.ProjKeys {
+		key, err := hex.DecodeString(keyHex)
+		if err != nil {
+			wksp.Stop()
+			return nil, fmt.Errorf("invalid key of project %s: %w", proj, err)
+		}
+		if err := wksp.SetProjectKey(proj, key); err != nil {
+			wksp.Stop()
+			return nil, err
+		}
+	}
+
+	removed := make([]enc.Name, 0, len(meta.Removed))
+	for _, nameStr := range meta.Removed {
+		if name, err := enc.NameFromStr(nameStr); err == nil {
+			removed = append(removed, name)
+		}
+	}
+	wksp.SetRemovedMembers(removed)
+
+	// Persist keys of new epochs
+	wksp.SetOnEpochKey(func(epoch uint64, dsk []byte) {
+		c.saveEpochKey(meta, epoch, dsk)
+	})
+	wksp.SetOnProjectKey(func(proj string, key []byte) {
+		c.saveProjectKey(meta, proj, key)
+	})
+
+	return wksp, nil
+}
+
+// saveEpochKey persists the key of an epoch.
+func (c *cli) saveEpochKey(meta *wkspState, epoch uint64, dsk []byte) {
+	c.metaMutex.Lock()
+	defer c.metaMutex.Unlock()
+
+	if meta.EpochKeys == nil {
+		meta.EpochKeys = make(map[uint64]string)
+	}
+	meta.EpochKeys[epoch] = hex.EncodeToString(dsk)
+	if err := c.saveWorkspace(meta); err != nil {
+		log.Error(c, "Failed to persist epoch key", "epoch", epoch, "err", err)
+	}
+}
+
+// saveProjectKey persists the key of a private project.
+func (c *cli) saveProjectKey(meta *wkspState, proj string, key []byte) {
+	c.metaMutex.Lock()
+	defer c.metaMutex.Unlock()
+
+	if meta.ProjKeys == nil {
+		meta.ProjKeys = make(map[string]string)
+	}
+	meta.ProjKeys[proj] = hex.EncodeToString(key)
+	if err := c.saveWorkspace(meta); err != nil {
+		log.Error(c, "Failed to persist project key", "project", proj, "err", err)
+	}
+}
+
+// findDsk gets the DSK from other members through the root group.
+func (c *cli) findDsk(wksp *app.Workspace, meta *wkspState) error {
+	rootSvs, _, err := c.projectSvs(wksp, meta, "root")
+	if err != nil {
+		return err
+	}
+	if err := rootSvs.Start(); err != nil {
+		return err
+	}
+	defer rootSvs.Stop()
+
+	if meta.DskExch == "" {
+		dskExch, err := rootSvs.PubDskRequest()
+		if err != nil {
+			return err
+		}
+
+		// Persist the key exchange key so that this process can be asynchronous
+		meta.DskExch = hex.EncodeToString(dskExch)
+		if err := c.saveWorkspace(meta); err != nil {
+			return err
+		}
+	}
+
+	// Wait for DSK key or fail
+	dskExch, err := hex.DecodeString(meta.DskExch)
+	if err != nil {
+		return err
+	}
+	epoch, dsk, err := wksp.WaitForDsk(dskExch)
+	if err != nil {
+		return fmt.Errorf("no DSK, try again later when others are online: %w", err)
+	}
+
+	// Persist the DSK key
+	meta.Dsk = hex.EncodeToString(dsk)
+	meta.DskEpoch = epoch
+	if err := c.saveWorkspace(meta); err != nil {
+		return err
+	}
+
+	// Acknowledge the DSK key
+	return rootSvs.PubDskAck(dskExch)
+}
+
+// projectSvs creates the SVS ALO instance for a project with its local mirror.
+func (c *cli) projectSvs(wksp *app.Workspace, meta *wkspState, project string) (*app.SvsAlo, *mirror, error) {
+	m, err := c.openMirror(meta.Name, project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var state enc.Wire
+	if buf := m.State(); buf != nil {
+		state = enc.Wire{buf}
+	}
+
+	group := wksp.Group().Append(enc.NewGenericComponent(project))
+	svs, err := wksp.SvsAlo(group, state, func(state enc.Wire) {
+		if err := m.PersistState(state.Join()); err != nil {
+			log.Error(c, "Failed to persist state", "project", project, "err", err)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return svs, m, nil
+}
@@ -0,0 +1,187 @@
+//go:build !js
+
+// Command ownly is a native client for Ownly workspaces.
+// It connects to a local NDN forwarder and mirrors workspace projects to disk.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type command struct {
+	usage string
+	help  string
+	run   func(c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"identity": {
+		usage: "identity [-email address]",
+		help:  "show the testbed identity, or request a certificate with NDNCERT",
+		run:   (*cli).cmdIdentity,
+	},
+	"join": {
+		usage: "join [-create] [-psk hex] [-label name] [-network name] [-ignore] <workspace>",
+		help:  "join or create a workspace",
+		run:   (*cli).cmdJoin,
+	},
+	"info": {
+		usage: "info <workspace>",
+		help:  "show the metadata of a workspace",
+		run:   (*cli).cmdInfo,
+	},
+	"workspaces": {
+		usage: "workspaces",
+		help:  "list joined workspaces",
+		run:   (*cli).cmdWorkspaces,
+	},
+	"projects": {
+		usage: "projects <workspace>",
+		help:  "list projects mirrored locally for a workspace",
+		run:   (*cli).cmdProjects,
+	},
+	"sync": {
+		usage: "sync [-duration d] <workspace> [project...]",
+		help:  "synchronize projects (by uuid) of a workspace to the state directory",
+		run:   (*cli).cmdSync,
+	},
+	"rotate-key": {
+		usage: "rotate-key [-remove names] [-revoke] [-duration d] <workspace>",
+		help:  "mint a new workspace key, optionally removing or revoking members",
+		run:   (*cli).cmdRotateKey,
+	},
+	"members": {
+		usage: "members [-set names] [-duration d] <workspace> <project>",
+		help:  "show the members of a private project, or make it private to members",
+		run:   (*cli).cmdMembers,
+	},
+	"repo-status": {
+		usage: "repo-status [-duration d] <workspace> <project>",
+		help:  "show whether the repo joined a project and its latest snapshot",
+		run:   (*cli).cmdRepoStatus,
+	},
+	"repos": {
+		usage: "repos [-set names] [-duration d] <workspace>",
+		help:  "show the repos of a workspace, or change them",
+		run:   (*cli).cmdRepos,
+	},
+	"requests": {
+		usage: "requests [-duration d] <workspace>",
+		help:  "list access requests to an owned workspace",
+		run:   (*cli).cmdRequests,
+	},
+	"invite": {
+		usage: "invite [-expiry d] [-not-before time] [-role r] <workspace> <name>",
+		help:  "invite a user and publish the invitation",
+		run:   (*cli).cmdInvite,
+	},
+	"renew": {
+		usage: "renew <workspace>",
+		help:  "renew the workspace certificate before it expires",
+		run:   (*cli).cmdRenew,
+	},
+	"approve": {
+		usage: "approve [-expiry d] [-not-before time] [-role r] <workspace> <requester>",
+		help:  "approve an access request and publish the invitation",
+		run:   (*cli).cmdApprove,
+	},
+	"deny": {
+		usage: "deny <workspace> <requester>",
+		help:  "deny an access request",
+		run:   (*cli).cmdDeny,
+	},
+	"grant-owner": {
+		usage: "grant-owner [-expiry d] [-not-before time] <workspace> <name>",
+		help:  "make a member a co-owner of a workspace",
+		run:   (*cli).cmdGrantOwner,
+	},
+	"accept-owner": {
+		usage: "accept-owner <workspace>",
+		help:  "accept a co-owner grant to a workspace",
+		run:   (*cli).cmdAcceptOwner,
+	},
+	"put-blob": {
+		usage: "put-blob [-uuid id] [-dedup] [-duration d] <workspace> <project> <file>",
+		help:  "encrypt and publish a file as a blob, printing its name",
+		run:   (*cli).cmdPutBlob,
+	},
+	"get-blob": {
+		usage: "get-blob [-window n] <workspace> <project> <name> <file>",
+		help:  "fetch and decrypt a blob into a file, resuming an interrupted fetch",
+		run:   (*cli).cmdGetBlob,
+	},
+	"remove-blob": {
+		usage: "remove-blob <workspace> <project> <name>",
+		help:  "remove a blob from the local store, the repos keep it (owner only)",
+		run:   (*cli).cmdRemoveBlob,
+	},
+	"export": {
+		usage: "export <workspace> <project> <directory>",
+		help:  "export the mirrored Yjs documents of a project",
+		run:   (*cli).cmdExport,
+	},
+}
+
+func main() {
+	flags := flag.NewFlagSet("ownly", flag.ExitOnError)
+	flags.Usage = func() { usage(flags) }
+
+	c := &cli{}
+	flags.StringVar(&c.keychainDir, "keychain", defaultDir("keychain"), "keychain directory")
+	flags.StringVar(&c.stateDir, "state", defaultDir("state"), "state directory")
+	flags.StringVar(&c.router, "router", "", "forwarder to connect to (unix:// or tcp://), or multicast group (udp4://)")
+	flags.StringVar(&c.networks, "networks", "", "JSON file of network profiles, the first is used for identities")
+	flags.BoolVar(&c.lan, "lan", false, "LAN-only mode, peers connect over UDP multicast without the testbed")
+	flags.StringVar(&c.lanIface, "lan-iface", "", "network interface for multicast in LAN-only mode")
+	flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) == 0 {
+		usage(flags)
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ownly: unknown command %q\n", args[0])
+		usage(flags)
+		os.Exit(2)
+	}
+
+	err := cmd.run(c, args[1:])
+	if c.app != nil {
+		c.app.Stop()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ownly: %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+}
+
+func usage(flags *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Usage: ownly [options] <command> [arguments]\n\nCommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-48s %s\n", commands[name].usage, commands[name].help)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nOptions:\n")
+	flags.PrintDefaults()
+}
+
+func defaultDir(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".ownly", name)
+	}
+	return filepath.Join(home, ".ownly", name)
+}
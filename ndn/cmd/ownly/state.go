@@ -0,0 +1,210 @@
+//go:build !js
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// wkspState is the local metadata of a joined workspace.
+// This mirrors IWkspStats of the web application.
+type wkspState struct {
+	// Readable label for the space
+	Label string `json:"label"`
+	// Data prefix of the space
+	Name string `json:"name"`
+	// Is the current user the owner
+	Owner bool `json:"owner"`
+	// Workspace ignore certificate lifetime
+	Ignore bool `json:"ignore"`
+
+	// Pre-shared key (hex)
+	Psk string `json:"psk"`
+	// Dynamic-shared key (hex)
+	Dsk string `json:"dsk,omitempty"`
+	// Key epoch of the DSK
+	DskEpoch uint64 `json:"dskEpoch,omitempty"`
+	// DSK request key (hex)
+	DskExch string `json:"dskExch,omitempty"`
+	// Keys of other epochs (hex)
+	EpochKeys map[uint64]string `json:"epochKeys,omitempty"`
+	// Members removed on key rotation
+	Removed []string `json:"removed,omitempty"`
+	// Keys of private projects (hex)
+	ProjKeys map[string]string `json:"projKeys,omitempty"`
+}
+
+func (c *cli) wkspFile() string {
+	return filepath.Join(c.stateDir, "workspaces.json")
+}
+
+// loadWorkspaces reads the list of joined workspaces.
+func (c *cli) loadWorkspaces() (map[string]*wkspState, error) {
+	wksps := make(map[string]*wkspState)
+
+	buf, err := os.ReadFile(c.wkspFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return wksps, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(buf, &wksps); err != nil {
+		return nil, fmt.Errorf("invalid workspace list: %w", err)
+	}
+	return wksps, nil
+}
+
+// saveWorkspace inserts or updates a workspace in the list.
+func (c *cli) saveWorkspace(meta *wkspState) error {
+	wksps, err := c.loadWorkspaces()
+	if err != nil {
+		return err
+	}
+	wksps[meta.Name] = meta
+
+	buf, err := json.MarshalIndent(wksps, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.stateDir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(c.wkspFile(), buf, 0600)
+}
+
+// getWorkspace returns the metadata of a joined workspace.
+func (c *cli) getWorkspace(name string) (*wkspState, error) {
+	wksps, err := c.loadWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, meta := range wksps {
+		if meta.Name == name || meta.Label == name {
+			return meta, nil
+		}
+	}
+	return nil, fmt.Errorf("workspace %s is not joined", name)
+}
+
+// escapeName converts an NDN name to a directory name.
+// This is the same as escapeUrlName of the web application.
+func escapeName(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	return strings.ReplaceAll(strings.ReplaceAll(name, "-", "--"), "/", "-")
+}
+
+// mirror is the on-disk copy of a project SVS group.
+// Each Yjs document is stored as a log of length-prefixed V2 updates.
+type mirror struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func (c *cli) mirrorDir(wksp string) string {
+	return filepath.Join(c.stateDir, "mirror", escapeName(wksp))
+}
+
+func (c *cli) openMirror(wksp string, project string) (*mirror, error) {
+	dir := filepath.Join(c.mirrorDir(wksp), url.PathEscape(project))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &mirror{dir: dir}, nil
+}
+
+// State returns the persisted SVS state, or nil if none.
+func (m *mirror) State() []byte {
+	state, err := os.ReadFile(filepath.Join(m.dir, "svs.state"))
+	if err != nil {
+		return nil
+	}
+	return state
+}
+
+// PersistState stores the SVS state.
+func (m *mirror) PersistState(state []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tmp := filepath.Join(m.dir, "svs.state.tmp")
+	if err := os.WriteFile(tmp, state, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.dir, "svs.state"))
+}
+
+// AppendUpdate appends a Yjs update to the log of a document.
+func (m *mirror) AppendUpdate(doc string, update []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.docFile(doc), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	frame := binary.AppendUvarint(nil, uint64(len(update)))
+	frame = append(frame, update...)
+	_, err = file.Write(frame)
+	return err
+}
+
+// Docs returns the list of documents in the mirror.
+func (m *mirror) Docs() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(m.dir, "*.ylog"))
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]string, 0, len(files))
+	for _, file := range files {
+		doc, err := url.PathUnescape(strings.TrimSuffix(filepath.Base(file), ".ylog"))
+		if err != nil {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// Updates reads all updates of a document.
+func (m *mirror) Updates(doc string) ([][]byte, error) {
+	file, err := os.Open(m.docFile(doc))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	updates := make([][]byte, 0)
+	reader := bufio.NewReader(file)
+	for {
+		size, err := binary.ReadUvarint(reader)
+		if err == io.EOF {
+			return updates, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		update := make([]byte, size)
+		if _, err := io.ReadFull(reader, update); err != nil {
+			return nil, fmt.Errorf("truncated update log for %s: %w", doc, err)
+		}
+		updates = append(updates, update)
+	}
+}
+
+func (m *mirror) docFile(doc string) string {
+	// Document UUIDs come from the network, never use them as paths directly
+	return filepath.Join(m.dir, url.PathEscape(doc)+".ylog")
+}
@@ -1,7 +1,7 @@
 import { EventEmitter } from 'events';
 
 import type TypedEmitter from 'typed-emitter';
-import type { IChatChannel, IProject, IProjectFile, IAgentChannel } from './types';
+import type { IChatChannel, IProject, IProjectFile, IAgentChannel, IAccessRequest } from './types';
 
 
 /**
@@ -42,6 +42,13 @@ export const GlobalBus = new EventEmitter() as TypedEmitter<{
    */
   'conn-change': () => void;
 
+  /**
+   * Event when the pending access requests to an owned workspace change.
+   * @param wksp Name of the workspace
+   * @param requests List of pending requests
+   */
+  'access-requests': (wksp: string, requests: IAccessRequest[]) => void;
+
   /**
    * Event when agent channels are updated.
    * @param channels List of agent channels
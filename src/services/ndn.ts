@@ -6,16 +6,28 @@ import { StoreDexie, type StoreJS } from '@/services/database/store_js';
 import { KeyChainDexie, type KeyChainJS } from '@/services/database/keychain_js';
 import { GlobalBus } from '@/services/event-bus';
 
+import type {
+  BlobFetchOpts,
+  BlobGcOpts,
+  BlobGcResult,
+  BlobPublishOpts,
+  ConnState,
+  IAccessRequest,
+  IChatPub,
+  IRepoStatus,
+  IRouterInfo,
+  IWorkspaceInfo,
+} from '@/services/types';
+
 /* eslint-disable no-var */
 declare global {
   var _ndnd_store_js: StoreJS;
   var _ndnd_keychain_js: KeyChainJS;
   var _yjs_merge_updates: (updates: Uint8Array[]) => Uint8Array;
-  var _ndnd_conn_change_js: (connected: boolean, router: string) => void;
-  var _ndnd_conn_state: { connected: boolean; router: string };
-
-  // [0]: wksp name, [1]: requester name, [2]: should be suppressed (because the request has already been dealt with)
-  var _access_requests: [string,string,boolean][];
+  var _ndnd_conn_change_js: (connected: boolean, router: IRouterInfo, state: ConnState) => void;
+  var _ndnd_conn_state: { connected: boolean; state: ConnState; router: string; rtt: number };
+  var _ndnd_access_requests_js: (wksp: string, requests: IAccessRequest[]) => void;
+  var _ndnd_network_profiles_js: string | undefined;
 
   var set_ndn: undefined | ((ndn: NDNAPI) => void);
   var ndn_api: NDNAPI;
@@ -32,6 +44,10 @@ interface NDNAPI {
 
   /** Connect to the global NDN testbed */
   connect_testbed(): Promise<void>;
+  /** Get the known routers, best first */
+  list_routers(): Promise<IRouterInfo[]>;
+  /** Connect to another router */
+  set_router(uri: string): Promise<void>;
 
   /** NDNCERT email verfication challenge */
   ndncert_email(email: string, code: (status: string) => Promise<string>): Promise<void>;
@@ -41,8 +57,21 @@ interface NDNAPI {
     confirm: (recordName: string, recordValue: string, status: string) => Promise<string>,
   ): Promise<void>;
 
+  /** Get the names of the known network profiles (the first is connected to) */
+  network_profiles(): Promise<string[]>;
+  /** Select the network profile of a workspace before joining */
+  set_workspace_profile(wksp: string, profile: string): Promise<void>;
+  /** Get the network profile of a workspace */
+  get_workspace_profile(wksp: string): Promise<string>;
+
   /** Join Workspace (generate keys etc.) */
-  join_workspace(wksp: string, create: boolean): Promise<string>;
+  join_workspace(wksp: string, create: boolean, label?: string): Promise<string>;
+  /** Get the signed metadata of a workspace */
+  get_workspace_info(wksp: string): Promise<IWorkspaceInfo>;
+  /** Renew the workspace certificate if it is about to expire */
+  renew_workspace_cert(wksp: string): Promise<boolean>;
+  /** Accept a co-owner grant to the workspace (sign the co-owner key) */
+  accept_coowner_grant(wksp: string): Promise<void>;
   /** Check if the user has owner permissions on the workspace */
   is_workspace_owner(wksp: string): Promise<boolean>;
 
@@ -55,9 +84,37 @@ export interface WorkspaceAPI {
   name: string;
   /** Overall prefix of workspace */
   group: string;
+  /** Our role in the workspace */
+  role: WorkspaceRole;
 
   /** Set the encryption keys */
-  set_encrypt_keys(psk: Uint8Array, dsk: Uint8Array): Promise<void>;
+  set_encrypt_keys(psk: Uint8Array, dsk: Uint8Array, epoch: number): Promise<void>;
+  /** Add the DSK of another key epoch */
+  add_epoch_key(epoch: number, dsk: Uint8Array): Promise<void>;
+  /** Set members that are not given keys of new epochs */
+  set_removed_members(names: string[]): Promise<void>;
+  /** Set the callback to persist keys of new epochs */
+  set_on_epoch_key(cb: (epoch: number, dsk: Uint8Array) => Promise<void>): void;
+  /** Set the persisted key of a private project */
+  set_project_key(proj: string, key: Uint8Array): Promise<void>;
+  /** Set the callback to persist keys of private projects */
+  set_on_project_key(cb: (proj: string, key: Uint8Array) => Promise<void>): void;
+  /** Make a project private to the given members (owners only) */
+  set_project_members(proj: string, members: string[]): Promise<void>;
+  /** Get the members of a private project, or null if it is public */
+  project_members(proj: string): Promise<string[] | null>;
+  /** Check if we can open a project */
+  is_project_member(proj: string): Promise<boolean>;
+  /** Add members to the revocation list (owners only) */
+  revoke_members(names: string[]): Promise<void>;
+  /** Get the members in the revocation list */
+  revoked_members(): Promise<string[]>;
+  /** Get the repos storing the workspace data */
+  repos(): Promise<string[]>;
+  /** Change the repos storing the workspace data (creator only) */
+  set_repos(repos: string[]): Promise<void>;
+  /** Get the state of each repo for a project */
+  repo_status(proj: string): Promise<IRepoStatus[]>;
 
   /** Start the workspace */
   start(): Promise<void>;
@@ -77,12 +134,34 @@ export interface WorkspaceAPI {
   ): Promise<SvsAloApi>;
 
   /** Sign an invitation for a given NDN name */
-  sign_invitation(invitee: string): Promise<Uint8Array>;
+  sign_invitation(invitee: string, opts?: InviteOpts): Promise<Uint8Array>;
+  /** Sign a grant that makes a member a co-owner */
+  sign_coowner_grant(coowner: string, opts?: InviteOpts): Promise<Uint8Array>;
+
+  /** List the pending access requests (owners only) */
+  list_access_requests(): Promise<IAccessRequest[]>;
+  /** Approve an access request, returns the signed invitation */
+  approve_access_request(requester: string, opts?: InviteOpts): Promise<Uint8Array>;
+  /** Deny an access request */
+  deny_access_request(requester: string): Promise<void>;
 
   /** Wait for DSK to appear for the given key */
-  wait_for_dsk(key: Uint8Array): Promise<Uint8Array>;
+  wait_for_dsk(key: Uint8Array): Promise<{ epoch: number; dsk: Uint8Array }>;
+}
+
+/** Validity of an invitation (unix milliseconds) */
+export interface InviteOpts {
+  /** Start of the validity, defaults to now */
+  notBefore?: number;
+  /** Expiry of the invitation and the invitee's certificate */
+  notAfter?: number;
+  /** Role of the invitee, defaults to editor */
+  role?: WorkspaceRole;
 }
 
+/** Permission level of a workspace member */
+export type WorkspaceRole = 'editor' | 'commenter' | 'viewer';
+
 /** API of the SVS ALO instance */
 export interface SvsAloApi {
   /** Sync prefix of the instance */
@@ -101,16 +180,40 @@ export interface SvsAloApi {
 
   /** Publish chat message to SVS ALO */
   pub_yjs_delta(uuid: string, binary: Uint8Array): Promise<void>;
-  /** Publish blob fetch command */
-  pub_blob_fetch(name: string, encapsulate: Uint8Array | undefined): Promise<string>;
+  /** Publish typed chat message, returns undefined if queued while offline */
+  pub_chat(msg: IChatPub): Promise<string | undefined>;
+  /** Publish blob fetch command, returns undefined if queued while offline */
+  pub_blob_fetch(name: string, encapsulate: Uint8Array | undefined): Promise<string | undefined>;
+  /** Encrypt and produce a blob read chunk by chunk, then publish blob fetch */
+  publish_blob(
+    uuid: string,
+    size: number,
+    read: (offset: number, length: number) => Promise<Uint8Array>,
+    opts?: BlobPublishOpts,
+  ): Promise<string>;
+  /** Drop a reference to a content-addressed blob, returns the references left */
+  release_blob(name: string): Promise<number>;
+  /** Remove blobs not in the reachable list from the store after a grace period */
+  gc_blobs(reachable: string[], opts?: BlobGcOpts): Promise<BlobGcResult>;
+  /** Remove a blob locally (owner only), the repos keep their copy */
+  remove_blob(name: string): Promise<void>;
+  /** Fetch and decrypt a blob, written chunk by chunk in order, checking its digest */
+  fetch_blob(
+    name: string,
+    write: (chunk: Uint8Array) => Promise<void>,
+    opts?: BlobFetchOpts,
+  ): Promise<void>;
   /** Publish request for the DSK */
   pub_dsk_request(): Promise<Uint8Array>;
   /** Publish ack for the DSK response */
   pub_dsk_ack(key: Uint8Array): Promise<void>;
+  /** Mint a new DSK epoch (owner only, root project) */
+  pub_dsk_rotate(remove: string[]): Promise<{ epoch: number; dsk: Uint8Array }>;
 
   /** Set SVS ALO subscription callbacks */
   subscribe(params: {
     on_yjs_delta: SvsAloSub<{ uuid: string; binary: Uint8Array }>;
+    on_chat?: SvsAloSub<IChatPub>;
   }): Promise<void>;
 
   /** Awareness instance piggybacking on this SVS instance */
@@ -155,8 +258,9 @@ class NDNService {
     globalThis._ndnd_keychain_js = new KeyChainDexie();
     globalThis._yjs_merge_updates = Y.mergeUpdatesV2;
     globalThis._ndnd_conn_change_js = _ndnd_conn_change_js;
-    globalThis._ndnd_conn_state = { connected: false, router: String() };
-    globalThis._access_requests = new Array<[string,string,boolean]>();
+    globalThis._ndnd_conn_state = { connected: false, state: 'connecting', router: String(), rtt: 0 };
+    globalThis._ndnd_access_requests_js = _ndnd_access_requests_js;
+    globalThis._ndnd_network_profiles_js = import.meta.env.VITE_NETWORK_PROFILES || undefined;
 
     // Load the Go WASM module
     const go = new Go();
@@ -192,14 +296,21 @@ class NDNService {
   }
 }
 
-function _ndnd_conn_change_js(connected: boolean, router: string) {
+function _ndnd_conn_change_js(connected: boolean, info: IRouterInfo, state: ConnState) {
+  let router = info.uri;
   try {
     router = new URL(router).host;
   } catch {}
   try {
-    globalThis._ndnd_conn_state = { connected, router };
+    globalThis._ndnd_conn_state = { connected, state, router, rtt: info.rtt };
     GlobalBus.emit('conn-change');
   } catch {}
 }
 
+function _ndnd_access_requests_js(wksp: string, requests: IAccessRequest[]) {
+  try {
+    GlobalBus.emit('access-requests', wksp, requests);
+  } catch {}
+}
+
 export default new NDNService();
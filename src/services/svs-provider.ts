@@ -1,11 +1,19 @@
+import { EventEmitter } from 'events';
 import * as Y from 'yjs';
 import * as awareProto from 'y-protocols/awareness.js';
 
 import * as utils from '@/utils';
 
 import type { AwarenessApi, SvsAloApi, WorkspaceAPI } from '@/services/ndn';
-import type { AwarenessLocalState } from '@/services/types';
+import type {
+  AwarenessLocalState,
+  BlobFetchOpts,
+  BlobPublishOpts,
+  IBlobVersion,
+  IChatPub,
+} from '@/services/types';
 import type { ProjDb } from '@/services/database/proj_db';
+import type TypedEmitter from 'typed-emitter';
 import { Bundler } from "@/utils/bundler.ts";
 
 /**
@@ -21,6 +29,11 @@ export class SvsProvider {
   private lastCompaction = 0;
   private isCompacting = false;
 
+  /** Typed chat messages received in the group */
+  public readonly events = new EventEmitter() as TypedEmitter<{
+    chat: (msgs: IChatPub[]) => void;
+  }>;
+
   private constructor(
     private readonly db: ProjDb,
     private readonly wksp: WorkspaceAPI,
@@ -122,6 +135,9 @@ export class SvsProvider {
           console.error('Failed to apply update', e);
         }
       },
+      on_chat: async (msgs) => {
+        this.events.emit('chat', msgs);
+      },
     });
     await this.svs.start();
   }
@@ -188,33 +204,48 @@ export class SvsProvider {
   }
 
   /**
-   * Publish a blob object to the group
+   * Publish a blob object to the group.
+   * The blob is encrypted and streamed, so it is never fully held in memory.
+   * The repo is asked to fetch it once all of it is produced.
    *
    * @param uuid UUID of the document
    * @param blob Blob to publish
+   * @param opts Deduplication and progress
    *
    * @returns Name of the published blob
    */
-  public async publishBlob(uuid: string, blob: Uint8Array): Promise<string> {
-    // Seems okay to use ms time as version for now.
-    const version = Date.now();
-
-    // Place all blobs under the data prefix.
-    const name = `${this.svs.data_prefix}/32=blob/${uuid}/v=${version}`;
-    await this.wksp.produce(name, blob);
-
-    // Publish name to sync group for repo to pick up
-    await this.svs.pub_blob_fetch(name, undefined);
-
-    return name;
+  public async publishBlob(uuid: string, blob: Blob, opts?: BlobPublishOpts): Promise<string> {
+    const read = async (offset: number, length: number) =>
+      new Uint8Array(await blob.slice(offset, offset + length).arrayBuffer());
+    return await this.svs.publish_blob(uuid, blob.size, read, opts);
   }
 
   /**
    * Consume a blob object from the network.
-   * Strictly speaking, this has nothing to do with SVS, it's a pure network operation.
+   * Interrupted fetches resume from the segments already fetched.
+   *
+   * @param version Version of the blob
+   * @param opts Progress and cancellation (encrypted blobs only)
    */
-  public async consumeBlob(name: string) {
-    return await this.wksp.consume(name);
+  public async consumeBlob(version: IBlobVersion, opts?: BlobFetchOpts): Promise<Uint8Array> {
+    // Legacy blobs are plain NDN objects
+    if (!version.encrypted) {
+      const res = await this.wksp.consume(version.name);
+      return res.data;
+    }
+
+    const data = new Uint8Array(version.size);
+    let offset = 0;
+    await this.svs.fetch_blob(
+      version.name,
+      async (chunk) => {
+        if (offset + chunk.length > data.length) throw new Error('Blob is larger than expected');
+        data.set(chunk, offset);
+        offset += chunk.length;
+      },
+      opts,
+    );
+    return data.subarray(0, offset);
   }
 
   /**
@@ -16,8 +16,81 @@ export type IWkspStats = {
   psk: string;
   /** Dynamic-shared key */
   dsk: string | null;
+  /** Key epoch of the DSK (0 if absent) */
+  dskEpoch?: number;
   /** DSK request key */
   dskExch?: string;
+  /** Keys of other epochs */
+  epochKeys?: Record<number, string>;
+  /** Members removed on key rotation */
+  removed?: string[];
+  /** Keys of private projects */
+  projKeys?: Record<string, string>;
+};
+
+export type IAccessRequest = {
+  /** Identity name of the requester */
+  requester: string;
+  /** Time the request was received (unix milliseconds) */
+  time: number;
+};
+
+export type IRouterInfo = {
+  /** Endpoint of the router */
+  uri: string;
+  /** Time to connect at the last probe (milliseconds, 0 if not probed) */
+  rtt: number;
+  /** False if the router failed at the last probe or connection */
+  reachable: boolean;
+  /** True if connected to this router */
+  current: boolean;
+};
+
+/**
+ * State of the connection to the network.
+ * degraded: the connection dropped and may come back by itself
+ * offline: reconnecting with backoff, publications are queued
+ */
+export type ConnState = 'connecting' | 'up' | 'degraded' | 'offline';
+
+export type IRepoStatus = {
+  /** Name of the repo */
+  repo: string;
+  /** Status of the SyncJoin command for the project */
+  join: 'none' | 'pending' | 'acked';
+  /** Number of SyncJoin commands sent */
+  joinAttempts: number;
+  /** Time the repo joined (unix milliseconds, 0 if not joined) */
+  joinedAt: number;
+  /** Last error of a SyncJoin command */
+  error: string;
+  /** Latest snapshot of our publications held by the repo */
+  snapshot: string | null;
+  /** Number of blobs the repo is fetching */
+  blobsPending: number;
+  /** Number of blobs the repo stored */
+  blobsStored: number;
+  /** Blobs the repo did not fetch */
+  failedBlobs: string[];
+};
+
+export type IWorkspaceInfo = {
+  /** Name of the workspace */
+  name: string;
+  /** Display name of the workspace */
+  label: string;
+  /** Identity name of the owner */
+  owner: string;
+  /** Creation time (unix milliseconds) */
+  created: number;
+  /** Version of the workspace layout */
+  schemaVersion: number;
+  /** Main repo storing the workspace data */
+  repo: string;
+  /** All repos storing the workspace data, starting with the main repo */
+  repos: string[];
+  /** AEAD algorithm of publications */
+  algorithm: number;
 };
 
 export type IChatMessage = {
@@ -34,6 +107,33 @@ export type IChatMessage = {
   tsStr?: string;
 };
 
+/** Typed chat message published with pub_chat */
+export type IChatPub = {
+  /** Channel name */
+  channel: string;
+  /** Unique ID of the message, reused to edit it */
+  id: string;
+  /** Name of the author, set when publishing */
+  author?: string;
+  /** Unix time in milliseconds, now if not set */
+  timestamp?: number;
+  /** Message content */
+  body: string;
+  /** ID of the message this replies to */
+  replyTo?: string;
+  /** Blobs attached to the message */
+  attachments?: IChatAttachment[];
+};
+
+export type IChatAttachment = {
+  /** Name of the blob */
+  name: string;
+  /** File name shown to users */
+  filename: string;
+  /** Size in bytes */
+  size: number;
+};
+
 export type IChatChannel = {
   /** Channel ID */
   uuid: string;
@@ -46,6 +146,8 @@ export type IProject = {
   uuid: string;
   /** Project name */
   name: string;
+  /** Project is only visible to its members */
+  private?: boolean;
 };
 
 export type IProjectFile = {
@@ -71,6 +173,41 @@ export type IBlobVersion = {
   time: number;
   /** Size of the blob */
   size: number;
+  /** Blob is segmented and encrypted with the workspace key */
+  encrypted?: boolean;
+};
+
+/** Progress of a blob transfer, in bytes */
+export type BlobProgress = (done: number, total: number) => void;
+
+export type BlobPublishOpts = {
+  /** Name the blob by its content, so the same content is only published once */
+  dedup?: boolean;
+  /** Progress callback */
+  progress?: BlobProgress;
+};
+
+export type BlobGcOpts = {
+  /** Milliseconds an unreferenced blob is kept (default 7 days) */
+  grace?: number;
+};
+
+export type BlobGcResult = {
+  /** Number of referenced blobs */
+  kept: number;
+  /** Number of unreferenced blobs within the grace period */
+  expiring: number;
+  /** Names of the blobs removed from the store */
+  removed: string[];
+};
+
+export type BlobFetchOpts = {
+  /** Number of segments fetched in parallel (default 8) */
+  window?: number;
+  /** Progress callback */
+  progress?: BlobProgress;
+  /** Cancels the fetch, which resumes from the stored segments next time */
+  signal?: AbortSignal;
 };
 
 export type IProfile = {
@@ -2,9 +2,9 @@ import * as utils from '@/utils';
 import * as Y from 'yjs';
 
 import type { Router } from 'vue-router';
-import type { WorkspaceAPI } from '@/services/ndn';
+import type { InviteOpts, WorkspaceAPI } from '@/services/ndn';
 import type { SvsProvider } from '@/services/svs-provider';
-import type { IProfile, IWkspStats } from '@/services/types';
+import type { IAccessRequest, IProfile, IWkspStats } from '@/services/types';
 
 export class WorkspaceInviteManager {
   private readonly inviteeProfiles: Y.Map<IProfile>;
@@ -43,8 +43,9 @@ export class WorkspaceInviteManager {
    * Try to invite a profile to the workspace
    *
    * @param invitee Profile of the invitee
+   * @param opts Validity of the invitation
    */
-  public async tryInvite(invitee: IProfile): Promise<void> {
+  public async tryInvite(invitee: IProfile, opts?: InviteOpts): Promise<void> {
     // Check if the name is already in the list
     if (this.inviteeProfiles.has(invitee.name)) {
       throw new Error(`Invitation for ${invitee.name} already exists`);
@@ -54,7 +55,7 @@ export class WorkspaceInviteManager {
     this.inviteeProfiles.set(invitee.name, invitee);
 
     // Publish the invitation
-    await this.invite(invitee.name); // Publish the invitation
+    await this.invite(invitee.name, opts); // Publish the invitation
   }
 
   /**
@@ -100,16 +101,66 @@ export class WorkspaceInviteManager {
    * Generate and publish an invitation for a name
    *
    * @param name NDN name to invite
+   * @param opts Validity of the invitation
    */
-  public async invite(name: string): Promise<void> {
+  public async invite(name: string, opts?: InviteOpts): Promise<void> {
     // Sign the invitation
-    const invite = await this.api.sign_invitation(name);
+    const invite = await this.api.sign_invitation(name, opts);
 
     // Alert repo to fetch the invitation
     // name is unused when encapsulated
     await this.provider.svs.pub_blob_fetch(String(), invite);
   }
 
+  /**
+   * Make a member a co-owner of the workspace and publish the grant
+   *
+   * @param name NDN name of the member
+   * @param opts Validity of the grant
+   */
+  public async grantOwner(name: string, opts?: InviteOpts): Promise<void> {
+    const grant = await this.api.sign_coowner_grant(name, opts);
+
+    // Mark the profile as owner
+    const profile = this.inviteeProfiles.get(name);
+    this.inviteeProfiles.set(name, { ...profile, name, owner: true });
+
+    // Alert repo to fetch the grant
+    await this.provider.svs.pub_blob_fetch(String(), grant);
+  }
+
+  /**
+   * Get the pending access requests to the workspace
+   */
+  public async listRequests(): Promise<IAccessRequest[]> {
+    if (!this.wsmeta.owner) return [];
+    return await this.api.list_access_requests();
+  }
+
+  /**
+   * Approve an access request and publish the invitation
+   *
+   * @param invitee Profile of the requester
+   * @param opts Validity of the invitation
+   */
+  public async approveRequest(invitee: IProfile, opts?: InviteOpts): Promise<void> {
+    // Answers the pending request directly if it did not expire
+    const invite = await this.api.approve_access_request(invitee.name, opts);
+
+    // Add invitee and let the repo store the invitation for later
+    this.inviteeProfiles.set(invitee.name, invitee);
+    await this.provider.svs.pub_blob_fetch(String(), invite);
+  }
+
+  /**
+   * Deny an access request
+   *
+   * @param name NDN name of the requester
+   */
+  public async denyRequest(name: string): Promise<void> {
+    await this.api.deny_access_request(name);
+  }
+
   /**
    * Get the join link for the workspace
    * @param router Vue router instance
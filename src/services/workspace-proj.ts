@@ -8,7 +8,13 @@ import * as utils from '@/utils';
 import { nanoid } from 'nanoid';
 
 import type { WorkspaceAPI } from './ndn';
-import type { IBlobVersion, IProject, IProjectFile } from './types';
+import type {
+  BlobGcResult,
+  BlobProgress,
+  IBlobVersion,
+  IProject,
+  IProjectFile,
+} from './types';
 import {
   excalidrawToFile,
   type ExcalidrawElementYMap,
@@ -16,6 +22,9 @@ import {
 } from './excalidraw-types';
 import type { ImportedDataState } from '@excalidraw/excalidraw/data/types';
 
+/** Delay after opening a project before unreferenced blobs are collected */
+const BLOB_GC_DELAY = 60 * 1000;
+
 /**
  * Project manager for the workspace.
  * Keeps track of the list of projects and their instances.
@@ -32,7 +41,7 @@ export class WorkspaceProjManager {
   ) {
     this.list = this.root.getMap<IProject>('list');
 
-    const listObserver = async () => GlobalBus.emit('project-list', this.getProjects());
+    const listObserver = async () => GlobalBus.emit('project-list', await this.getVisibleProjects());
     this.list.observe(listObserver);
     listObserver();
   }
@@ -64,6 +73,33 @@ export class WorkspaceProjManager {
     return Array.from(this.list.values());
   }
 
+  /** Get the list of projects, without private projects we are not a member of */
+  public async getVisibleProjects(): Promise<IProject[]> {
+    const visible = await Promise.all(
+      this.getProjects().map((p) => !p.private || this.wksp.is_project_member(p.uuid)),
+    );
+    return this.getProjects().filter((_, i) => visible[i]);
+  }
+
+  /**
+   * Make a project private to the given members (owners only).
+   * Owners can always open all projects.
+   */
+  public async setMembers(name: string, members: string[]) {
+    const pmeta = this.getProjects().find((p) => p.name === name);
+    if (!pmeta) throw new Error('Project not found');
+
+    await this.wksp.set_project_members(pmeta.uuid, members);
+    this.list.set(pmeta.uuid, { ...pmeta, private: true });
+  }
+
+  /** Get the members of a private project, or null if it is public */
+  public async getMembers(name: string): Promise<string[] | null> {
+    const pmeta = this.getProjects().find((p) => p.name === name);
+    if (!pmeta) throw new Error('Project not found');
+    return await this.wksp.project_members(pmeta.uuid);
+  }
+
   /** Create a new project */
   public async newProject(name: string) {
     if (!name) throw new Error('Project name is required');
@@ -94,6 +130,7 @@ export class WorkspaceProjManager {
  */
 export class WorkspaceProj {
   private readonly fileMap: Y.Map<IProjectFile>;
+  private gcTimer: ReturnType<typeof setTimeout> | null = null;
 
   private constructor(
     public readonly uuid: string,
@@ -128,11 +165,20 @@ export class WorkspaceProj {
     const root = await provider.getDoc('root');
 
     // Create project object
-    return new WorkspaceProj(uuid, name, root, provider, manager);
+    const proj = new WorkspaceProj(uuid, name, root, provider, manager);
+
+    // Collect unreferenced blobs once the project had time to sync
+    proj.gcTimer = setTimeout(() => {
+      proj.gcTimer = null;
+      proj.gcBlobs().catch((err) => console.warn('Blob collection failed', err));
+    }, BLOB_GC_DELAY);
+
+    return proj;
   }
 
   /** Destroy the project instance */
   public async destroy() {
+    if (this.gcTimer) clearTimeout(this.gcTimer);
     this.root.destroy();
     await this.provider.destroy();
   }
@@ -185,12 +231,14 @@ export class WorkspaceProj {
     const isFolder = path.endsWith('/');
 
     let deletedCount = 0;
+    const deletedBlobs: IProjectFile[] = [];
     this.root.transact(() => {
-      this.fileMap.forEach((_, fpath) => {
+      this.fileMap.forEach((meta, fpath) => {
         const matchFolder = isFolder && fpath.startsWith(path);
         const matchFile = fpath === path;
         if (matchFolder || matchFile) {
           this.fileMap.delete(fpath);
+          if (meta.is_blob) deletedBlobs.push(meta);
           deletedCount++;
         }
       });
@@ -199,6 +247,19 @@ export class WorkspaceProj {
     if (!deletedCount) {
       throw new Error(`File or folder not found: ${path}`);
     }
+
+    // Release deduplicated blobs, so they can be collected
+    for (const meta of deletedBlobs) {
+      const doc = new Y.Doc();
+      try {
+        await this.provider.readInto(doc, meta.uuid);
+        for (const version of doc.getArray<IBlobVersion>('blobs').toArray()) {
+          await this.provider.svs.release_blob(version.name);
+        }
+      } finally {
+        doc.destroy();
+      }
+    }
   }
 
   /**
@@ -273,7 +334,10 @@ export class WorkspaceProj {
    *
    * @returns The file content as Uint8Array.
    */
-  public async exportFile(path: string): Promise<Uint8Array | null> {
+  public async exportFile(
+    path: string,
+    opts?: { onProgress?: BlobProgress; signal?: AbortSignal },
+  ): Promise<Uint8Array | null> {
     console.debug('Exporting file:', path);
 
     // Validate the path
@@ -300,9 +364,10 @@ export class WorkspaceProj {
         if (!latest) return null;
 
         // Get the blob content from local or network
-        // TODO: this makes it very important to show progress
-        const res = await this.provider.consumeBlob(latest.name);
-        return res.data;
+        return await this.provider.consumeBlob(latest, {
+          progress: opts?.onProgress,
+          signal: opts?.signal,
+        });
       } else if (utils.isExtensionType(path, 'code')) {
         // Get the text content as UTF-8
         return toUtf8(doc.getText('text').toString());
@@ -325,6 +390,53 @@ export class WorkspaceProj {
     return null;
   }
 
+  /**
+   * Remove blobs that no file of the project references from the local store.
+   * Every version in the history of a file is referenced. Unreferenced blobs
+   * are kept for a grace period, in case the project is not fully synced.
+   *
+   * @param grace Milliseconds an unreferenced blob is kept
+   */
+  public async gcBlobs(grace?: number): Promise<BlobGcResult> {
+    const reachable: string[] = [];
+    for (const meta of this.fileMap.values()) {
+      if (!meta.is_blob) continue;
+
+      const doc = new Y.Doc();
+      try {
+        await this.provider.readInto(doc, meta.uuid);
+        for (const version of doc.getArray<IBlobVersion>('blobs').toArray()) {
+          reachable.push(version.name);
+        }
+      } finally {
+        doc.destroy();
+      }
+    }
+
+    return await this.provider.svs.gc_blobs(reachable, { grace });
+  }
+
+  /**
+   * Remove a version of a blob file, e.g. content shared by mistake.
+   * The version is dropped from the history, so other members collect it
+   * (owner only). The repos keep their copy, since they cannot delete blobs.
+   *
+   * @param path Path of the file
+   * @param name Name of the blob version
+   */
+  public async removeBlobVersion(path: string, name: string) {
+    await this.provider.svs.remove_blob(name);
+
+    const doc = await this.getFile(path);
+    try {
+      const history = doc.getArray<IBlobVersion>('blobs');
+      const index = history.toArray().findIndex((v) => v.name === name);
+      if (index >= 0) history.delete(index);
+    } finally {
+      doc.destroy();
+    }
+  }
+
   /**
    * Import a text or binary file into the project.
    *
@@ -336,8 +448,12 @@ export class WorkspaceProj {
    * @throws {Error} If attempting to replace a text with blob or vice versa.
    * @throws {Error} If text file cannot be decoded.
    * @throws {Error} If blob or text is too large.
+   *
+   * @param path Path of the file
+   * @param content Content of the file
+   * @param opts.onProgress Progress of publishing a blob
    */
-  public async importFile(path: string, content: ReadableStream<Uint8Array>) {
+  public async importFile(path: string, content: Blob, opts?: { onProgress?: BlobProgress }) {
     path = utils.normalizePath(path);
     if (path.endsWith('/')) {
       throw new Error('Cannot import file as folder');
@@ -360,12 +476,12 @@ export class WorkspaceProj {
       meta = await this.newFile(path, isBlob);
     }
 
-    // Import binary content
+    // Import binary content, streamed and encrypted
     if (isBlob) {
-      // Read the full buffer for now. We can use a stream directly in the
-      // future if we want to support big files for some reason.
-      const buffer = await new Response(content).arrayBuffer();
-      const name = await this.provider.publishBlob(meta.uuid, new Uint8Array(buffer));
+      const name = await this.provider.publishBlob(meta.uuid, content, {
+        dedup: true,
+        progress: opts?.onProgress,
+      });
 
       // Update the file version history
       const doc = await this.getFile(path);
@@ -373,7 +489,8 @@ export class WorkspaceProj {
         const version: IBlobVersion = {
           name: name,
           time: Date.now(),
-          size: buffer.byteLength,
+          size: content.size,
+          encrypted: true,
         };
         const history = doc.getArray<IBlobVersion>('blobs');
         history.unshift([version]);
@@ -387,7 +504,7 @@ export class WorkspaceProj {
 
     // Import text content
     if (isText || isMilkdown) {
-      const buffer = await new Response(content).arrayBuffer();
+      const buffer = await content.arrayBuffer();
       const doc = await this.getFile(path);
       try {
         if (isText) {
@@ -411,7 +528,7 @@ export class WorkspaceProj {
 
     // Import excalidraw figure JSON
     if (isExcalidraw) {
-      const buffer = await new Response(content).arrayBuffer();
+      const buffer = await content.arrayBuffer();
       const doc = await this.getFile(path);
       try {
         const jsonContent = JSON.parse(new TextDecoder().decode(buffer)) as ImportedDataState;
@@ -11,7 +11,7 @@ import * as utils from '@/utils/index';
 
 import type { SvsAloApi, WorkspaceAPI } from '@/services/ndn';
 import type { Router } from 'vue-router';
-import type { IWkspStats } from '@/services/types';
+import type { IRepoStatus, IWkspStats } from '@/services/types';
 
 /**
  * We keep an active instance of the open workspace.
@@ -55,7 +55,27 @@ export class Workspace {
       if (!metadata.dsk) await Workspace.findDskRoutine(metadata, api);
 
       // Set encryption keys
-      await api.set_encrypt_keys(utils.fromHex(metadata.psk), utils.fromHex(metadata.dsk!));
+      await api.set_encrypt_keys(
+        utils.fromHex(metadata.psk),
+        utils.fromHex(metadata.dsk!),
+        metadata.dskEpoch ?? 0,
+      );
+      for (const [epoch, key] of Object.entries(metadata.epochKeys ?? {})) {
+        await api.add_epoch_key(Number(epoch), utils.fromHex(key));
+      }
+      await api.set_removed_members(metadata.removed ?? []);
+      for (const [proj, key] of Object.entries(metadata.projKeys ?? {})) {
+        await api.set_project_key(proj, utils.fromHex(key));
+      }
+
+      // Persist keys of new epochs fetched from the owners
+      api.set_on_epoch_key(async (epoch, dsk) => {
+        await Workspace.saveEpochKey(metadata, epoch, dsk);
+      });
+      api.set_on_project_key(async (proj, key) => {
+        metadata.projKeys = { ...metadata.projKeys, [proj]: utils.toHex(key) };
+        await globalThis._o.stats.put(metadata.name, metadata);
+      });
 
       // Create general SVS group
       const provider = await SvsProvider.create(api, 'root');
@@ -114,6 +134,84 @@ export class Workspace {
     return await this.provider.svs.names();
   }
 
+  /**
+   * Rotate the workspace key (owner only).
+   * Members fetch the new key from the owners, except for the removed ones.
+   *
+   * @param remove Names of members to remove
+   */
+  public async rotateKey(remove: string[]): Promise<number> {
+    const { epoch, dsk } = await this.provider.svs.pub_dsk_rotate(remove);
+
+    this.metadata.removed = [...(this.metadata.removed ?? []), ...remove];
+    await Workspace.saveEpochKey(this.metadata, epoch, dsk);
+
+    return epoch;
+  }
+
+  /**
+   * Revoke members of the workspace (owner only).
+   * Their publications are rejected and the workspace key is rotated.
+   *
+   * @param names Names of members to revoke
+   */
+  public async revokeMembers(names: string[]): Promise<number> {
+    await this.api.revoke_members(names);
+    return await this.rotateKey(names);
+  }
+
+  /**
+   * Get the members that were revoked.
+   */
+  public async getRevokedMembers(): Promise<string[]> {
+    return await this.api.revoked_members();
+  }
+
+  /**
+   * Get the repos storing the workspace data.
+   */
+  public async getRepos(): Promise<string[]> {
+    return await this.api.repos();
+  }
+
+  /**
+   * Change the repos storing the workspace data.
+   * Only the creator of the workspace can do this.
+   *
+   * @param repos Names of the repos, the first is the main repo
+   */
+  public async setRepos(repos: string[]): Promise<void> {
+    await this.api.set_repos(repos);
+  }
+
+  /**
+   * Get the state of each repo for a project.
+   *
+   * @param proj Name of the project
+   */
+  public async getRepoStatus(proj: string): Promise<IRepoStatus[]> {
+    return await this.api.repo_status(proj);
+  }
+
+  /**
+   * Accept a co-owner grant from an owner of the workspace.
+   * The workspace must be reopened to use the owner permissions.
+   */
+  public async acceptOwner(): Promise<void> {
+    await ndn.api.accept_coowner_grant(this.metadata.name);
+
+    this.metadata.owner = await ndn.api.is_workspace_owner(this.metadata.name);
+    await globalThis._o.stats.put(this.metadata.name, this.metadata);
+  }
+
+  /**
+   * Persist the key of an epoch.
+   */
+  private static async saveEpochKey(metadata: IWkspStats, epoch: number, dsk: Uint8Array) {
+    metadata.epochKeys = { ...metadata.epochKeys, [epoch]: utils.toHex(dsk) };
+    await globalThis._o.stats.put(metadata.name, metadata);
+  }
+
   /**
    * Setup workspace from URL parameter.
    * @param space Workspace name from URL
@@ -217,7 +315,7 @@ export class Workspace {
     if (create && dsk) globalThis.crypto.getRandomValues(dsk);
 
     // Join workspace - this will check invitation etc.
-    const finalName = await ndn.api.join_workspace(wksp, create);
+    const finalName = await ndn.api.join_workspace(wksp, create, label);
 
     // Check if we have the owner permissions
     const isOwner = await ndn.api.is_workspace_owner(finalName);
@@ -264,10 +362,11 @@ export class Workspace {
 
       // Wait for DSK key or throw error
       const dskExch = utils.fromHex(metadata.dskExch);
-      const dsk = await api.wait_for_dsk(dskExch);
+      const { epoch, dsk } = await api.wait_for_dsk(dskExch);
 
       // Persist the DSK key
       metadata.dsk = utils.toHex(dsk);
+      metadata.dskEpoch = epoch;
       await globalThis._o.stats.put(metadata.name, metadata);
 
       // Acknowledge the DSK key